@@ -623,6 +623,21 @@ func (app *App) Name(name string) Router {
 	return app
 }
 
+// Meta Assign metadata to specific route.
+// For GET routes the metadata is shared with the automatically registered HEAD route.
+//
+//	app.Post("/orders", handler).Meta("perm", "orders:write")
+func (app *App) Meta(key string, value interface{}) Router {
+	app.mutex.Lock()
+	if app.latestRoute.Metadata == nil {
+		app.latestRoute.Metadata = make(map[string]interface{})
+	}
+	app.latestRoute.Metadata[key] = value
+	app.mutex.Unlock()
+
+	return app
+}
+
 // GetRoute Get route by name
 func (app *App) GetRoute(name string) Route {
 	for _, routes := range app.stack {
@@ -700,7 +715,11 @@ func (app *App) Use(args ...interface{}) Router {
 // Get registers a route for GET methods that requests a representation
 // of the specified resource. Requests using GET should only retrieve data.
 func (app *App) Get(path string, handlers ...Handler) Router {
-	return app.Head(path, handlers...).Add(MethodGet, path, handlers...)
+	app.Head(path, handlers...)
+	metadata := app.latestRoute.Metadata
+	app.Add(MethodGet, path, handlers...)
+	app.shareMetadata(metadata)
+	return app
 }
 
 // Head registers a route for HEAD methods that asks for a response identical
@@ -7,6 +7,7 @@ package fiber
 import (
 	"bytes"
 	"crypto/tls"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -672,6 +673,45 @@ func Test_App_Route_Naming(t *testing.T) {
 	utils.AssertEqual(t, "test", app.GetRoute("test").Name)
 }
 
+// go test -run Test_App_Route_Meta
+func Test_App_Route_Meta(t *testing.T) {
+	t.Parallel()
+	app := New()
+	handler := func(c *Ctx) error {
+		return c.SendString(c.Route().Metadata["perm"].(string))
+	}
+	app.Get("/orders", handler).Meta("perm", "orders:read")
+	app.Post("/orders", handler).Meta("perm", "orders:write").Name("orders.create")
+	app.Group("/api").Put("/orders", handler).Meta("perm", "orders:update").Meta("hook", func() {})
+
+	utils.AssertEqual(t, "orders:write", app.GetRoute("orders.create").Metadata["perm"])
+
+	for method, perm := range map[string]string{
+		MethodGet:  "orders:read",
+		MethodPost: "orders:write",
+	} {
+		resp, err := app.Test(httptest.NewRequest(method, "/orders", nil))
+		utils.AssertEqual(t, nil, err, "app.Test(req)")
+		body, err := io.ReadAll(resp.Body)
+		utils.AssertEqual(t, nil, err)
+		utils.AssertEqual(t, perm, string(body))
+	}
+
+	// The HEAD route of GET shares the metadata
+	for _, route := range app.GetRoutes(true) {
+		if route.Path == "/orders" && route.Method == MethodHead {
+			utils.AssertEqual(t, "orders:read", route.Metadata["perm"])
+		}
+		if route.Path == "/api/orders" {
+			utils.AssertEqual(t, "orders:update", route.Metadata["perm"])
+		}
+	}
+
+	// Metadata isn't encoded, as values may not be encodable
+	_, err := json.Marshal(app.Stack())
+	utils.AssertEqual(t, nil, err)
+}
+
 func Test_App_New(t *testing.T) {
 	app := New()
 	app.Get("/", testEmptyHandler)
@@ -1117,6 +1157,26 @@ func Test_App_Group(t *testing.T) {
 	// utils.AssertEqual(t, "/test/v1/users", resp.Header.Get("Location"), "Location")
 }
 
+// go test -run Test_App_Group_Chaining
+func Test_App_Group_Chaining(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	grp := app.Group("/api").Name("api.")
+	grp.Get("/a", testEmptyHandler).Get("/b", testEmptyHandler).Name("b")
+
+	testStatus200(t, app, "/api/a", MethodGet)
+	testStatus200(t, app, "/api/b", MethodGet)
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/b", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, StatusNotFound, resp.StatusCode, "Status code")
+
+	// Name chained after a route names the route
+	utils.AssertEqual(t, "/api/b", app.GetRoute("api.b").Path)
+	utils.AssertEqual(t, "api.", grp.(*Group).name)
+}
+
 func Test_App_Route(t *testing.T) {
 	dummyHandler := testEmptyHandler
 
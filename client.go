@@ -2,6 +2,7 @@ package fiber
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"encoding/xml"
@@ -52,6 +53,71 @@ type Args = fasthttp.Args
 // Copy from fasthttp
 type RetryIfFunc = fasthttp.RetryIfFunc
 
+// ContextPropagator copies values carried by a context, i.e. a trace or a
+// request ID, into the headers of an outgoing request.
+type ContextPropagator = func(ctx context.Context, req *Request)
+
+var (
+	propagatorsMutex sync.RWMutex
+	propagators      []*contextPropagator
+)
+
+// contextPropagator is a registered propagator, the pointer identifies
+// the registration
+type contextPropagator struct {
+	propagate ContextPropagator
+}
+
+// RegisterContextPropagator registers a propagator that is applied to requests
+// of agents with a context and to requests forwarded by the proxy middleware.
+// The returned function unregisters the propagator.
+func RegisterContextPropagator(propagator ContextPropagator) (unregister func()) {
+	p := &contextPropagator{propagate: propagator}
+
+	propagatorsMutex.Lock()
+	propagators = append(propagators, p)
+	propagatorsMutex.Unlock()
+
+	return func() {
+		propagatorsMutex.Lock()
+		defer propagatorsMutex.Unlock()
+		for i := range propagators {
+			if propagators[i] == p {
+				propagators = append(propagators[:i], propagators[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// PropagateContext applies all registered propagators to the request.
+func PropagateContext(ctx context.Context, req *Request) {
+	propagatorsMutex.RLock()
+	defer propagatorsMutex.RUnlock()
+	for _, p := range propagators {
+		p.propagate(ctx, req)
+	}
+}
+
+// Doer performs an HTTP request. It is implemented by fasthttp's
+// HostClient, PipelineClient and Client.
+type Doer interface {
+	Do(req *Request, resp *Response) error
+}
+
+// DoerFunc is an adapter to allow the use of ordinary functions as Doer.
+type DoerFunc func(req *Request, resp *Response) error
+
+// Do calls f(req, resp).
+func (f DoerFunc) Do(req *Request, resp *Response) error {
+	return f(req, resp)
+}
+
+// ClientMiddleware wraps the Doer that sends the requests of an agent, to
+// modify requests and responses, i.e. to set auth headers, or to observe
+// them for logging, metrics and tracing.
+type ClientMiddleware func(next Doer) Doer
+
 var defaultClient Client
 
 // Client implements http client.
@@ -77,6 +143,76 @@ type Client struct {
 	//
 	// Allowing for flexibility in using another json library for decoding
 	JSONDecoder utils.JSONUnmarshal
+
+	// BaseURL is prepended to the URLs of agents that are not absolute,
+	// i.e. c.Get("/users") with a BaseURL of "https://api.example.com".
+	BaseURL string
+
+	// Headers are set on the requests of all agents created by the client.
+	Headers map[string]string
+
+	// Query holds query arguments added to the URLs of all agents created
+	// by the client, unless the URL has an argument with the same key.
+	Query map[string]string
+
+	// Timeout is the default request timeout of agents.
+	Timeout time.Duration
+
+	// TLSConfig is the default tls config of agents.
+	TLSConfig *tls.Config
+
+	// Jar stores the cookies of responses and adds them to subsequent
+	// requests of all agents created by the client.
+	Jar *CookieJar
+
+	// RetryPolicy is the retry policy of the agents created by the client.
+	RetryPolicy *RetryPolicy
+
+	// Proxy is the URL of the proxy agents send requests through, see
+	// Agent.Proxy.
+	Proxy string
+
+	// ProxyFromEnvironment sends the requests of agents through the proxy
+	// of the environment if Proxy is empty, see Agent.ProxyFromEnvironment.
+	ProxyFromEnvironment bool
+
+	// UnixSocket is the path of a Unix domain socket agents dial instead
+	// of the host of the URL.
+	UnixSocket string
+
+	// Transport sends the requests of the agents created by the client
+	// instead of their HostClient, i.e. AppTransport to send requests to
+	// an app or a Recorder in tests.
+	Transport Doer
+
+	// Pool makes the agents created by the client share a connection pool
+	// per host, with the limits of the config, instead of opening
+	// connections per agent. The statistics of the pools are returned by
+	// PoolStats. Agents whose transport settings are changed, i.e. with
+	// Agent.TLSConfig or Agent.Proxy, use their own HostClient instead,
+	// while fields of the HostClient of pooled agents are ignored.
+	Pool *PoolConfig
+
+	middlewares []ClientMiddleware
+	pools       map[poolKey]*hostPool
+	poolsMutex  sync.Mutex
+}
+
+// Use adds middlewares that are applied to the requests of all agents
+// created by the client. The first middleware is the outermost one.
+//
+//	c.Use(func(next fiber.Doer) fiber.Doer {
+//		return fiber.DoerFunc(func(req *fiber.Request, resp *fiber.Response) error {
+//			req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+//			return next.Do(req, resp)
+//		})
+//	})
+func (c *Client) Use(middlewares ...ClientMiddleware) *Client {
+	c.mutex.Lock()
+	c.middlewares = append(c.middlewares, middlewares...)
+	c.mutex.Unlock()
+
+	return c
 }
 
 // Get returns a agent with http method GET.
@@ -130,9 +266,23 @@ func (c *Client) Delete(url string) *Agent {
 func (c *Client) createAgent(method, url string) *Agent {
 	a := AcquireAgent()
 	a.req.Header.SetMethod(method)
-	a.req.SetRequestURI(url)
 
 	c.mutex.RLock()
+	if c.BaseURL != "" && !strings.Contains(url, "://") {
+		url = strings.TrimRight(c.BaseURL, "/") + "/" + strings.TrimLeft(url, "/")
+	}
+	a.req.SetRequestURI(url)
+	for k, v := range c.Headers {
+		a.req.Header.Set(k, v)
+	}
+	if len(c.Query) > 0 {
+		args := a.req.URI().QueryArgs()
+		for k, v := range c.Query {
+			if !args.Has(k) {
+				args.Add(k, v)
+			}
+		}
+	}
 	a.Name = c.UserAgent
 	a.NoDefaultUserAgentHeader = c.NoDefaultUserAgentHeader
 	a.jsonDecoder = c.JSONDecoder
@@ -140,10 +290,44 @@ func (c *Client) createAgent(method, url string) *Agent {
 	if a.jsonDecoder == nil {
 		a.jsonDecoder = json.Unmarshal
 	}
+	a.timeout = c.Timeout
+	a.jar = c.Jar
+	a.middlewares = append(a.middlewares, c.middlewares...)
+	a.retryPolicy = c.RetryPolicy
+	a.transport = c.Transport
+	tlsConfig := c.TLSConfig
+	proxy, proxyFromEnvironment, unixSocket := c.Proxy, c.ProxyFromEnvironment, c.UnixSocket
+	poolConfig := c.Pool
 	c.mutex.RUnlock()
 
 	if err := a.Parse(); err != nil {
 		a.errs = append(a.errs, err)
+		return a
+	}
+
+	if tlsConfig != nil {
+		// Clone the config, as the agent may modify it
+		a.HostClient.TLSConfig = tlsConfig.Clone()
+	}
+	switch {
+	case unixSocket != "":
+		a.UnixSocket(unixSocket)
+	case proxy != "":
+		a.Proxy(proxy)
+	case proxyFromEnvironment:
+		a.ProxyFromEnvironment()
+	}
+	if poolConfig != nil {
+		c.usePool(a, poolKey{
+			addr:                     a.HostClient.Addr,
+			isTLS:                    a.HostClient.IsTLS,
+			name:                     a.HostClient.Name,
+			noDefaultUserAgentHeader: a.NoDefaultUserAgentHeader,
+			tlsConfig:                tlsConfig,
+			proxy:                    proxy,
+			proxyFromEnvironment:     proxyFromEnvironment,
+			unixSocket:               unixSocket,
+		}, poolConfig)
 	}
 
 	return a
@@ -164,6 +348,7 @@ type Agent struct {
 
 	req               *Request
 	resp              *Response
+	ctx               context.Context
 	dest              []byte
 	args              *Args
 	timeout           time.Duration
@@ -173,10 +358,20 @@ type Agent struct {
 	mw                multipartWriter
 	jsonEncoder       utils.JSONMarshal
 	jsonDecoder       utils.JSONUnmarshal
+	middlewares       []ClientMiddleware
+	retryPolicy       *RetryPolicy
+	attemptTimeout    time.Duration
+	jar               *CookieJar
+	transport         Doer
+	pool              *hostPool
+	result            interface{}
+	errorResult       interface{}
+	streamBody        *clientStream
 	maxRedirectsCount int
 	boundary          string
 	reuse             bool
 	parsed            bool
+	stream            bool
 }
 
 // Parse initializes URI and HostClient.
@@ -460,7 +655,9 @@ func (a *Agent) Body(body []byte) *Agent {
 // If bodySize is >= 0, then the bodyStream must provide exactly bodySize bytes
 // before returning io.EOF.
 //
-// If bodySize < 0, then bodyStream is read until io.EOF.
+// If bodySize < 0, then bodyStream is read until io.EOF and sent with
+// chunked transfer encoding, i.e. to send large bodies of unknown size
+// without buffering them in memory.
 //
 // bodyStream.Close() is called after finishing reading all body data
 // if it implements io.Closer.
@@ -665,6 +862,7 @@ func (a *Agent) Reuse() *Agent {
 // InsecureSkipVerify controls whether the Agent verifies the server
 // certificate chain and host name.
 func (a *Agent) InsecureSkipVerify() *Agent {
+	a.pool = nil
 	if a.HostClient.TLSConfig == nil {
 		/* #nosec G402 */
 		a.HostClient.TLSConfig = &tls.Config{InsecureSkipVerify: true} // #nosec G402
@@ -678,6 +876,7 @@ func (a *Agent) InsecureSkipVerify() *Agent {
 
 // TLSConfig sets tls config.
 func (a *Agent) TLSConfig(config *tls.Config) *Agent {
+	a.pool = nil
 	a.HostClient.TLSConfig = config
 
 	return a
@@ -730,13 +929,56 @@ func (a *Agent) Dest(dest []byte) *Agent {
 }
 
 // RetryIf controls whether a retry should be attempted after an error.
+// These retries happen immediately and only for connection errors, use
+// Retry for retries with backoff.
 //
 // By default, will use isIdempotent function from fasthttp
 func (a *Agent) RetryIf(retryIf RetryIfFunc) *Agent {
+	a.pool = nil
 	a.HostClient.RetryIf = retryIf
 	return a
 }
 
+// WithContext sets the context of the request, i.e. c.UserContext() of
+// the inbound request. Values of the context are propagated into the
+// request headers by the registered context propagators.
+//
+// The request is aborted when the context is cancelled, and the remaining
+// time until the deadline of the context is used as the timeout of the
+// request, if it is shorter than the timeout of the agent. Requests with a
+// body stream are not aborted on cancellation, as the stream can't be copied.
+func (a *Agent) WithContext(ctx context.Context) *Agent {
+	a.ctx = ctx
+
+	return a
+}
+
+// CookieJar sets the cookie jar of the agent. Cookies of the jar are added
+// to the request, and cookies set by the responses are stored in the jar,
+// including the responses of redirects to the same host.
+func (a *Agent) CookieJar(jar *CookieJar) *Agent {
+	a.jar = jar
+
+	return a
+}
+
+// Transport sets the transport that sends the request instead of the
+// HostClient of the agent. The timeout of the agent isn't applied to the
+// transport, while the context of the agent is.
+func (a *Agent) Transport(transport Doer) *Agent {
+	a.transport = transport
+
+	return a
+}
+
+// Use adds middlewares that are applied to the request of the agent, after
+// the middlewares of the client.
+func (a *Agent) Use(middlewares ...ClientMiddleware) *Agent {
+	a.middlewares = append(a.middlewares, middlewares...)
+
+	return a
+}
+
 /************************** End Agent Setting **************************/
 
 // Bytes returns the status code, bytes body and errors of url.
@@ -765,6 +1007,10 @@ func (a *Agent) bytes() (code int, body []byte, errs []error) {
 		resp = a.resp
 	}
 
+	if a.ctx != nil {
+		PropagateContext(a.ctx, req)
+	}
+
 	defer func() {
 		if a.debugWriter != nil {
 			printDebugInfo(req, resp, a.debugWriter)
@@ -776,28 +1022,194 @@ func (a *Agent) bytes() (code int, body []byte, errs []error) {
 
 		body = append(a.dest, resp.Body()...)
 
+		if len(errs) == 0 && (a.result != nil || a.errorResult != nil) {
+			errs = append(errs, a.decodeResults(resp, body)...)
+		}
+
 		if nilResp {
 			ReleaseResponse(resp)
 		}
 	}()
 
-	if a.timeout > 0 {
-		if err := a.HostClient.DoTimeout(req, resp, a.timeout); err != nil {
-			errs = append(errs, err)
-			return
-		}
-	} else if a.maxRedirectsCount > 0 && (string(req.Header.Method()) == MethodGet || string(req.Header.Method()) == MethodHead) {
-		if err := a.HostClient.DoRedirects(req, resp, a.maxRedirectsCount); err != nil {
-			errs = append(errs, err)
-			return
-		}
-	} else if err := a.HostClient.Do(req, resp); err != nil {
+	if err := a.doRedirects(a.doer(), req, resp); err != nil {
 		errs = append(errs, err)
 	}
 
 	return
 }
 
+// doRedirects sends the request and follows redirects of GET and HEAD
+// requests up to the max redirects count of the agent. The cookies of the
+// jar of the agent are added to and stored from every request.
+func (a *Agent) doRedirects(doer Doer, req *Request, resp *Response) error {
+	follow := a.maxRedirectsCount > 0 && (string(req.Header.Method()) == MethodGet || string(req.Header.Method()) == MethodHead)
+
+	// Cookies set on the request are sent to every location
+	var cookies [][2]string
+	if a.jar != nil && follow {
+		req.Header.VisitAllCookie(func(key, value []byte) {
+			cookies = append(cookies, [2]string{string(key), string(value)})
+		})
+	}
+
+	// The HostClient still connects to the host of the request after a
+	// redirect to another host, so the jar isn't used for other hosts
+	var host string
+	if a.jar != nil {
+		host = hostname(req.URI())
+	}
+
+	for redirects := 0; ; redirects++ {
+		useJar := a.jar != nil && hostname(req.URI()) == host
+		if useJar {
+			a.jar.addCookies(req)
+		}
+
+		if err := a.doRetry(doer, req, resp); err != nil {
+			return err
+		}
+
+		if useJar {
+			a.jar.storeCookies(req, resp)
+		}
+
+		if !follow || !isRedirect(resp.StatusCode()) {
+			return nil
+		}
+		if redirects >= a.maxRedirectsCount {
+			return fasthttp.ErrTooManyRedirects
+		}
+		location := resp.Header.Peek(HeaderLocation)
+		if len(location) == 0 {
+			return fasthttp.ErrMissingLocation
+		}
+
+		req.URI().UpdateBytes(location)
+		if a.jar != nil {
+			req.Header.DelAllCookies()
+			for _, cookie := range cookies {
+				req.Header.SetCookie(cookie[0], cookie[1])
+			}
+		}
+		resp.Reset()
+	}
+}
+
+func isRedirect(status int) bool {
+	switch status {
+	case StatusMovedPermanently, StatusFound, StatusSeeOther, StatusTemporaryRedirect, StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// doer returns the Doer of the agent wrapped by its middlewares
+func (a *Agent) doer() Doer {
+	var doer Doer = DoerFunc(a.do)
+	for i := len(a.middlewares) - 1; i >= 0; i-- {
+		doer = a.middlewares[i](doer)
+	}
+	return doer
+}
+
+// do sends the request with the transport or the HostClient of the agent
+func (a *Agent) do(req *Request, resp *Response) error {
+	timeout := a.timeout
+	if a.attemptTimeout > 0 && (timeout == 0 || a.attemptTimeout < timeout) {
+		timeout = a.attemptTimeout
+	}
+
+	if a.ctx == nil {
+		return a.send(timeout)(req, resp)
+	}
+
+	if err := a.ctx.Err(); err != nil {
+		return err
+	}
+	if deadline, ok := a.ctx.Deadline(); ok {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return context.DeadlineExceeded
+		}
+		if timeout == 0 || remaining < timeout {
+			timeout = remaining
+		}
+	}
+
+	var err error
+	if a.ctx.Done() != nil && !req.IsBodyStream() && !a.stream {
+		err = a.doContext(req, resp, timeout)
+	} else {
+		err = a.send(timeout)(req, resp)
+	}
+	if err != nil && a.ctx.Err() != nil {
+		return a.ctx.Err()
+	}
+	return err
+}
+
+// doContext sends a copy of the request in a goroutine, so the request is
+// aborted when the context of the agent is done. The goroutine may outlive
+// the agent, so it only uses copies of the request and response.
+func (a *Agent) doContext(req *Request, resp *Response, timeout time.Duration) error {
+	reqCopy := fasthttp.AcquireRequest()
+	req.CopyTo(reqCopy)
+	respCopy := AcquireResponse()
+
+	send := a.send(timeout)
+	done := make(chan error, 1)
+	go func() {
+		done <- send(reqCopy, respCopy)
+	}()
+
+	select {
+	case err := <-done:
+		respCopy.CopyTo(resp)
+		fasthttp.ReleaseRequest(reqCopy)
+		ReleaseResponse(respCopy)
+		return err
+	case <-a.ctx.Done():
+		go func() {
+			<-done
+			fasthttp.ReleaseRequest(reqCopy)
+			ReleaseResponse(respCopy)
+		}()
+		return a.ctx.Err()
+	}
+}
+
+// send returns a function that sends a request with the transport or the
+// HostClient of the agent
+func (a *Agent) send(timeout time.Duration) func(req *Request, resp *Response) error {
+	if a.transport != nil {
+		return a.transport.Do
+	}
+	if a.stream {
+		return a.sendStream(timeout)
+	}
+	if a.pool != nil && a.pool.pipeline != nil {
+		pc := a.pool.pipeline
+		return func(req *Request, resp *Response) error {
+			if timeout > 0 {
+				return pc.DoTimeout(req, resp, timeout)
+			}
+			return pc.Do(req, resp)
+		}
+	}
+
+	hc := a.HostClient
+	if a.pool != nil {
+		hc = a.pool.hc
+	}
+
+	return func(req *Request, resp *Response) error {
+		if timeout > 0 {
+			return hc.DoTimeout(req, resp, timeout)
+		}
+		return hc.Do(req, resp)
+	}
+}
+
 func printDebugInfo(req *Request, resp *Response, w io.Writer) {
 	msg := fmt.Sprintf("Connected to %s(%s)\r\n\r\n", req.URI().Host(), resp.RemoteAddr())
 	_, _ = w.Write(utils.UnsafeBytes(msg))
@@ -818,6 +1230,10 @@ func (a *Agent) String() (int, string, []error) {
 // Struct returns the status code, bytes body and errors of url.
 // And bytes body will be unmarshalled to given v.
 //
+// The body is decoded as JSON regardless of the status code and content
+// type of the response, use [Agent.Result] and [Agent.Error] to decode
+// the body according to them.
+//
 // it's not safe to use Agent after calling [Agent.Struct]
 func (a *Agent) Struct(v interface{}) (code int, body []byte, errs []error) {
 	defer a.release()
@@ -848,6 +1264,7 @@ func (a *Agent) reset() {
 	a.HostClient = nil
 	a.req.Reset()
 	a.resp = nil
+	a.ctx = nil
 	a.dest = nil
 	a.timeout = 0
 	a.args = nil
@@ -867,6 +1284,19 @@ func (a *Agent) reset() {
 		a.formFiles[i] = nil
 	}
 	a.formFiles = a.formFiles[:0]
+	for i := range a.middlewares {
+		a.middlewares[i] = nil
+	}
+	a.middlewares = a.middlewares[:0]
+	a.retryPolicy = nil
+	a.attemptTimeout = 0
+	a.jar = nil
+	a.stream = false
+	a.streamBody = nil
+	a.transport = nil
+	a.pool = nil
+	a.result = nil
+	a.errorResult = nil
 }
 
 var (
@@ -903,6 +1333,21 @@ func ReleaseClient(c *Client) {
 	c.NoDefaultUserAgentHeader = false
 	c.JSONEncoder = nil
 	c.JSONDecoder = nil
+	c.BaseURL = ""
+	c.Headers = nil
+	c.Query = nil
+	c.Timeout = 0
+	c.TLSConfig = nil
+	c.Jar = nil
+	c.RetryPolicy = nil
+	c.Proxy = ""
+	c.ProxyFromEnvironment = false
+	c.UnixSocket = ""
+	c.Transport = nil
+	c.Pool = nil
+	c.middlewares = nil
+	c.CloseIdleConnections()
+	c.pools = nil
 
 	clientPool.Put(c)
 }
@@ -0,0 +1,377 @@
+package fiber
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2/internal/storage/memory"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/valyala/fasthttp"
+)
+
+// ClientCacheConfig defines the config of ClientCache.
+type ClientCacheConfig struct {
+	// Storage is the storage the responses are stored in.
+	//
+	// Default: an in-memory storage
+	Storage Storage
+
+	// CacheHeader is the response header reporting whether the response
+	// is served from the cache: "hit" for a fresh cached response,
+	// "revalidated" for a stale cached response the server confirmed with
+	// 304 Not Modified, and "miss" otherwise. Set to "-" to disable it.
+	//
+	// Default: X-Cache
+	CacheHeader string
+
+	// StaleExpiration is how long stale responses with an ETag or
+	// Last-Modified header are kept to revalidate them.
+	//
+	// Default: 1h
+	StaleExpiration time.Duration
+}
+
+const (
+	clientCacheHit         = "hit"
+	clientCacheMiss        = "miss"
+	clientCacheRevalidated = "revalidated"
+)
+
+// clientCacheEntry is a response stored by ClientCache
+type clientCacheEntry struct {
+	// Header is the raw response header including the status line
+	Header []byte `json:"h"`
+	Body   []byte `json:"b"`
+	// Stored is the unix time the response was stored or revalidated
+	Stored int64 `json:"s"`
+	// Expires is the unix time until the response is fresh
+	Expires int64 `json:"e"`
+	// Vary holds the request headers named by the Vary header
+	Vary map[string]string `json:"v,omitempty"`
+}
+
+// ClientCache returns a client middleware implementing an HTTP cache,
+// which stores the responses of GET requests and serves them while they
+// are fresh, according to the Cache-Control, Expires, Age and Vary headers
+// of the responses. Stale responses with an ETag or Last-Modified header
+// are revalidated with If-None-Match and If-Modified-Since requests.
+//
+// A client is usually shared by its callers, so responses to requests with
+// an Authorization or Cookie header are only stored if they have a
+// Cache-Control: public directive, and Set-Cookie headers are not stored.
+//
+// Successful requests with an unsafe method, i.e. POST, invalidate the
+// cached response of the URL. Requests with a Cache-Control: no-cache
+// header are always revalidated, while requests with Cache-Control:
+// no-store bypass the cache.
+//
+//	c := fiber.AcquireClient()
+//	c.Use(fiber.ClientCache())
+func ClientCache(config ...ClientCacheConfig) ClientMiddleware {
+	var cfg ClientCacheConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Storage == nil {
+		cfg.Storage = memory.New()
+	}
+	if cfg.CacheHeader == "" {
+		cfg.CacheHeader = "X-Cache"
+	}
+	if cfg.StaleExpiration <= 0 {
+		cfg.StaleExpiration = time.Hour
+	}
+
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *Request, resp *Response) error {
+			return cfg.do(next, req, resp)
+		})
+	}
+}
+
+func (cfg *ClientCacheConfig) do(next Doer, req *Request, resp *Response) error {
+	method := string(req.Header.Method())
+	key := MethodGet + " " + string(req.URI().FullURI())
+
+	switch method {
+	case MethodGet:
+	case MethodHead, MethodOptions, MethodTrace:
+		return next.Do(req, resp)
+	default:
+		// Unsafe methods invalidate the cached response
+		err := next.Do(req, resp)
+		if err == nil && resp.StatusCode() < StatusBadRequest {
+			_ = cfg.Storage.Delete(key)
+		}
+		return err
+	}
+
+	reqCacheControl := utils.UnsafeString(req.Header.Peek(HeaderCacheControl))
+	if hasDirective(reqCacheControl, "no-store") {
+		return next.Do(req, resp)
+	}
+
+	entry := cfg.load(key)
+	if entry != nil && !entry.varyMatch(req) {
+		entry = nil
+	}
+
+	now := time.Now().Unix()
+	if entry != nil && now < entry.Expires && !hasDirective(reqCacheControl, "no-cache") {
+		if err := entry.restore(resp, now); err == nil {
+			cfg.report(resp, clientCacheHit)
+			return nil
+		}
+	}
+
+	// Revalidate the stale response with its validators, unless the
+	// request is conditional already
+	var conditional []string
+	if entry != nil {
+		cached := AcquireResponse()
+		if err := entry.restore(cached, now); err == nil {
+			if etag := cached.Header.Peek(HeaderETag); len(etag) > 0 && len(req.Header.Peek(HeaderIfNoneMatch)) == 0 {
+				req.Header.SetBytesV(HeaderIfNoneMatch, etag)
+				conditional = append(conditional, HeaderIfNoneMatch)
+			}
+			if lastModified := cached.Header.Peek(HeaderLastModified); len(lastModified) > 0 && len(req.Header.Peek(HeaderIfModifiedSince)) == 0 {
+				req.Header.SetBytesV(HeaderIfModifiedSince, lastModified)
+				conditional = append(conditional, HeaderIfModifiedSince)
+			}
+		}
+		ReleaseResponse(cached)
+	}
+
+	err := next.Do(req, resp)
+	for _, header := range conditional {
+		req.Header.Del(header)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(conditional) > 0 && resp.StatusCode() == StatusNotModified {
+		// Update the cached response with the headers of the 304 response
+		updated := make(map[string][]byte)
+		for _, header := range []string{HeaderCacheControl, HeaderExpires, HeaderETag, HeaderLastModified, HeaderAge} {
+			if value := resp.Header.Peek(header); len(value) > 0 {
+				updated[header] = append([]byte(nil), value...)
+			}
+		}
+		if err = entry.restore(resp, now); err != nil {
+			return err
+		}
+		resp.Header.Del(HeaderAge)
+		for header, value := range updated {
+			resp.Header.SetBytesV(header, value)
+		}
+		cfg.store(key, req, resp)
+		cfg.report(resp, clientCacheRevalidated)
+		return nil
+	}
+
+	cfg.store(key, req, resp)
+	cfg.report(resp, clientCacheMiss)
+
+	return nil
+}
+
+// report sets the cache header of the response
+func (cfg *ClientCacheConfig) report(resp *Response, result string) {
+	if cfg.CacheHeader != "-" {
+		resp.Header.Set(cfg.CacheHeader, result)
+	}
+}
+
+// load returns the cached response of the key, or nil if there is none
+func (cfg *ClientCacheConfig) load(key string) *clientCacheEntry {
+	data, err := cfg.Storage.Get(key)
+	if err != nil || data == nil {
+		return nil
+	}
+	var entry clientCacheEntry
+	if err = json.Unmarshal(data, &entry); err != nil {
+		return nil
+	}
+	return &entry
+}
+
+// store stores the response if it is cacheable
+func (cfg *ClientCacheConfig) store(key string, req *Request, resp *Response) {
+	// Don't serve the response of a user to other callers
+	if (len(req.Header.Peek(HeaderAuthorization)) > 0 || len(req.Header.Peek(HeaderCookie)) > 0) &&
+		!hasDirective(utils.UnsafeString(resp.Header.Peek(HeaderCacheControl)), "public") {
+		return
+	}
+
+	lifetime, ok := freshnessLifetime(resp)
+	if !ok {
+		return
+	}
+
+	validators := len(resp.Header.Peek(HeaderETag)) > 0 || len(resp.Header.Peek(HeaderLastModified)) > 0
+	if lifetime <= 0 && !validators {
+		_ = cfg.Storage.Delete(key)
+		return
+	}
+
+	vary, ok := varyHeaders(req, resp)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	entry := clientCacheEntry{
+		Body:    append([]byte(nil), resp.Body()...),
+		Stored:  now.Unix(),
+		Expires: now.Add(lifetime).Unix(),
+		Vary:    vary,
+	}
+
+	// Cookies are set for the caller that received the response,
+	// don't replay them to the callers sharing the storage
+	var header fasthttp.ResponseHeader
+	resp.Header.CopyTo(&header)
+	header.DelAllCookies()
+	entry.Header = append([]byte(nil), header.Header()...)
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	exp := lifetime
+	if validators {
+		exp += cfg.StaleExpiration
+	}
+	if exp <= 0 {
+		// An expiration of zero never expires
+		_ = cfg.Storage.Delete(key)
+		return
+	}
+	_ = cfg.Storage.Set(key, data, exp)
+}
+
+// restore sets the response to the cached response
+func (e *clientCacheEntry) restore(resp *Response, now int64) error {
+	resp.Reset()
+	if err := resp.Header.Read(bufio.NewReader(bytes.NewReader(e.Header))); err != nil {
+		return err
+	}
+	resp.SetBody(e.Body)
+
+	age, _ := strconv.ParseInt(string(resp.Header.Peek(HeaderAge)), 10, 64)
+	if elapsed := now - e.Stored; elapsed > 0 {
+		age += elapsed
+	}
+	if age > 0 {
+		resp.Header.Set(HeaderAge, strconv.FormatInt(age, 10))
+	}
+	return nil
+}
+
+// varyMatch reports whether the request headers named by the Vary header
+// of the cached response match the request
+func (e *clientCacheEntry) varyMatch(req *Request) bool {
+	for header, value := range e.Vary {
+		if string(req.Header.Peek(header)) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// varyHeaders returns the request headers named by the Vary header of the
+// response, it reports false if the response varies on all headers
+func varyHeaders(req *Request, resp *Response) (map[string]string, bool) {
+	var vary map[string]string
+	for _, header := range strings.Split(string(resp.Header.Peek(HeaderVary)), ",") {
+		header = strings.TrimSpace(header)
+		if header == "" {
+			continue
+		}
+		if header == "*" {
+			return nil, false
+		}
+		if vary == nil {
+			vary = make(map[string]string)
+		}
+		vary[header] = string(req.Header.Peek(header))
+	}
+	return vary, true
+}
+
+// freshnessLifetime returns how long the response is fresh, it reports
+// false if the response must not be stored
+func freshnessLifetime(resp *Response) (time.Duration, bool) {
+	switch resp.StatusCode() {
+	case StatusOK, StatusNonAuthoritativeInformation, StatusNoContent, StatusMultipleChoices,
+		StatusMovedPermanently, StatusPermanentRedirect, StatusNotFound, StatusMethodNotAllowed,
+		StatusGone, StatusRequestURITooLong, StatusNotImplemented:
+	default:
+		return 0, false
+	}
+
+	cacheControl := utils.UnsafeString(resp.Header.Peek(HeaderCacheControl))
+	if hasDirective(cacheControl, "no-store") {
+		return 0, false
+	}
+	if hasDirective(cacheControl, "no-cache") {
+		return 0, true
+	}
+
+	age, err := strconv.Atoi(string(resp.Header.Peek(HeaderAge)))
+	if err != nil || age < 0 {
+		age = 0
+	}
+
+	if maxAge, ok := directiveValue(cacheControl, "max-age"); ok {
+		seconds, err := strconv.Atoi(maxAge)
+		if err != nil {
+			return 0, true
+		}
+		return time.Duration(seconds-age) * time.Second, true
+	}
+
+	if expires := resp.Header.Peek(HeaderExpires); len(expires) > 0 {
+		expiresAt, err := http.ParseTime(string(expires))
+		if err != nil {
+			// Invalid dates like "0" represent a time in the past
+			return 0, true
+		}
+		date := time.Now()
+		if d, err := http.ParseTime(string(resp.Header.Peek(HeaderDate))); err == nil {
+			date = d
+		}
+		return expiresAt.Sub(date) - time.Duration(age)*time.Second, true
+	}
+
+	return 0, true
+}
+
+// hasDirective reports whether the Cache-Control header has the directive
+func hasDirective(cacheControl, directive string) bool {
+	_, ok := directiveValue(cacheControl, directive)
+	return ok
+}
+
+// directiveValue returns the value of the directive of the Cache-Control
+// header and reports whether the directive is present
+func directiveValue(cacheControl, directive string) (string, bool) {
+	for _, part := range strings.Split(cacheControl, ",") {
+		part = strings.TrimSpace(part)
+		name, value := part, ""
+		if i := strings.IndexByte(part, '='); i >= 0 {
+			name, value = strings.TrimSpace(part[:i]), strings.Trim(strings.TrimSpace(part[i+1:]), `"`)
+		}
+		if utils.EqualFold(name, directive) {
+			return value, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,332 @@
+package fiber
+
+import (
+	"bufio"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2/internal/storage/memory"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// cacheTestGet sends a GET request and returns the body and cache header
+func cacheTestGet(t *testing.T, c *Client, url string, headers ...string) (string, string) {
+	t.Helper()
+
+	resp := AcquireResponse()
+	defer ReleaseResponse(resp)
+
+	a := c.Get(url).SetResponse(resp)
+	for i := 1; i < len(headers); i += 2 {
+		a.Set(headers[i-1], headers[i])
+	}
+
+	code, body, errs := a.String()
+	utils.AssertEqual(t, 0, len(errs))
+	utils.AssertEqual(t, StatusOK, code)
+
+	return body, string(resp.Header.Peek("X-Cache"))
+}
+
+func Test_Client_Cache(t *testing.T) {
+	t.Parallel()
+
+	app := New()
+
+	var requests, notModified int32
+	app.Use(func(c *Ctx) error {
+		atomic.AddInt32(&requests, 1)
+		return c.Next()
+	})
+	app.Get("/max-age", func(c *Ctx) error {
+		c.Set(HeaderCacheControl, "public, max-age=60")
+		return c.SendString("max-age " + strconv.Itoa(int(atomic.LoadInt32(&requests))))
+	})
+	app.Get("/expires", func(c *Ctx) error {
+		c.Set(HeaderExpires, time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+		return c.SendString("expires " + strconv.Itoa(int(atomic.LoadInt32(&requests))))
+	})
+	app.Get("/expired", func(c *Ctx) error {
+		c.Set(HeaderExpires, "0")
+		return c.SendString("expired " + strconv.Itoa(int(atomic.LoadInt32(&requests))))
+	})
+	app.Get("/no-store", func(c *Ctx) error {
+		c.Set(HeaderCacheControl, "no-store")
+		return c.SendString("no-store " + strconv.Itoa(int(atomic.LoadInt32(&requests))))
+	})
+	app.Get("/etag", func(c *Ctx) error {
+		c.Set(HeaderCacheControl, "no-cache")
+		c.Set(HeaderETag, `"v1"`)
+		if c.Get(HeaderIfNoneMatch) == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			return c.SendStatus(StatusNotModified)
+		}
+		return c.SendString("etag")
+	})
+	app.Get("/last-modified", func(c *Ctx) error {
+		lastModified := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat)
+		c.Set(HeaderCacheControl, "max-age=0")
+		c.Set(HeaderLastModified, lastModified)
+		if c.Get(HeaderIfModifiedSince) == lastModified {
+			atomic.AddInt32(&notModified, 1)
+			return c.SendStatus(StatusNotModified)
+		}
+		return c.SendString("last-modified")
+	})
+	app.Get("/vary", func(c *Ctx) error {
+		c.Set(HeaderCacheControl, "max-age=60")
+		c.Set(HeaderVary, HeaderAcceptLanguage)
+		return c.SendString(c.Get(HeaderAcceptLanguage))
+	})
+	app.Get("/private", func(c *Ctx) error {
+		c.Set(HeaderCacheControl, "max-age=60")
+		return c.SendString("private " + c.Get(HeaderAuthorization) + c.Cookies("session"))
+	})
+	app.Post("/max-age", func(c *Ctx) error {
+		return c.SendStatus(StatusNoContent)
+	})
+
+	c := AcquireClient()
+	defer ReleaseClient(c)
+	c.Transport = AppTransport(app)
+	c.Use(ClientCache())
+
+	t.Run("max-age", func(t *testing.T) {
+		body, cache := cacheTestGet(t, c, "http://example.com/max-age")
+		utils.AssertEqual(t, "max-age 1", body)
+		utils.AssertEqual(t, clientCacheMiss, cache)
+
+		body, cache = cacheTestGet(t, c, "http://example.com/max-age")
+		utils.AssertEqual(t, "max-age 1", body)
+		utils.AssertEqual(t, clientCacheHit, cache)
+		utils.AssertEqual(t, int32(1), atomic.LoadInt32(&requests))
+
+		// Other URLs are cached separately
+		body, cache = cacheTestGet(t, c, "http://example.com/max-age?page=2")
+		utils.AssertEqual(t, "max-age 2", body)
+		utils.AssertEqual(t, clientCacheMiss, cache)
+
+		// Requests with no-cache are revalidated
+		body, cache = cacheTestGet(t, c, "http://example.com/max-age", HeaderCacheControl, "no-cache")
+		utils.AssertEqual(t, "max-age 3", body)
+		utils.AssertEqual(t, clientCacheMiss, cache)
+
+		// Unsafe methods invalidate the cached response
+		code, _, errs := c.Post("http://example.com/max-age").String()
+		utils.AssertEqual(t, 0, len(errs))
+		utils.AssertEqual(t, StatusNoContent, code)
+
+		body, cache = cacheTestGet(t, c, "http://example.com/max-age")
+		utils.AssertEqual(t, "max-age 5", body)
+		utils.AssertEqual(t, clientCacheMiss, cache)
+	})
+
+	t.Run("expires", func(t *testing.T) {
+		body, cache := cacheTestGet(t, c, "http://example.com/expires")
+		utils.AssertEqual(t, clientCacheMiss, cache)
+
+		cached, cache := cacheTestGet(t, c, "http://example.com/expires")
+		utils.AssertEqual(t, body, cached)
+		utils.AssertEqual(t, clientCacheHit, cache)
+
+		body, cache = cacheTestGet(t, c, "http://example.com/expired")
+		utils.AssertEqual(t, clientCacheMiss, cache)
+
+		fresh, cache := cacheTestGet(t, c, "http://example.com/expired")
+		utils.AssertEqual(t, false, body == fresh)
+		utils.AssertEqual(t, clientCacheMiss, cache)
+	})
+
+	t.Run("no-store", func(t *testing.T) {
+		body, cache := cacheTestGet(t, c, "http://example.com/no-store")
+		utils.AssertEqual(t, clientCacheMiss, cache)
+
+		fresh, cache := cacheTestGet(t, c, "http://example.com/no-store")
+		utils.AssertEqual(t, false, body == fresh)
+		utils.AssertEqual(t, clientCacheMiss, cache)
+	})
+
+	t.Run("etag", func(t *testing.T) {
+		body, cache := cacheTestGet(t, c, "http://example.com/etag")
+		utils.AssertEqual(t, "etag", body)
+		utils.AssertEqual(t, clientCacheMiss, cache)
+
+		body, cache = cacheTestGet(t, c, "http://example.com/etag")
+		utils.AssertEqual(t, "etag", body)
+		utils.AssertEqual(t, clientCacheRevalidated, cache)
+		utils.AssertEqual(t, int32(1), atomic.LoadInt32(&notModified))
+	})
+
+	t.Run("last-modified", func(t *testing.T) {
+		body, cache := cacheTestGet(t, c, "http://example.com/last-modified")
+		utils.AssertEqual(t, "last-modified", body)
+		utils.AssertEqual(t, clientCacheMiss, cache)
+
+		body, cache = cacheTestGet(t, c, "http://example.com/last-modified")
+		utils.AssertEqual(t, "last-modified", body)
+		utils.AssertEqual(t, clientCacheRevalidated, cache)
+		utils.AssertEqual(t, int32(2), atomic.LoadInt32(&notModified))
+	})
+
+	t.Run("authenticated", func(t *testing.T) {
+		// Responses to authenticated requests aren't shared
+		body, cache := cacheTestGet(t, c, "http://example.com/private", HeaderAuthorization, "alice")
+		utils.AssertEqual(t, "private alice", body)
+		utils.AssertEqual(t, clientCacheMiss, cache)
+
+		body, cache = cacheTestGet(t, c, "http://example.com/private", HeaderCookie, "session=bob")
+		utils.AssertEqual(t, "private bob", body)
+		utils.AssertEqual(t, clientCacheMiss, cache)
+
+		body, cache = cacheTestGet(t, c, "http://example.com/private")
+		utils.AssertEqual(t, "private ", body)
+		utils.AssertEqual(t, clientCacheMiss, cache)
+
+		// Unless they are public
+		before := atomic.LoadInt32(&requests)
+		body, cache = cacheTestGet(t, c, "http://example.com/max-age?user=alice", HeaderAuthorization, "alice")
+		utils.AssertEqual(t, clientCacheMiss, cache)
+
+		cached, cache := cacheTestGet(t, c, "http://example.com/max-age?user=alice")
+		utils.AssertEqual(t, body, cached)
+		utils.AssertEqual(t, clientCacheHit, cache)
+		utils.AssertEqual(t, before+1, atomic.LoadInt32(&requests))
+	})
+
+	t.Run("vary", func(t *testing.T) {
+		body, cache := cacheTestGet(t, c, "http://example.com/vary", HeaderAcceptLanguage, "en")
+		utils.AssertEqual(t, "en", body)
+		utils.AssertEqual(t, clientCacheMiss, cache)
+
+		body, cache = cacheTestGet(t, c, "http://example.com/vary", HeaderAcceptLanguage, "de")
+		utils.AssertEqual(t, "de", body)
+		utils.AssertEqual(t, clientCacheMiss, cache)
+
+		body, cache = cacheTestGet(t, c, "http://example.com/vary", HeaderAcceptLanguage, "de")
+		utils.AssertEqual(t, "de", body)
+		utils.AssertEqual(t, clientCacheHit, cache)
+	})
+}
+
+func Test_Client_Cache_SetCookie(t *testing.T) {
+	t.Parallel()
+
+	app := New()
+	app.Get("/", func(c *Ctx) error {
+		c.Set(HeaderCacheControl, "public, max-age=60")
+		c.Cookie(&Cookie{Name: "session", Value: "secret"})
+		return c.SendString("cookie")
+	})
+
+	c := AcquireClient()
+	defer ReleaseClient(c)
+	c.Transport = AppTransport(app)
+	c.Use(ClientCache())
+
+	resp := AcquireResponse()
+	defer ReleaseResponse(resp)
+
+	code, body, errs := c.Get("http://example.com/").SetResponse(resp).String()
+	utils.AssertEqual(t, 0, len(errs))
+	utils.AssertEqual(t, StatusOK, code)
+	utils.AssertEqual(t, "cookie", body)
+	utils.AssertEqual(t, clientCacheMiss, string(resp.Header.Peek("X-Cache")))
+	utils.AssertEqual(t, "session=secret; path=/; SameSite=Lax", string(resp.Header.PeekCookie("session")))
+
+	// The cookie isn't replayed from the cache
+	resp.Reset()
+	code, body, errs = c.Get("http://example.com/").SetResponse(resp).String()
+	utils.AssertEqual(t, 0, len(errs))
+	utils.AssertEqual(t, StatusOK, code)
+	utils.AssertEqual(t, "cookie", body)
+	utils.AssertEqual(t, clientCacheHit, string(resp.Header.Peek("X-Cache")))
+	utils.AssertEqual(t, "", string(resp.Header.PeekCookie("session")))
+	utils.AssertEqual(t, StatusOK, resp.StatusCode())
+}
+
+func Test_Client_Cache_Config(t *testing.T) {
+	t.Parallel()
+
+	app := New()
+
+	app.Get("/", func(c *Ctx) error {
+		c.Set(HeaderCacheControl, "max-age=60")
+		c.Set(HeaderAge, "10")
+		return c.SendString("config")
+	})
+
+	storage := memory.New()
+
+	c := AcquireClient()
+	defer ReleaseClient(c)
+	c.Transport = AppTransport(app)
+	c.Use(ClientCache(ClientCacheConfig{
+		Storage:     storage,
+		CacheHeader: "-",
+	}))
+
+	_, cache := cacheTestGet(t, c, "http://example.com")
+	utils.AssertEqual(t, "", cache)
+
+	data, err := storage.Get("GET http://example.com/")
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, true, len(data) > 0)
+
+	resp := AcquireResponse()
+	defer ReleaseResponse(resp)
+
+	code, body, errs := c.Get("http://example.com").SetResponse(resp).String()
+	utils.AssertEqual(t, 0, len(errs))
+	utils.AssertEqual(t, StatusOK, code)
+	utils.AssertEqual(t, "config", body)
+	utils.AssertEqual(t, "10", string(resp.Header.Peek(HeaderAge)))
+}
+
+// go test -run Test_Client_Cache_Freshness
+func Test_Client_Cache_Freshness(t *testing.T) {
+	t.Parallel()
+
+	date := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		status   int
+		headers  map[string]string
+		lifetime time.Duration
+		store    bool
+	}{
+		{StatusOK, nil, 0, true},
+		{StatusOK, map[string]string{HeaderCacheControl: "max-age=60"}, time.Minute, true},
+		{StatusOK, map[string]string{HeaderCacheControl: `private, max-age="30"`}, 30 * time.Second, true},
+		{StatusOK, map[string]string{HeaderCacheControl: "max-age=60", HeaderAge: "20"}, 40 * time.Second, true},
+		{StatusOK, map[string]string{HeaderCacheControl: "no-cache, max-age=60"}, 0, true},
+		{StatusOK, map[string]string{HeaderCacheControl: "No-Store"}, 0, false},
+		{StatusOK, map[string]string{
+			HeaderDate:    date.Format(http.TimeFormat),
+			HeaderExpires: date.Add(time.Hour).Format(http.TimeFormat),
+		}, time.Hour, true},
+		{StatusOK, map[string]string{HeaderCacheControl: "max-age=60", HeaderExpires: "0"}, time.Minute, true},
+		{StatusNotFound, map[string]string{HeaderCacheControl: "max-age=60"}, time.Minute, true},
+		{StatusInternalServerError, map[string]string{HeaderCacheControl: "max-age=60"}, 0, false},
+	}
+
+	for i, tt := range tests {
+		// The Date header is only kept when it is read
+		raw := "HTTP/1.1 " + strconv.Itoa(tt.status) + " " + utils.StatusMessage(tt.status) + "\r\n"
+		for k, v := range tt.headers {
+			raw += k + ": " + v + "\r\n"
+		}
+
+		resp := AcquireResponse()
+		utils.AssertEqual(t, nil, resp.Header.Read(bufio.NewReader(strings.NewReader(raw+"\r\n"))))
+
+		lifetime, store := freshnessLifetime(resp)
+		utils.AssertEqual(t, tt.store, store, strconv.Itoa(i))
+		utils.AssertEqual(t, tt.lifetime, lifetime, strconv.Itoa(i))
+
+		ReleaseResponse(resp)
+	}
+}
@@ -0,0 +1,215 @@
+package fiber
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2/internal/bytebufferpool"
+	"github.com/gofiber/fiber/v2/internal/msgp"
+	"github.com/gofiber/fiber/v2/internal/schema"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/valyala/fasthttp"
+)
+
+// ResponseDecoder decodes a response body into v.
+type ResponseDecoder = func(body []byte, v interface{}) error
+
+var (
+	decodersMutex sync.RWMutex
+	decoders      = make(map[string]ResponseDecoder)
+)
+
+// RegisterResponseDecoder registers the decoder of response bodies with the
+// given content type, which replaces the built-in decoder of the content
+// type, if any. It is used by Agent.Result and Agent.Error.
+//
+// JSON, XML, MessagePack and form bodies are decoded without registering a
+// decoder, other formats need to be registered:
+//
+//	fiber.RegisterResponseDecoder("application/yaml", yaml.Unmarshal)
+func RegisterResponseDecoder(contentType string, decoder ResponseDecoder) {
+	decodersMutex.Lock()
+	decoders[utils.ToLower(contentType)] = decoder
+	decodersMutex.Unlock()
+}
+
+// HTTPError is returned by agents with a result or error target set by
+// Agent.Result or Agent.Error for responses with a non-2xx status code.
+type HTTPError struct {
+	// StatusCode is the status code of the response
+	StatusCode int
+	// Header holds the headers of the response
+	Header http.Header
+	// Body is the body of the response
+	Body []byte
+}
+
+// newHTTPError returns an HTTPError with a copy of the response
+func newHTTPError(resp *Response) *HTTPError {
+	e := &HTTPError{
+		StatusCode: resp.StatusCode(),
+		Header:     make(http.Header),
+		Body:       append([]byte(nil), resp.Body()...),
+	}
+	resp.Header.VisitAll(func(key, value []byte) {
+		e.Header.Add(string(key), string(value))
+	})
+	return e
+}
+
+// Error returns the status code and message of the response.
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("http error %d: %s", e.StatusCode, utils.StatusMessage(e.StatusCode))
+}
+
+// Result sets the target the body of successful responses with a 2xx
+// status code is decoded into, according to the content type of the
+// response. Responses with a non-2xx status code result in an *HTTPError,
+// and their body is decoded into the target set by Error, if any.
+//
+//	var user User
+//	var apiErr APIError
+//	code, body, errs := fiber.Get(url).Result(&user).Error(&apiErr).Bytes()
+func (a *Agent) Result(v interface{}) *Agent {
+	a.result = v
+
+	return a
+}
+
+// Error sets the target the body of responses with a non-2xx status code
+// is decoded into, according to the content type of the response. These
+// responses result in an *HTTPError.
+func (a *Agent) Error(v interface{}) *Agent {
+	a.errorResult = v
+
+	return a
+}
+
+// decodeResults decodes the body of the response into the result or error
+// target of the agent
+func (a *Agent) decodeResults(resp *Response, body []byte) []error {
+	status := resp.StatusCode()
+	if status >= StatusOK && status < StatusMultipleChoices {
+		if a.result == nil || len(body) == 0 {
+			return nil
+		}
+		if err := a.decode(resp.Header.ContentType(), body, a.result); err != nil {
+			return []error{err}
+		}
+		return nil
+	}
+
+	errs := []error{newHTTPError(resp)}
+	if a.errorResult != nil && len(body) > 0 {
+		if err := a.decode(resp.Header.ContentType(), body, a.errorResult); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// decode decodes the body into v with the decoder of the content type.
+// Bodies without a content type are decoded as JSON.
+func (a *Agent) decode(contentType, body []byte, v interface{}) error {
+	ctype := utils.ToLower(utils.UnsafeString(contentType))
+	if i := strings.IndexByte(ctype, ';'); i >= 0 {
+		ctype = ctype[:i]
+	}
+	ctype = strings.TrimSpace(ctype)
+
+	decoder := a.decoder(ctype)
+	if decoder == nil {
+		// Vendor specific content types like application/problem+json
+		decoder = a.decoder(utils.ParseVendorSpecificContentType(ctype))
+	}
+	if decoder == nil {
+		return fmt.Errorf("failed to decode response with content type %q", ctype)
+	}
+	return decoder(body, v)
+}
+
+// decoder returns the decoder of the content type, or nil if there is none
+func (a *Agent) decoder(ctype string) ResponseDecoder {
+	decodersMutex.RLock()
+	decoder, ok := decoders[ctype]
+	decodersMutex.RUnlock()
+	if ok {
+		return decoder
+	}
+
+	switch ctype {
+	case "", MIMEApplicationJSON:
+		if a.jsonDecoder == nil {
+			a.jsonDecoder = json.Unmarshal
+		}
+		return a.jsonDecoder
+	case MIMEApplicationXML, MIMETextXML:
+		return xml.Unmarshal
+	case MIMEApplicationForm:
+		return decodeForm
+	case mimeApplicationMsgpack, mimeApplicationXMsgpack:
+		return a.decodeMsgpack
+	}
+	return nil
+}
+
+// MessagePack content types
+const (
+	mimeApplicationMsgpack  = "application/msgpack"
+	mimeApplicationXMsgpack = "application/x-msgpack"
+)
+
+// decodeMsgpack decodes a MessagePack body into v. Types generated by msgp
+// are decoded directly, other types are decoded from the JSON representation
+// of the body with the JSON decoder, so they use json tags.
+func (a *Agent) decodeMsgpack(body []byte, v interface{}) error {
+	if u, ok := v.(msgp.Unmarshaler); ok {
+		_, err := u.UnmarshalMsg(body)
+		return err
+	}
+
+	buf := bytebufferpool.Get()
+	defer bytebufferpool.Put(buf)
+
+	if _, err := msgp.UnmarshalAsJSON(buf, body); err != nil {
+		return err
+	}
+	return a.decoder(MIMEApplicationJSON)(buf.Bytes(), v)
+}
+
+// decodeForm decodes a form body into a map or a struct with form tags
+func decodeForm(body []byte, v interface{}) error {
+	args := fasthttp.AcquireArgs()
+	defer fasthttp.ReleaseArgs(args)
+	args.ParseBytes(body)
+
+	data := make(map[string][]string)
+	args.VisitAll(func(key, value []byte) {
+		k := string(key)
+		data[k] = append(data[k], string(value))
+	})
+
+	switch out := v.(type) {
+	case *map[string][]string:
+		*out = data
+		return nil
+	case *map[string]string:
+		m := make(map[string]string, len(data))
+		for k, values := range data {
+			m[k] = values[0]
+		}
+		*out = m
+		return nil
+	}
+
+	schemaDecoder := decoderPoolMap[bodyTag].Get().(*schema.Decoder)
+	defer decoderPoolMap[bodyTag].Put(schemaDecoder)
+
+	schemaDecoder.SetAliasTag(bodyTag)
+
+	return schemaDecoder.Decode(v, data)
+}
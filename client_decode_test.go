@@ -0,0 +1,225 @@
+package fiber
+
+import (
+	"encoding/xml"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2/internal/msgp"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+type decodeUser struct {
+	XMLName xml.Name `json:"-" xml:"user"`
+	Name    string   `json:"name" xml:"name" form:"name"`
+	Age     int      `json:"age" xml:"age" form:"age"`
+}
+
+type decodeAPIError struct {
+	Message string `json:"message"`
+}
+
+func Test_Client_Agent_Result(t *testing.T) {
+	t.Parallel()
+
+	ln := fasthttputil.NewInmemoryListener()
+
+	app := New(Config{DisableStartupMessage: true})
+
+	app.Get("/json", func(c *Ctx) error {
+		return c.JSON(Map{"name": "john", "age": 20})
+	})
+	app.Get("/problem", func(c *Ctx) error {
+		c.Set(HeaderContentType, "application/problem+json; charset=utf-8")
+		return c.SendString(`{"name":"problem","age":30}`)
+	})
+	app.Get("/xml", func(c *Ctx) error {
+		return c.XML(decodeUser{Name: "doe", Age: 40})
+	})
+	app.Get("/form", func(c *Ctx) error {
+		c.Set(HeaderContentType, MIMEApplicationForm)
+		return c.SendString("name=jane&age=50")
+	})
+	app.Get("/text", func(c *Ctx) error {
+		return c.SendString("name=text")
+	})
+	app.Get("/empty", func(c *Ctx) error {
+		return c.SendStatus(StatusNoContent)
+	})
+
+	go func() { utils.AssertEqual(t, nil, app.Listener(ln)) }()
+
+	tests := []struct {
+		path string
+		user decodeUser
+	}{
+		{"/json", decodeUser{Name: "john", Age: 20}},
+		{"/problem", decodeUser{Name: "problem", Age: 30}},
+		{"/xml", decodeUser{XMLName: xml.Name{Local: "user"}, Name: "doe", Age: 40}},
+		{"/form", decodeUser{Name: "jane", Age: 50}},
+		{"/empty", decodeUser{}},
+	}
+
+	for _, tt := range tests {
+		var user decodeUser
+		a := Get("http://example.com" + tt.path).Result(&user)
+		a.HostClient.Dial = func(addr string) (net.Conn, error) { return ln.Dial() }
+
+		_, _, errs := a.Bytes()
+
+		utils.AssertEqual(t, 0, len(errs), tt.path)
+		utils.AssertEqual(t, tt.user, user, tt.path)
+	}
+
+	t.Run("form map", func(t *testing.T) {
+		var m map[string]string
+		a := Get("http://example.com/form").Result(&m)
+		a.HostClient.Dial = func(addr string) (net.Conn, error) { return ln.Dial() }
+
+		_, _, errs := a.Bytes()
+
+		utils.AssertEqual(t, 0, len(errs))
+		utils.AssertEqual(t, map[string]string{"name": "jane", "age": "50"}, m)
+	})
+
+	t.Run("unsupported content type", func(t *testing.T) {
+		var user decodeUser
+		a := Get("http://example.com/text").Result(&user)
+		a.HostClient.Dial = func(addr string) (net.Conn, error) { return ln.Dial() }
+
+		code, body, errs := a.String()
+
+		utils.AssertEqual(t, StatusOK, code)
+		utils.AssertEqual(t, "name=text", body)
+		utils.AssertEqual(t, 1, len(errs))
+		utils.AssertEqual(t, `failed to decode response with content type "text/plain"`, errs[0].Error())
+	})
+}
+
+func Test_Client_Agent_Result_Error(t *testing.T) {
+	t.Parallel()
+
+	ln := fasthttputil.NewInmemoryListener()
+
+	app := New(Config{DisableStartupMessage: true})
+
+	app.Get("/", func(c *Ctx) error {
+		c.Set("X-Request-ID", "123")
+		return c.Status(StatusNotFound).JSON(Map{"message": "user not found"})
+	})
+	app.Get("/text", func(c *Ctx) error {
+		return c.Status(StatusInternalServerError).SendString("internal error")
+	})
+
+	go func() { utils.AssertEqual(t, nil, app.Listener(ln)) }()
+
+	t.Run("error target", func(t *testing.T) {
+		var (
+			user   decodeUser
+			apiErr decodeAPIError
+		)
+		a := Get("http://example.com").Result(&user).Error(&apiErr)
+		a.HostClient.Dial = func(addr string) (net.Conn, error) { return ln.Dial() }
+
+		code, _, errs := a.Bytes()
+
+		utils.AssertEqual(t, StatusNotFound, code)
+		utils.AssertEqual(t, 1, len(errs))
+		utils.AssertEqual(t, decodeUser{}, user)
+		utils.AssertEqual(t, "user not found", apiErr.Message)
+
+		var httpErr *HTTPError
+		utils.AssertEqual(t, true, errors.As(errs[0], &httpErr))
+		utils.AssertEqual(t, StatusNotFound, httpErr.StatusCode)
+		utils.AssertEqual(t, "123", httpErr.Header.Get("X-Request-ID"))
+		utils.AssertEqual(t, MIMEApplicationJSON, httpErr.Header.Get(HeaderContentType))
+		utils.AssertEqual(t, `{"message":"user not found"}`, string(httpErr.Body))
+		utils.AssertEqual(t, "http error 404: Not Found", httpErr.Error())
+	})
+
+	t.Run("result only", func(t *testing.T) {
+		var user decodeUser
+		a := Get("http://example.com/text").Result(&user)
+		a.HostClient.Dial = func(addr string) (net.Conn, error) { return ln.Dial() }
+
+		code, _, errs := a.Bytes()
+
+		utils.AssertEqual(t, StatusInternalServerError, code)
+		utils.AssertEqual(t, 1, len(errs))
+
+		var httpErr *HTTPError
+		utils.AssertEqual(t, true, errors.As(errs[0], &httpErr))
+		utils.AssertEqual(t, "internal error", string(httpErr.Body))
+	})
+
+	t.Run("undecodable error", func(t *testing.T) {
+		var apiErr decodeAPIError
+		a := Get("http://example.com/text").Error(&apiErr)
+		a.HostClient.Dial = func(addr string) (net.Conn, error) { return ln.Dial() }
+
+		_, _, errs := a.Bytes()
+
+		utils.AssertEqual(t, 2, len(errs))
+		utils.AssertEqual(t, "http error 500: Internal Server Error", errs[0].Error())
+		utils.AssertEqual(t, `failed to decode response with content type "text/plain"`, errs[1].Error())
+	})
+}
+
+// go test -run Test_Client_Agent_Result_Msgpack
+func Test_Client_Agent_Result_Msgpack(t *testing.T) {
+	t.Parallel()
+
+	body := msgp.AppendMapHeader(nil, 2)
+	body = msgp.AppendString(body, "name")
+	body = msgp.AppendString(body, "msgpack")
+	body = msgp.AppendString(body, "age")
+	body = msgp.AppendInt(body, 60)
+
+	for _, contentType := range []string{"application/msgpack", "application/x-msgpack"} {
+		r := NewRecorder().On("", "", RecorderResponse{
+			Header: map[string]string{HeaderContentType: contentType},
+			Body:   body,
+		})
+
+		var user decodeUser
+		_, _, errs := Get("http://example.com").Transport(r).Result(&user).Bytes()
+		utils.AssertEqual(t, 0, len(errs), contentType)
+		utils.AssertEqual(t, decodeUser{Name: "msgpack", Age: 60}, user, contentType)
+	}
+
+	// Invalid bodies result in an error
+	r := NewRecorder().On("", "", RecorderResponse{
+		Header: map[string]string{HeaderContentType: "application/msgpack"},
+		Body:   []byte{0xc1},
+	})
+	var user decodeUser
+	_, _, errs := Get("http://example.com").Transport(r).Result(&user).Bytes()
+	utils.AssertEqual(t, 1, len(errs))
+}
+
+// go test -run Test_Client_RegisterResponseDecoder
+func Test_Client_RegisterResponseDecoder(t *testing.T) {
+	t.Parallel()
+
+	RegisterResponseDecoder("Application/X-Test", func(body []byte, v interface{}) error {
+		user := v.(*decodeUser)
+		parts := strings.SplitN(string(body), ":", 2)
+		user.Name = parts[0]
+		user.Age = len(parts[1])
+		return nil
+	})
+
+	r := NewRecorder().On("", "", RecorderResponse{
+		Header: map[string]string{HeaderContentType: "application/x-test"},
+		Body:   []byte("custom:abc"),
+	})
+
+	var user decodeUser
+	_, _, errs := Get("http://example.com").Transport(r).Result(&user).Bytes()
+
+	utils.AssertEqual(t, 0, len(errs))
+	utils.AssertEqual(t, decodeUser{Name: "custom", Age: 3}, user)
+}
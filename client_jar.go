@@ -0,0 +1,230 @@
+package fiber
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/valyala/fasthttp"
+)
+
+// CookieJar stores the cookies set by responses and adds them to subsequent
+// requests, following the domain, path, secure and expiry rules of RFC 6265.
+// Cookies are stored in memory.
+//
+// It is safe calling CookieJar methods from concurrently running goroutines.
+// The zero value is an empty jar ready to use.
+type CookieJar struct {
+	mu      sync.Mutex
+	cookies map[string]*jarCookie
+}
+
+// jarCookie is a cookie stored in a jar
+type jarCookie struct {
+	name     string
+	value    string
+	domain   string
+	path     string
+	hostOnly bool
+	secure   bool
+	expires  time.Time
+}
+
+// NewCookieJar returns an empty cookie jar.
+func NewCookieJar() *CookieJar {
+	return &CookieJar{}
+}
+
+// SetCookies stores the cookies received in a response to the given uri.
+// Cookies with a domain that doesn't match the host of the uri are ignored,
+// as well as cookies for a top-level domain like "com" and cookies with a
+// domain other than the IP address of an IP host.
+func (j *CookieJar) SetCookies(uri *fasthttp.URI, cookies ...*fasthttp.Cookie) {
+	host := hostname(uri)
+	now := time.Now()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.cookies == nil {
+		j.cookies = make(map[string]*jarCookie)
+	}
+
+	for _, cookie := range cookies {
+		c := &jarCookie{
+			name:   string(cookie.Key()),
+			value:  string(cookie.Value()),
+			domain: strings.ToLower(strings.TrimPrefix(string(cookie.Domain()), ".")),
+			path:   string(cookie.Path()),
+			secure: cookie.Secure(),
+		}
+
+		switch {
+		case c.domain == "":
+			c.domain, c.hostOnly = host, true
+		case net.ParseIP(host) != nil:
+			// IP addresses have no subdomains
+			if c.domain != host {
+				continue
+			}
+			c.hostOnly = true
+		case c.domain != host && strings.IndexByte(c.domain, '.') < 0:
+			// Reject top-level domains, the jar doesn't know the public
+			// suffix list to reject others like "co.uk"
+			continue
+		case !domainMatch(host, c.domain):
+			continue
+		}
+
+		if c.path == "" || c.path[0] != '/' {
+			c.path = defaultPath(uri.Path())
+		}
+
+		key := c.domain + ";" + c.path + ";" + c.name
+
+		// Max-Age takes precedence over Expires, both remove the cookie when
+		// they are in the past
+		if maxAge := cookie.MaxAge(); maxAge > 0 {
+			c.expires = now.Add(time.Duration(maxAge) * time.Second)
+		} else if maxAge < 0 {
+			delete(j.cookies, key)
+			continue
+		} else if expire := cookie.Expire(); !expire.Equal(fasthttp.CookieExpireUnlimited) {
+			if !expire.After(now) {
+				delete(j.cookies, key)
+				continue
+			}
+			c.expires = expire
+		}
+
+		j.cookies[key] = c
+	}
+}
+
+// Cookies returns the cookies to send in a request to the given uri.
+// The returned cookies should be released with fasthttp.ReleaseCookie.
+func (j *CookieJar) Cookies(uri *fasthttp.URI) []*fasthttp.Cookie {
+	var cookies []*fasthttp.Cookie
+	j.visit(uri, func(c *jarCookie) {
+		cookie := fasthttp.AcquireCookie()
+		cookie.SetKey(c.name)
+		cookie.SetValue(c.value)
+		cookies = append(cookies, cookie)
+	})
+	return cookies
+}
+
+// addCookies adds the cookies of the jar to the request. Cookies set on the
+// request take precedence over cookies of the jar.
+func (j *CookieJar) addCookies(req *Request) {
+	j.visit(req.URI(), func(c *jarCookie) {
+		if len(req.Header.Cookie(c.name)) == 0 {
+			req.Header.SetCookie(c.name, c.value)
+		}
+	})
+}
+
+// storeCookies stores the cookies set by the response to the request
+func (j *CookieJar) storeCookies(req *Request, resp *Response) {
+	var cookies []*fasthttp.Cookie
+	resp.Header.VisitAllCookie(func(_, value []byte) {
+		// fasthttp doesn't parse a Max-Age of zero or less, which removes the cookie
+		value, remove := stripExpiredMaxAge(value)
+
+		cookie := fasthttp.AcquireCookie()
+		if err := cookie.ParseBytes(value); err != nil {
+			fasthttp.ReleaseCookie(cookie)
+			return
+		}
+		if remove {
+			cookie.SetMaxAge(-1)
+		}
+		cookies = append(cookies, cookie)
+	})
+
+	j.SetCookies(req.URI(), cookies...)
+
+	for _, cookie := range cookies {
+		fasthttp.ReleaseCookie(cookie)
+	}
+}
+
+// stripExpiredMaxAge removes a Max-Age attribute of zero or less from the
+// Set-Cookie header value and reports whether it was present
+func stripExpiredMaxAge(value []byte) ([]byte, bool) {
+	parts := bytes.Split(value, []byte{';'})
+	for i, part := range parts[1:] {
+		kv := bytes.SplitN(bytes.TrimSpace(part), []byte{'='}, 2)
+		if len(kv) == 2 && bytes.EqualFold(kv[0], []byte("max-age")) {
+			v := bytes.TrimSpace(kv[1])
+			if len(v) > 0 && (v[0] == '-' || len(bytes.Trim(v, "0")) == 0) {
+				parts = append(parts[:i+1], parts[i+2:]...)
+				return bytes.Join(parts, []byte{';'}), true
+			}
+		}
+	}
+	return value, false
+}
+
+// visit calls f for every cookie matching the uri, removing expired cookies
+func (j *CookieJar) visit(uri *fasthttp.URI, f func(c *jarCookie)) {
+	host := hostname(uri)
+	path := string(uri.Path())
+	secure := bytes.Equal(uri.Scheme(), strHTTPS)
+	now := time.Now()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for key, c := range j.cookies {
+		if !c.expires.IsZero() && !c.expires.After(now) {
+			delete(j.cookies, key)
+			continue
+		}
+		if c.secure && !secure {
+			continue
+		}
+		if c.hostOnly && host != c.domain || !c.hostOnly && !domainMatch(host, c.domain) {
+			continue
+		}
+		if !pathMatch(path, c.path) {
+			continue
+		}
+		f(c)
+	}
+}
+
+// hostname returns the lower case host of the uri without port
+func hostname(uri *fasthttp.URI) string {
+	host := utils.ToLower(string(uri.Host()))
+	if i := strings.LastIndexByte(host, ':'); i >= 0 && !strings.HasSuffix(host, "]") {
+		host = host[:i]
+	}
+	return strings.Trim(host, "[]")
+}
+
+// domainMatch reports whether the host is the domain or a subdomain of it
+func domainMatch(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// pathMatch reports whether the request path is the cookie path or below it
+func pathMatch(path, cookiePath string) bool {
+	if path == cookiePath {
+		return true
+	}
+	return strings.HasPrefix(path, cookiePath) &&
+		(cookiePath[len(cookiePath)-1] == '/' || path[len(cookiePath)] == '/')
+}
+
+// defaultPath returns the directory of the request path
+func defaultPath(path []byte) string {
+	i := bytes.LastIndexByte(path, '/')
+	if i <= 0 {
+		return "/"
+	}
+	return string(path[:i])
+}
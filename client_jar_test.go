@@ -0,0 +1,210 @@
+package fiber
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+func jarCookies(jar *CookieJar, url string) map[string]string {
+	uri := fasthttp.AcquireURI()
+	defer fasthttp.ReleaseURI(uri)
+	_ = uri.Parse(nil, []byte(url))
+
+	cookies := make(map[string]string)
+	for _, cookie := range jar.Cookies(uri) {
+		cookies[string(cookie.Key())] = string(cookie.Value())
+		fasthttp.ReleaseCookie(cookie)
+	}
+	return cookies
+}
+
+func setJarCookies(jar *CookieJar, url string, setCookies ...string) {
+	uri := fasthttp.AcquireURI()
+	defer fasthttp.ReleaseURI(uri)
+	_ = uri.Parse(nil, []byte(url))
+
+	var cookies []*fasthttp.Cookie
+	for _, setCookie := range setCookies {
+		cookie := fasthttp.AcquireCookie()
+		_ = cookie.Parse(setCookie)
+		cookies = append(cookies, cookie)
+	}
+	jar.SetCookies(uri, cookies...)
+}
+
+func Test_CookieJar_Domain(t *testing.T) {
+	t.Parallel()
+
+	jar := NewCookieJar()
+	setJarCookies(jar, "http://www.example.com/",
+		"host=1",
+		"domain=2; Domain=example.com",
+		"dot=3; Domain=.www.example.com",
+		"other=4; Domain=other.com",
+		"tld=5; Domain=com",
+		"tlddot=6; Domain=.com",
+	)
+
+	utils.AssertEqual(t, map[string]string{"host": "1", "domain": "2", "dot": "3"}, jarCookies(jar, "http://www.example.com/"))
+	utils.AssertEqual(t, map[string]string{"domain": "2"}, jarCookies(jar, "http://api.example.com/"))
+	utils.AssertEqual(t, map[string]string{"domain": "2"}, jarCookies(jar, "http://example.com:8080/"))
+	utils.AssertEqual(t, map[string]string{"domain": "2", "dot": "3"}, jarCookies(jar, "http://a.www.example.com/"))
+	utils.AssertEqual(t, map[string]string{}, jarCookies(jar, "http://other.com/"))
+	utils.AssertEqual(t, map[string]string{}, jarCookies(jar, "http://notexample.com/"))
+
+	// Single label hosts may set cookies for themselves
+	setJarCookies(jar, "http://localhost/", "local=1; Domain=localhost")
+	utils.AssertEqual(t, map[string]string{"local": "1"}, jarCookies(jar, "http://localhost/"))
+
+	// IP hosts may only set cookies for the IP address
+	setJarCookies(jar, "http://127.0.0.1/", "ip=1; Domain=127.0.0.1", "suffix=2; Domain=0.0.1", "other=3; Domain=example.com")
+	utils.AssertEqual(t, map[string]string{"ip": "1"}, jarCookies(jar, "http://127.0.0.1/"))
+	utils.AssertEqual(t, map[string]string{}, jarCookies(jar, "http://a.127.0.0.1/"))
+}
+
+func Test_CookieJar_Path(t *testing.T) {
+	t.Parallel()
+
+	jar := NewCookieJar()
+	setJarCookies(jar, "http://example.com/users/1",
+		"default=1",
+		"api=2; Path=/api",
+		"root=3; Path=/",
+	)
+
+	utils.AssertEqual(t, map[string]string{"default": "1", "root": "3"}, jarCookies(jar, "http://example.com/users/2"))
+	utils.AssertEqual(t, map[string]string{"default": "1", "root": "3"}, jarCookies(jar, "http://example.com/users"))
+	utils.AssertEqual(t, map[string]string{"api": "2", "root": "3"}, jarCookies(jar, "http://example.com/api/v1"))
+	utils.AssertEqual(t, map[string]string{"root": "3"}, jarCookies(jar, "http://example.com/apiv1"))
+}
+
+func Test_CookieJar_Expiry_Secure(t *testing.T) {
+	t.Parallel()
+
+	jar := NewCookieJar()
+	setJarCookies(jar, "https://example.com/",
+		"session=1",
+		"secure=2; Secure",
+		"short=3; Max-Age=1",
+		"expired=4; Expires="+time.Now().Add(-time.Hour).UTC().Format(time.RFC1123),
+		"future=5; Expires="+time.Now().Add(time.Hour).UTC().Format(time.RFC1123),
+	)
+
+	utils.AssertEqual(t, map[string]string{"session": "1", "secure": "2", "short": "3", "future": "5"}, jarCookies(jar, "https://example.com/"))
+	utils.AssertEqual(t, map[string]string{"session": "1", "short": "3", "future": "5"}, jarCookies(jar, "http://example.com/"))
+
+	// Cookies are removed by an expiry in the past and replaced by new values
+	setJarCookies(jar, "https://example.com/", "session=; Expires="+time.Now().Add(-time.Hour).UTC().Format(time.RFC1123), "future=6")
+	utils.AssertEqual(t, map[string]string{"secure": "2", "short": "3", "future": "6"}, jarCookies(jar, "https://example.com/"))
+
+	time.Sleep(1100 * time.Millisecond)
+	utils.AssertEqual(t, map[string]string{"secure": "2", "future": "6"}, jarCookies(jar, "https://example.com/"))
+}
+
+func Test_Client_Jar(t *testing.T) {
+	t.Parallel()
+
+	ln := fasthttputil.NewInmemoryListener()
+
+	app := New(Config{DisableStartupMessage: true})
+
+	app.Get("/login", func(c *Ctx) error {
+		c.Cookie(&Cookie{Name: "session", Value: "abc"})
+		return c.Redirect("/profile")
+	})
+	app.Get("/profile", func(c *Ctx) error {
+		c.Cookie(&Cookie{Name: "seen", Value: "1"})
+		return c.SendString(c.Cookies("session") + "," + c.Cookies("explicit"))
+	})
+	app.Get("/", func(c *Ctx) error {
+		return c.SendString(c.Cookies("session") + "," + c.Cookies("seen"))
+	})
+	app.Get("/cross", func(c *Ctx) error {
+		return c.Redirect("http://other.com/leak")
+	})
+	app.Get("/leak", func(c *Ctx) error {
+		c.Cookie(&Cookie{Name: "planted", Value: "1"})
+		return c.SendString(c.Cookies("secret") + "," + c.Cookies("session"))
+	})
+	app.Get("/logout", func(c *Ctx) error {
+		c.Set(HeaderSetCookie, "session=; Max-Age=0")
+		return nil
+	})
+
+	go func() { utils.AssertEqual(t, nil, app.Listener(ln)) }()
+
+	c := AcquireClient()
+	defer ReleaseClient(c)
+	c.Jar = NewCookieJar()
+
+	// Cookies are stored and sent across redirects
+	a := c.Get("http://example.com/login").MaxRedirectsCount(1).Cookie("explicit", "x")
+	a.HostClient.Dial = func(addr string) (net.Conn, error) { return ln.Dial() }
+
+	code, body, errs := a.String()
+
+	utils.AssertEqual(t, StatusOK, code)
+	utils.AssertEqual(t, "abc,x", body)
+	utils.AssertEqual(t, 0, len(errs))
+
+	// Cookies are replayed on subsequent agents
+	a = c.Get("http://example.com/")
+	a.HostClient.Dial = func(addr string) (net.Conn, error) { return ln.Dial() }
+
+	code, body, errs = a.String()
+
+	utils.AssertEqual(t, StatusOK, code)
+	utils.AssertEqual(t, "abc,1", body)
+	utils.AssertEqual(t, 0, len(errs))
+
+	// Cookies set on the agent take precedence
+	a = c.Get("http://example.com/").Cookie("session", "override")
+	a.HostClient.Dial = func(addr string) (net.Conn, error) { return ln.Dial() }
+
+	_, body, _ = a.String()
+	utils.AssertEqual(t, "override,1", body)
+
+	// Cookies are removed by a Max-Age of zero
+	a = c.Get("http://example.com/logout")
+	a.HostClient.Dial = func(addr string) (net.Conn, error) { return ln.Dial() }
+
+	code, _, errs = a.String()
+	utils.AssertEqual(t, StatusOK, code)
+	utils.AssertEqual(t, 0, len(errs))
+	utils.AssertEqual(t, map[string]string{"seen": "1"}, jarCookies(c.Jar, "http://example.com/"))
+
+	// The jar isn't used after a redirect to another host, because the
+	// request is still sent to the original host
+	setJarCookies(c.Jar, "http://other.com/", "secret=1")
+	a = c.Get("http://example.com/cross").MaxRedirectsCount(1)
+	a.HostClient.Dial = func(addr string) (net.Conn, error) { return ln.Dial() }
+
+	code, body, errs = a.String()
+	utils.AssertEqual(t, StatusOK, code)
+	utils.AssertEqual(t, ",", body)
+	utils.AssertEqual(t, 0, len(errs))
+	utils.AssertEqual(t, map[string]string{"secret": "1"}, jarCookies(c.Jar, "http://other.com/"))
+}
+
+func Test_CookieJar_StripExpiredMaxAge(t *testing.T) {
+	t.Parallel()
+
+	for value, expected := range map[string]string{
+		"a=1; Max-Age=0; Path=/": "a=1; Path=/",
+		"a=1; max-age=-1":        "a=1",
+		"a=1; Max-Age=00":        "a=1",
+		"a=1; Max-Age=10":        "",
+		"a=1; Path=/":            "",
+	} {
+		stripped, ok := stripExpiredMaxAge([]byte(value))
+		utils.AssertEqual(t, expected != "", ok, value)
+		if ok {
+			utils.AssertEqual(t, expected, string(stripped), value)
+		}
+	}
+}
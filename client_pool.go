@@ -0,0 +1,244 @@
+package fiber
+
+import (
+	"crypto/tls"
+	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// PoolConfig configures the connection pools of a client. Agents of a
+// client with a PoolConfig share a connection pool per host.
+type PoolConfig struct {
+	// MaxConnsPerHost is the maximum number of connections per host.
+	//
+	// Default: 512, or 1 with Pipeline
+	MaxConnsPerHost int
+
+	// MaxIdleConnDuration is how long idle connections are kept open.
+	//
+	// Default: 10s
+	MaxIdleConnDuration time.Duration
+
+	// MaxConnDuration is how long connections are kept open, they are
+	// closed after the request in progress. It is ignored with Pipeline.
+	//
+	// Default: unlimited
+	MaxConnDuration time.Duration
+
+	// MaxConnWaitTimeout is how long requests wait for a free connection
+	// if all MaxConnsPerHost connections are busy, instead of failing with
+	// fasthttp.ErrNoFreeConns. It is ignored with Pipeline.
+	//
+	// Default: 0
+	MaxConnWaitTimeout time.Duration
+
+	// Pipeline sends the requests with HTTP/1.1 pipelining, i.e. multiple
+	// requests are sent on a connection without waiting for the responses.
+	// The server must support pipelining, so it is meant for high-throughput
+	// calls to internal services. Streamed requests aren't pipelined.
+	//
+	// Default: false
+	Pipeline bool
+
+	// MaxPendingRequests is the maximum number of pipelined requests in
+	// progress per host, more requests fail with fasthttp.ErrPipelineOverflow.
+	//
+	// Default: 1024
+	MaxPendingRequests int
+}
+
+// PoolStats holds the statistics of the connection pool of a host.
+type PoolStats struct {
+	// Addr is the address of the host
+	Addr string
+	// IsTLS reports whether the connections use TLS
+	IsTLS bool
+	// Open is the number of open connections
+	Open int
+	// Idle is the number of open connections without a request in progress
+	Idle int
+	// Pending is the number of requests in progress, including requests
+	// waiting for a free connection
+	Pending int
+	// DialErrors is the number of failed dials
+	DialErrors uint64
+}
+
+// poolKey identifies the agents of a client that share a connection pool
+type poolKey struct {
+	addr                     string
+	isTLS                    bool
+	name                     string
+	noDefaultUserAgentHeader bool
+	tlsConfig                *tls.Config
+	proxy                    string
+	proxyFromEnvironment     bool
+	unixSocket               string
+}
+
+// hostPool is the connection pool of a host shared by the agents of a client
+type hostPool struct {
+	// 64-bit fields first for atomic access on 32-bit platforms
+	open       int64
+	dialErrors uint64
+	addr       string
+	isTLS      bool
+	hc         *fasthttp.HostClient
+	pipeline   *fasthttp.PipelineClient
+}
+
+// PoolStats returns the statistics of the connection pools of the client
+// sorted by address. It is empty unless the Pool of the client is set.
+func (c *Client) PoolStats() []PoolStats {
+	c.poolsMutex.Lock()
+	stats := make([]PoolStats, 0, len(c.pools))
+	for _, p := range c.pools {
+		stats = append(stats, p.stats())
+	}
+	c.poolsMutex.Unlock()
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Addr != stats[j].Addr {
+			return stats[i].Addr < stats[j].Addr
+		}
+		return !stats[i].IsTLS
+	})
+	return stats
+}
+
+// CloseIdleConnections closes the idle connections of the connection pools
+// of the client.
+func (c *Client) CloseIdleConnections() {
+	c.poolsMutex.Lock()
+	defer c.poolsMutex.Unlock()
+	for _, p := range c.pools {
+		if p.hc != nil {
+			p.hc.CloseIdleConnections()
+		}
+	}
+}
+
+// usePool makes the agent use the connection pool of the key, which is
+// created from the HostClient of the first agent of the key. The agent
+// keeps its own HostClient, which is used if its transport settings are
+// changed.
+func (c *Client) usePool(a *Agent, key poolKey, cfg *PoolConfig) {
+	c.poolsMutex.Lock()
+	defer c.poolsMutex.Unlock()
+
+	p, ok := c.pools[key]
+	if !ok {
+		p = newHostPool(a.HostClient, cfg)
+		if c.pools == nil {
+			c.pools = make(map[poolKey]*hostPool)
+		}
+		c.pools[key] = p
+	}
+
+	a.pool = p
+}
+
+func newHostPool(hc *fasthttp.HostClient, cfg *PoolConfig) *hostPool {
+	p := &hostPool{
+		addr:  hc.Addr,
+		isTLS: hc.IsTLS,
+	}
+
+	dial := hc.Dial
+	if dial == nil {
+		dial = fasthttp.Dial
+		if hc.DialDualStack {
+			dial = fasthttp.DialDualStack
+		}
+	}
+
+	// The agent may modify its tls config
+	var tlsConfig *tls.Config
+	if hc.TLSConfig != nil {
+		tlsConfig = hc.TLSConfig.Clone()
+	}
+
+	if cfg.Pipeline {
+		p.pipeline = &fasthttp.PipelineClient{
+			Addr:                     hc.Addr,
+			Name:                     hc.Name,
+			NoDefaultUserAgentHeader: hc.NoDefaultUserAgentHeader,
+			MaxConns:                 cfg.MaxConnsPerHost,
+			MaxPendingRequests:       cfg.MaxPendingRequests,
+			MaxIdleConnDuration:      cfg.MaxIdleConnDuration,
+			Dial:                     p.dial(dial),
+			IsTLS:                    hc.IsTLS,
+			TLSConfig:                tlsConfig,
+		}
+		return p
+	}
+
+	p.hc = &fasthttp.HostClient{
+		Addr:                     hc.Addr,
+		Name:                     hc.Name,
+		NoDefaultUserAgentHeader: hc.NoDefaultUserAgentHeader,
+		MaxConns:                 cfg.MaxConnsPerHost,
+		MaxIdleConnDuration:      cfg.MaxIdleConnDuration,
+		MaxConnDuration:          cfg.MaxConnDuration,
+		MaxConnWaitTimeout:       cfg.MaxConnWaitTimeout,
+		Dial:                     p.dial(dial),
+		IsTLS:                    hc.IsTLS,
+		TLSConfig:                tlsConfig,
+	}
+
+	return p
+}
+
+// dial wraps the dial function to count open connections and dial errors
+func (p *hostPool) dial(dial fasthttp.DialFunc) fasthttp.DialFunc {
+	return func(addr string) (net.Conn, error) {
+		conn, err := dial(addr)
+		if err != nil {
+			atomic.AddUint64(&p.dialErrors, 1)
+			return nil, err
+		}
+		atomic.AddInt64(&p.open, 1)
+		return &poolConn{Conn: conn, pool: p}, nil
+	}
+}
+
+func (p *hostPool) stats() PoolStats {
+	s := PoolStats{
+		Addr:       p.addr,
+		IsTLS:      p.isTLS,
+		Open:       int(atomic.LoadInt64(&p.open)),
+		DialErrors: atomic.LoadUint64(&p.dialErrors),
+	}
+	if p.pipeline != nil {
+		s.Pending = p.pipeline.PendingRequests()
+	} else {
+		s.Pending = p.hc.PendingRequests()
+	}
+	if s.Pending < s.Open {
+		s.Idle = s.Open - s.Pending
+	}
+	// Pipelined connections are busy while any request is in progress
+	if p.pipeline != nil && s.Pending > 0 {
+		s.Idle = 0
+	}
+	return s
+}
+
+// poolConn is a connection of a hostPool
+type poolConn struct {
+	net.Conn
+	pool   *hostPool
+	closed sync.Once
+}
+
+func (c *poolConn) Close() error {
+	c.closed.Do(func() {
+		atomic.AddInt64(&c.pool.open, -1)
+	})
+	return c.Conn.Close()
+}
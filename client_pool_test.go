@@ -0,0 +1,182 @@
+package fiber
+
+import (
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// startPoolTestApp starts the app on a local port and returns its address
+func startPoolTestApp(t *testing.T, app *App) string {
+	t.Helper()
+
+	ln, err := net.Listen(NetworkTCP4, "127.0.0.1:0")
+	utils.AssertEqual(t, nil, err)
+
+	go func() { utils.AssertEqual(t, nil, app.Listener(ln)) }()
+	t.Cleanup(func() { _ = app.Shutdown() })
+
+	return ln.Addr().String()
+}
+
+func Test_Client_Pool(t *testing.T) {
+	t.Parallel()
+
+	app := New(Config{DisableStartupMessage: true})
+
+	release := make(chan struct{})
+	app.Get("/", func(c *Ctx) error {
+		return c.SendString("pool")
+	})
+	app.Get("/slow", func(c *Ctx) error {
+		<-release
+		return c.SendString("slow")
+	})
+
+	addr := startPoolTestApp(t, app)
+
+	c := AcquireClient()
+	defer ReleaseClient(c)
+	c.Pool = &PoolConfig{MaxConnsPerHost: 2, MaxIdleConnDuration: time.Minute}
+
+	utils.AssertEqual(t, 0, len(c.PoolStats()))
+
+	for i := 0; i < 3; i++ {
+		code, body, errs := c.Get("http://" + addr).String()
+		utils.AssertEqual(t, 0, len(errs))
+		utils.AssertEqual(t, StatusOK, code)
+		utils.AssertEqual(t, "pool", body)
+	}
+
+	// Agents share the connection of the host
+	stats := c.PoolStats()
+	utils.AssertEqual(t, 1, len(stats))
+	utils.AssertEqual(t, PoolStats{Addr: addr, Open: 1, Idle: 1}, stats[0])
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, body, errs := c.Get("http://" + addr + "/slow").String()
+			utils.AssertEqual(t, 0, len(errs))
+			utils.AssertEqual(t, "slow", body)
+		}()
+	}
+
+	for i := 0; i < 100 && c.PoolStats()[0].Pending < 2; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	stats = c.PoolStats()
+	utils.AssertEqual(t, PoolStats{Addr: addr, Open: 2, Pending: 2}, stats[0])
+
+	// The pool is limited to MaxConnsPerHost connections
+	_, _, errs := c.Get("http://" + addr).String()
+	utils.AssertEqual(t, 1, len(errs))
+	utils.AssertEqual(t, "no free connections available to host", errs[0].Error())
+
+	close(release)
+	wg.Wait()
+
+	stats = c.PoolStats()
+	utils.AssertEqual(t, PoolStats{Addr: addr, Open: 2, Idle: 2}, stats[0])
+
+	c.CloseIdleConnections()
+	utils.AssertEqual(t, 0, c.PoolStats()[0].Open)
+}
+
+func Test_Client_Pool_Pipeline(t *testing.T) {
+	t.Parallel()
+
+	app := New(Config{DisableStartupMessage: true})
+
+	app.Get("/", func(c *Ctx) error {
+		return c.SendString(c.Query("id"))
+	})
+
+	addr := startPoolTestApp(t, app)
+
+	c := AcquireClient()
+	defer ReleaseClient(c)
+	c.Pool = &PoolConfig{Pipeline: true}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			code, body, errs := c.Get("http://" + addr + "/?id=" + id).String()
+			utils.AssertEqual(t, 0, len(errs))
+			utils.AssertEqual(t, StatusOK, code)
+			utils.AssertEqual(t, id, body)
+		}(strconv.Itoa(i))
+	}
+	wg.Wait()
+
+	// Requests are pipelined on a single connection
+	stats := c.PoolStats()
+	utils.AssertEqual(t, 1, len(stats))
+	utils.AssertEqual(t, PoolStats{Addr: addr, Open: 1, Idle: 1}, stats[0])
+}
+
+func Test_Client_Pool_DialErrors(t *testing.T) {
+	t.Parallel()
+
+	ln, err := net.Listen(NetworkTCP4, "127.0.0.1:0")
+	utils.AssertEqual(t, nil, err)
+	addr := ln.Addr().String()
+	utils.AssertEqual(t, nil, ln.Close())
+
+	c := AcquireClient()
+	defer ReleaseClient(c)
+	c.Pool = &PoolConfig{}
+
+	for i := 0; i < 2; i++ {
+		_, _, errs := c.Get("https://" + addr).String()
+		utils.AssertEqual(t, 1, len(errs))
+	}
+
+	stats := c.PoolStats()
+	utils.AssertEqual(t, 1, len(stats))
+	utils.AssertEqual(t, PoolStats{Addr: addr, IsTLS: true, DialErrors: 2}, stats[0])
+}
+
+func Test_Client_Pool_Agent_Settings(t *testing.T) {
+	t.Parallel()
+
+	c := AcquireClient()
+	defer ReleaseClient(c)
+	c.Pool = &PoolConfig{}
+
+	a := c.Get("https://example.com")
+	defer ReleaseAgent(a)
+	utils.AssertEqual(t, true, a.pool != nil)
+	utils.AssertEqual(t, false, a.HostClient == a.pool.hc)
+
+	// Agents with their own transport settings don't use the pool
+	insecure := c.Get("https://example.com").InsecureSkipVerify()
+	defer ReleaseAgent(insecure)
+	utils.AssertEqual(t, true, insecure.pool == nil)
+	utils.AssertEqual(t, true, a.pool.hc.TLSConfig == nil)
+
+	proxied := c.Get("https://example.com").Proxy("localhost:8080")
+	defer ReleaseAgent(proxied)
+	utils.AssertEqual(t, true, proxied.pool == nil)
+
+	// Pools are keyed by the transport settings of the client
+	c.UnixSocket = "/tmp/fiber.sock"
+	unix := c.Get("https://example.com")
+	defer ReleaseAgent(unix)
+	utils.AssertEqual(t, true, unix.pool != nil)
+	utils.AssertEqual(t, false, unix.pool == a.pool)
+
+	same := c.Get("https://example.com/other")
+	defer ReleaseAgent(same)
+	utils.AssertEqual(t, true, same.pool == unix.pool)
+
+	utils.AssertEqual(t, 2, len(c.PoolStats()))
+}
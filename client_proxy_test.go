@@ -0,0 +1,411 @@
+package fiber
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+// testProxy is a stand-in proxy that tunnels every connection to an app
+type testProxy struct {
+	ln      net.Listener
+	mu      sync.Mutex
+	targets []string
+}
+
+func (p *testProxy) addr() string {
+	return p.ln.Addr().String()
+}
+
+func (p *testProxy) target(i int) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if i >= len(p.targets) {
+		return ""
+	}
+	return p.targets[i]
+}
+
+func (p *testProxy) serve(handshake func(conn net.Conn, br *bufio.Reader) (string, bool), app *fasthttputil.InmemoryListener) {
+	for {
+		conn, err := p.ln.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer func() { _ = conn.Close() }()
+
+			br := bufio.NewReader(conn)
+			target, ok := handshake(conn, br)
+			if !ok {
+				return
+			}
+
+			p.mu.Lock()
+			p.targets = append(p.targets, target)
+			p.mu.Unlock()
+
+			upstream, err := app.Dial()
+			if err != nil {
+				return
+			}
+			defer func() { _ = upstream.Close() }()
+
+			go func() { _, _ = io.Copy(upstream, br) }()
+			_, _ = io.Copy(conn, upstream)
+		}()
+	}
+}
+
+func startTestProxy(t *testing.T, handshake func(conn net.Conn, br *bufio.Reader) (string, bool)) *testProxy {
+	t.Helper()
+
+	ln, err := net.Listen(NetworkTCP4, "127.0.0.1:0")
+	utils.AssertEqual(t, nil, err)
+
+	appLn := fasthttputil.NewInmemoryListener()
+
+	app := New(Config{DisableStartupMessage: true})
+	app.Get("/", func(c *Ctx) error {
+		return c.SendString("proxied " + c.Hostname())
+	})
+
+	go func() { utils.AssertEqual(t, nil, app.Listener(appLn)) }()
+
+	p := &testProxy{ln: ln}
+	go p.serve(handshake, appLn)
+
+	t.Cleanup(func() {
+		_ = ln.Close()
+		_ = app.Shutdown()
+	})
+
+	return p
+}
+
+// httpConnectHandshake accepts CONNECT requests with the given credentials
+func httpConnectHandshake(auth string) func(conn net.Conn, br *bufio.Reader) (string, bool) {
+	return func(conn net.Conn, br *bufio.Reader) (string, bool) {
+		var req fasthttp.Request
+		if err := req.Header.Read(br); err != nil {
+			return "", false
+		}
+		if string(req.Header.Method()) != MethodConnect {
+			_, _ = conn.Write([]byte("HTTP/1.1 405 Method Not Allowed\r\n\r\n"))
+			return "", false
+		}
+		if auth != "" && string(req.Header.Peek(HeaderProxyAuthorization)) != "Basic "+base64.StdEncoding.EncodeToString([]byte(auth)) {
+			_, _ = conn.Write([]byte("HTTP/1.1 407 Proxy Authentication Required\r\n\r\n"))
+			return "", false
+		}
+		_, _ = conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+		return string(req.Header.RequestURI()), true
+	}
+}
+
+// socks5Handshake accepts SOCKS5 connections with the given credentials
+func socks5Handshake(username, password string) func(conn net.Conn, br *bufio.Reader) (string, bool) {
+	return func(conn net.Conn, br *bufio.Reader) (string, bool) {
+		header := make([]byte, 2)
+		if _, err := io.ReadFull(br, header); err != nil || header[0] != 5 {
+			return "", false
+		}
+		methods := make([]byte, header[1])
+		if _, err := io.ReadFull(br, methods); err != nil {
+			return "", false
+		}
+
+		if username == "" {
+			_, _ = conn.Write([]byte{5, 0})
+		} else {
+			if !bytesContains(methods, 2) {
+				_, _ = conn.Write([]byte{5, 0xff})
+				return "", false
+			}
+			_, _ = conn.Write([]byte{5, 2})
+
+			version, _ := br.ReadByte()
+			ulen, _ := br.ReadByte()
+			user := make([]byte, ulen)
+			_, _ = io.ReadFull(br, user)
+			plen, _ := br.ReadByte()
+			pass := make([]byte, plen)
+			_, _ = io.ReadFull(br, pass)
+			if version != 1 || string(user) != username || string(pass) != password {
+				_, _ = conn.Write([]byte{1, 1})
+				return "", false
+			}
+			_, _ = conn.Write([]byte{1, 0})
+		}
+
+		req := make([]byte, 4)
+		if _, err := io.ReadFull(br, req); err != nil || req[1] != 1 {
+			return "", false
+		}
+		var host string
+		switch req[3] {
+		case 1:
+			ip := make([]byte, 4)
+			_, _ = io.ReadFull(br, ip)
+			host = net.IP(ip).String()
+		case 3:
+			n, _ := br.ReadByte()
+			name := make([]byte, n)
+			_, _ = io.ReadFull(br, name)
+			host = string(name)
+		default:
+			_, _ = conn.Write([]byte{5, 8, 0, 1, 0, 0, 0, 0, 0, 0})
+			return "", false
+		}
+		port := make([]byte, 2)
+		_, _ = io.ReadFull(br, port)
+
+		_, _ = conn.Write([]byte{5, 0, 0, 1, 127, 0, 0, 1, 0, 0})
+		return net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))), true
+	}
+}
+
+func bytesContains(b []byte, c byte) bool {
+	for _, v := range b {
+		if v == c {
+			return true
+		}
+	}
+	return false
+}
+
+func Test_Client_Agent_Proxy_HTTP(t *testing.T) {
+	t.Parallel()
+
+	p := startTestProxy(t, httpConnectHandshake("user:pass"))
+
+	code, body, errs := Get("http://example.com").Proxy("http://user:pass@" + p.addr()).String()
+
+	utils.AssertEqual(t, 0, len(errs))
+	utils.AssertEqual(t, StatusOK, code)
+	utils.AssertEqual(t, "proxied example.com", body)
+	utils.AssertEqual(t, "example.com:80", p.target(0))
+
+	_, _, errs = Get("http://example.com").Proxy("http://user:wrong@" + p.addr()).String()
+
+	utils.AssertEqual(t, 1, len(errs))
+	utils.AssertEqual(t, "proxy: CONNECT example.com:80 failed with status code 407", errs[0].Error())
+}
+
+func Test_Client_Agent_Proxy_SOCKS5(t *testing.T) {
+	t.Parallel()
+
+	p := startTestProxy(t, socks5Handshake("user", "pass"))
+
+	c := AcquireClient()
+	defer ReleaseClient(c)
+	c.Proxy = "socks5h://user:pass@" + p.addr()
+
+	code, body, errs := c.Get("http://example.com:8080").String()
+
+	utils.AssertEqual(t, 0, len(errs))
+	utils.AssertEqual(t, StatusOK, code)
+	utils.AssertEqual(t, "proxied example.com:8080", body)
+	utils.AssertEqual(t, "example.com:8080", p.target(0))
+
+	code, body, errs = c.Get("http://10.0.0.1").String()
+
+	utils.AssertEqual(t, 0, len(errs))
+	utils.AssertEqual(t, StatusOK, code)
+	utils.AssertEqual(t, "proxied 10.0.0.1", body)
+	utils.AssertEqual(t, "10.0.0.1:80", p.target(1))
+
+	// socks5 resolves host names locally
+	code, body, errs = Get("http://localhost:8080").Proxy("socks5://user:pass@" + p.addr()).String()
+
+	utils.AssertEqual(t, 0, len(errs))
+	utils.AssertEqual(t, StatusOK, code)
+	utils.AssertEqual(t, "proxied localhost:8080", body)
+	utils.AssertEqual(t, true, p.target(2) == "127.0.0.1:8080" || p.target(2) == "[::1]:8080", p.target(2))
+
+	_, _, errs = Get("http://example.com").Proxy("socks5h://user:wrong@" + p.addr()).String()
+
+	utils.AssertEqual(t, 1, len(errs))
+	utils.AssertEqual(t, "proxy: SOCKS5 authentication failed", errs[0].Error())
+
+	_, _, errs = Get("http://example.com").Proxy("socks5h://" + p.addr()).String()
+
+	utils.AssertEqual(t, 1, len(errs))
+	utils.AssertEqual(t, "proxy: no acceptable SOCKS5 authentication methods", errs[0].Error())
+}
+
+func Test_Client_Agent_Proxy_Dial_Timeout(t *testing.T) {
+	t.Parallel()
+
+	ln, err := net.Listen(NetworkTCP4, "127.0.0.1:0")
+	utils.AssertEqual(t, nil, err)
+	defer func() { _ = ln.Close() }()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		httpConnectHandshake("")(conn, bufio.NewReader(conn))
+	}()
+
+	proxyURL, err := parseProxyURL("http://" + ln.Addr().String())
+	utils.AssertEqual(t, nil, err)
+
+	_, err = dialProxy(proxyURL, "example.com:80", time.Nanosecond)
+	utils.AssertEqual(t, fasthttp.ErrDialTimeout, err)
+
+	conn, err := dialProxy(proxyURL, "example.com:80", time.Second)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, nil, conn.Close())
+}
+
+func Test_Client_Agent_Proxy_Invalid(t *testing.T) {
+	t.Parallel()
+
+	_, _, errs := Get("http://example.com").Proxy("ftp://localhost").String()
+
+	utils.AssertEqual(t, 1, len(errs))
+	utils.AssertEqual(t, `proxy: unsupported proxy scheme "ftp". http and socks5 are supported`, errs[0].Error())
+}
+
+func Test_Client_Agent_Proxy_Stream(t *testing.T) {
+	t.Parallel()
+
+	p := startTestProxy(t, httpConnectHandshake(""))
+
+	code, body, errs := Get("http://example.com").Proxy(p.addr()).Stream()
+
+	utils.AssertEqual(t, 0, len(errs))
+	utils.AssertEqual(t, StatusOK, code)
+
+	b, err := io.ReadAll(body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "proxied example.com", string(b))
+	utils.AssertEqual(t, nil, body.Close())
+}
+
+// setTestEnv sets the environment variable until the end of the test
+func setTestEnv(t *testing.T, key, value string) {
+	t.Helper()
+
+	prev, ok := os.LookupEnv(key)
+	utils.AssertEqual(t, nil, os.Setenv(key, value))
+	t.Cleanup(func() {
+		if ok {
+			_ = os.Setenv(key, prev)
+		} else {
+			_ = os.Unsetenv(key)
+		}
+	})
+}
+
+// go test -run Test_Client_Agent_ProxyFromEnvironment
+func Test_Client_Agent_ProxyFromEnvironment(t *testing.T) {
+	p := startTestProxy(t, httpConnectHandshake(""))
+
+	setTestEnv(t, "HTTP_PROXY", p.addr())
+	setTestEnv(t, "NO_PROXY", "internal.example.com,.local")
+
+	c := AcquireClient()
+	defer ReleaseClient(c)
+	c.ProxyFromEnvironment = true
+
+	code, body, errs := c.Get("http://example.com").String()
+
+	utils.AssertEqual(t, 0, len(errs))
+	utils.AssertEqual(t, StatusOK, code)
+	utils.AssertEqual(t, "proxied example.com", body)
+	utils.AssertEqual(t, "example.com:80", p.target(0))
+
+	// Hosts matching NO_PROXY are dialed directly
+	proxyURL, err := proxyFromEnvironment("api.internal.example.com:80", false)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, true, proxyURL == nil)
+
+	// HTTPS_PROXY isn't set
+	proxyURL, err = proxyFromEnvironment("example.com:443", true)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, true, proxyURL == nil)
+
+	proxyURL, err = proxyFromEnvironment("example.com:80", false)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "http://"+p.addr(), proxyURL.String())
+}
+
+// go test -run Test_Client_UseProxy
+func Test_Client_UseProxy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		addr    string
+		noProxy string
+		use     bool
+	}{
+		{"example.com:80", "", true},
+		{"localhost:80", "", false},
+		{"127.0.0.1:80", "", false},
+		{"[::1]:80", "", false},
+		{"example.com:80", "*", false},
+		{"example.com:80", "example.com", false},
+		{"api.example.com:80", "example.com", false},
+		{"example.com:80", ".example.com", true},
+		{"api.example.com:80", ".example.com", false},
+		{"notexample.com:80", "example.com", true},
+		{"example.com:80", "example.com:443", true},
+		{"example.com:443", "example.com:443", false},
+		{"10.0.0.1:80", "10.0.0.0/8", false},
+		{"11.0.0.1:80", "10.0.0.0/8", true},
+		{"10.0.0.1:80", "10.0.0.1", false},
+		{"[2001:db8::1]:80", "2001:db8::/32", false},
+		{"example.com:80", "foo.com, EXAMPLE.com", false},
+	}
+
+	for _, tt := range tests {
+		utils.AssertEqual(t, tt.use, useProxy(tt.addr, tt.noProxy), tt.addr+" "+tt.noProxy)
+	}
+}
+
+func Test_Client_Agent_UnixSocket(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "fiber.sock")
+
+	ln, err := net.Listen("unix", path)
+	utils.AssertEqual(t, nil, err)
+
+	app := New(Config{DisableStartupMessage: true})
+	app.Get("/", func(c *Ctx) error {
+		return c.SendString("unix " + c.Hostname())
+	})
+
+	go func() { utils.AssertEqual(t, nil, app.Listener(ln)) }()
+	defer func() { _ = app.Shutdown() }()
+
+	c := AcquireClient()
+	defer ReleaseClient(c)
+	c.UnixSocket = path
+
+	code, body, errs := c.Get("http://localhost").String()
+
+	utils.AssertEqual(t, 0, len(errs))
+	utils.AssertEqual(t, StatusOK, code)
+	utils.AssertEqual(t, "unix localhost", body)
+
+	code, body, errs = Get("http://daemon").UnixSocket(path).String()
+
+	utils.AssertEqual(t, 0, len(errs))
+	utils.AssertEqual(t, StatusOK, code)
+	utils.AssertEqual(t, "unix daemon", body)
+}
@@ -0,0 +1,255 @@
+package fiber
+
+import (
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// RetryPolicy controls how failed requests of an agent are retried.
+//
+// Requests are retried with an exponential backoff: the n-th retry waits
+// InitialInterval * Multiplier^(n-1), capped at MaxInterval and randomized
+// by Jitter. Requests with a body stream are never retried, as the body can
+// only be read once.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first one.
+	//
+	// Default: 3
+	MaxAttempts int
+
+	// InitialInterval is the delay before the first retry.
+	//
+	// Default: 100ms
+	InitialInterval time.Duration
+
+	// MaxInterval is the maximum delay between attempts. Responses with a
+	// Retry-After header asking for a longer delay are not retried.
+	//
+	// Default: 10s
+	MaxInterval time.Duration
+
+	// Multiplier is the factor the delay is multiplied with after every retry.
+	//
+	// Default: 2
+	Multiplier float64
+
+	// Jitter randomizes the delay by up to the given fraction, i.e. a jitter
+	// of 0.5 results in a delay between 50% and 150% of the backoff, to
+	// prevent clients from retrying in lockstep. Set to a negative value to
+	// disable jitter.
+	//
+	// Default: 0.5
+	Jitter float64
+
+	// StatusCodes are the response status codes that are retried for
+	// idempotent requests. The delay of responses with a Retry-After header,
+	// i.e. 429 Too Many Requests and 503 Service Unavailable, is at least
+	// the requested delay.
+	//
+	// Default: 429, 502, 503, 504
+	StatusCodes []int
+
+	// AttemptTimeout is the timeout of a single attempt.
+	//
+	// Default: 0 (the timeout of the agent)
+	AttemptTimeout time.Duration
+
+	// RetryIf decides whether an attempt is retried, replacing the default
+	// decision: errors and responses with one of the StatusCodes are retried
+	// for idempotent requests, i.e. GET, PUT and DELETE requests or requests
+	// with an Idempotency-Key header. Other requests are not retried, as a
+	// gateway error may occur after the upstream has applied the request.
+	//
+	// Optional. Default: nil
+	RetryIf func(req *Request, resp *Response, err error) bool
+
+	// OnAttempt is called after every attempt, i.e. to log or count retries.
+	//
+	// Optional. Default: nil
+	OnAttempt func(attempt RetryAttempt)
+}
+
+// RetryAttempt describes an attempt of a request with a retry policy.
+type RetryAttempt struct {
+	// Attempt is the number of the attempt, starting at 1
+	Attempt int
+	// Request and Response of the attempt, only valid during OnAttempt
+	Request  *Request
+	Response *Response
+	// Err is the error of the attempt, if any
+	Err error
+	// Retry reports whether the request will be retried
+	Retry bool
+	// Delay is the delay before the next attempt
+	Delay time.Duration
+}
+
+// Retry sets the retry policy of the agent.
+func (a *Agent) Retry(policy RetryPolicy) *Agent {
+	a.retryPolicy = &policy
+
+	return a
+}
+
+// retryDefault sets the default values of the policy
+func retryDefault(policy RetryPolicy) *RetryPolicy {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 3
+	}
+	if policy.InitialInterval <= 0 {
+		policy.InitialInterval = 100 * time.Millisecond
+	}
+	if policy.MaxInterval <= 0 {
+		policy.MaxInterval = 10 * time.Second
+	}
+	if policy.Multiplier < 1 {
+		policy.Multiplier = 2
+	}
+	if policy.Jitter == 0 {
+		policy.Jitter = 0.5
+	} else if policy.Jitter < 0 {
+		policy.Jitter = 0
+	}
+	if policy.StatusCodes == nil {
+		policy.StatusCodes = []int{StatusTooManyRequests, StatusBadGateway, StatusServiceUnavailable, StatusGatewayTimeout}
+	}
+	return &policy
+}
+
+// doRetry sends the request with the doer and retries it according to the
+// retry policy of the agent
+func (a *Agent) doRetry(doer Doer, req *Request, resp *Response) error {
+	if a.retryPolicy == nil || req.IsBodyStream() {
+		return doer.Do(req, resp)
+	}
+	policy := retryDefault(*a.retryPolicy)
+
+	a.attemptTimeout = policy.AttemptTimeout
+	defer func() { a.attemptTimeout = 0 }()
+
+	for attempt := 1; ; attempt++ {
+		err := doer.Do(req, resp)
+
+		retry := attempt < policy.MaxAttempts
+		if retry {
+			if policy.RetryIf != nil {
+				retry = policy.RetryIf(req, resp, err)
+			} else {
+				retry = shouldRetry(policy, req, resp, err)
+			}
+		}
+
+		var delay time.Duration
+		if retry {
+			delay = policy.backoff(attempt)
+			if err == nil {
+				if after, ok := retryAfter(resp); ok {
+					if after > policy.MaxInterval {
+						retry = false
+					} else if after > delay {
+						delay = after
+					}
+				}
+			}
+		}
+
+		if policy.OnAttempt != nil {
+			policy.OnAttempt(RetryAttempt{
+				Attempt:  attempt,
+				Request:  req,
+				Response: resp,
+				Err:      err,
+				Retry:    retry,
+				Delay:    delay,
+			})
+		}
+
+		if !retry {
+			return err
+		}
+
+		if err = a.sleep(delay); err != nil {
+			return err
+		}
+		resp.Reset()
+	}
+}
+
+// sleep waits for the given duration or until the context of the agent is done
+func (a *Agent) sleep(d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	var done <-chan struct{}
+	if a.ctx != nil {
+		done = a.ctx.Done()
+	}
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-done:
+		return a.ctx.Err()
+	}
+}
+
+// backoff returns the delay after the given attempt
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	delay := float64(p.InitialInterval) * math.Pow(p.Multiplier, float64(attempt-1))
+	if delay > float64(p.MaxInterval) {
+		delay = float64(p.MaxInterval)
+	}
+	if p.Jitter > 0 {
+		delay *= 1 + p.Jitter*(2*rand.Float64()-1) // #nosec G404
+	}
+	return time.Duration(delay)
+}
+
+func shouldRetry(policy *RetryPolicy, req *Request, resp *Response, err error) bool {
+	if !isIdempotent(req) {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	status := resp.StatusCode()
+	for _, code := range policy.StatusCodes {
+		if status == code {
+			return true
+		}
+	}
+	return false
+}
+
+// isIdempotent reports whether the request can be retried safely
+func isIdempotent(req *Request) bool {
+	switch utils.UnsafeString(req.Header.Method()) {
+	case MethodGet, MethodHead, MethodPut, MethodDelete, MethodOptions, MethodTrace:
+		return true
+	}
+	return len(req.Header.Peek(HeaderIdempotencyKey)) > 0
+}
+
+// retryAfter parses the Retry-After header of the response, which is
+// either a number of seconds or a date
+func retryAfter(resp *Response) (time.Duration, bool) {
+	value := utils.UnsafeString(resp.Header.Peek(HeaderRetryAfter))
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date), true
+	}
+	return 0, false
+}
@@ -0,0 +1,259 @@
+package fiber
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+func Test_Client_Agent_Retry(t *testing.T) {
+	t.Parallel()
+
+	ln := fasthttputil.NewInmemoryListener()
+
+	app := New(Config{DisableStartupMessage: true})
+
+	requests := 0
+	app.Get("/", func(c *Ctx) error {
+		requests++
+		if requests < 3 {
+			return c.SendStatus(StatusServiceUnavailable)
+		}
+		return c.SendString("ok")
+	})
+
+	go func() { utils.AssertEqual(t, nil, app.Listener(ln)) }()
+
+	var attempts []RetryAttempt
+	a := Get("http://example.com").Retry(RetryPolicy{
+		InitialInterval: time.Millisecond,
+		OnAttempt: func(attempt RetryAttempt) {
+			attempt.Request, attempt.Response = nil, nil
+			attempts = append(attempts, attempt)
+		},
+	})
+
+	a.HostClient.Dial = func(addr string) (net.Conn, error) { return ln.Dial() }
+
+	code, body, errs := a.String()
+
+	utils.AssertEqual(t, StatusOK, code)
+	utils.AssertEqual(t, "ok", body)
+	utils.AssertEqual(t, 0, len(errs))
+	utils.AssertEqual(t, 3, requests)
+	utils.AssertEqual(t, 3, len(attempts))
+	utils.AssertEqual(t, true, attempts[0].Retry)
+	utils.AssertEqual(t, 1, attempts[0].Attempt)
+	utils.AssertEqual(t, true, attempts[1].Delay > 0)
+	utils.AssertEqual(t, false, attempts[2].Retry)
+	utils.AssertEqual(t, time.Duration(0), attempts[2].Delay)
+}
+
+func Test_Client_Agent_Retry_MaxAttempts(t *testing.T) {
+	t.Parallel()
+
+	ln := fasthttputil.NewInmemoryListener()
+
+	app := New(Config{DisableStartupMessage: true})
+
+	requests := 0
+	app.Get("/", func(c *Ctx) error {
+		requests++
+		return c.SendStatus(StatusBadGateway)
+	})
+	app.Get("/notfound", func(c *Ctx) error {
+		requests++
+		return c.SendStatus(StatusNotFound)
+	})
+
+	go func() { utils.AssertEqual(t, nil, app.Listener(ln)) }()
+
+	c := AcquireClient()
+	defer ReleaseClient(c)
+	c.RetryPolicy = &RetryPolicy{
+		MaxAttempts:     2,
+		InitialInterval: time.Millisecond,
+		Jitter:          -1,
+	}
+
+	a := c.Get("http://example.com")
+	a.HostClient.Dial = func(addr string) (net.Conn, error) { return ln.Dial() }
+
+	code, _, errs := a.String()
+
+	utils.AssertEqual(t, StatusBadGateway, code)
+	utils.AssertEqual(t, 0, len(errs))
+	utils.AssertEqual(t, 2, requests)
+
+	// Other status codes are not retried
+	requests = 0
+	a = c.Get("http://example.com/notfound")
+	a.HostClient.Dial = func(addr string) (net.Conn, error) { return ln.Dial() }
+
+	code, _, errs = a.String()
+
+	utils.AssertEqual(t, StatusNotFound, code)
+	utils.AssertEqual(t, 0, len(errs))
+	utils.AssertEqual(t, 1, requests)
+}
+
+func Test_Client_Agent_Retry_After(t *testing.T) {
+	t.Parallel()
+
+	ln := fasthttputil.NewInmemoryListener()
+
+	app := New(Config{DisableStartupMessage: true})
+
+	requests := 0
+	app.Get("/", func(c *Ctx) error {
+		requests++
+		if requests == 1 {
+			c.Set(HeaderRetryAfter, "1")
+			return c.SendStatus(StatusTooManyRequests)
+		}
+		return c.SendString("ok")
+	})
+	app.Get("/long", func(c *Ctx) error {
+		requests++
+		c.Set(HeaderRetryAfter, "3600")
+		return c.SendStatus(StatusServiceUnavailable)
+	})
+
+	go func() { utils.AssertEqual(t, nil, app.Listener(ln)) }()
+
+	var delay time.Duration
+	a := Get("http://example.com").Retry(RetryPolicy{
+		InitialInterval: time.Millisecond,
+		OnAttempt: func(attempt RetryAttempt) {
+			if attempt.Attempt == 1 {
+				delay = attempt.Delay
+			}
+		},
+	})
+	a.HostClient.Dial = func(addr string) (net.Conn, error) { return ln.Dial() }
+
+	code, body, errs := a.String()
+
+	utils.AssertEqual(t, StatusOK, code)
+	utils.AssertEqual(t, "ok", body)
+	utils.AssertEqual(t, 0, len(errs))
+	utils.AssertEqual(t, time.Second, delay)
+
+	// Delays longer than MaxInterval are not retried
+	requests = 0
+	a = Get("http://example.com/long").Retry(RetryPolicy{
+		InitialInterval: time.Millisecond,
+	})
+	a.HostClient.Dial = func(addr string) (net.Conn, error) { return ln.Dial() }
+
+	code, _, errs = a.String()
+
+	utils.AssertEqual(t, StatusServiceUnavailable, code)
+	utils.AssertEqual(t, 0, len(errs))
+	utils.AssertEqual(t, 1, requests)
+}
+
+func Test_Client_Agent_Retry_Errors(t *testing.T) {
+	t.Parallel()
+
+	ln := fasthttputil.NewInmemoryListener()
+
+	app := New(Config{DisableStartupMessage: true})
+
+	slow := func(c *Ctx) error {
+		time.Sleep(100 * time.Millisecond)
+		return c.SendString("slow")
+	}
+	app.Get("/", slow)
+	app.Post("/", slow)
+
+	go func() { utils.AssertEqual(t, nil, app.Listener(ln)) }()
+
+	attempts := 0
+	a := Get("http://example.com").Retry(RetryPolicy{
+		MaxAttempts:     2,
+		InitialInterval: time.Millisecond,
+		AttemptTimeout:  10 * time.Millisecond,
+		OnAttempt: func(attempt RetryAttempt) {
+			attempts++
+			utils.AssertEqual(t, "timeout", attempt.Err.Error())
+		},
+	})
+	a.HostClient.Dial = func(addr string) (net.Conn, error) { return ln.Dial() }
+
+	_, _, errs := a.String()
+
+	utils.AssertEqual(t, 1, len(errs))
+	utils.AssertEqual(t, "timeout", errs[0].Error())
+	utils.AssertEqual(t, 2, attempts)
+
+	// Errors of non-idempotent requests are not retried
+	attempts = 0
+	a = Post("http://example.com").Retry(RetryPolicy{
+		AttemptTimeout: 10 * time.Millisecond,
+		OnAttempt: func(attempt RetryAttempt) {
+			attempts++
+		},
+	})
+	a.HostClient.Dial = func(addr string) (net.Conn, error) { return ln.Dial() }
+
+	_, _, errs = a.String()
+
+	utils.AssertEqual(t, 1, len(errs))
+	utils.AssertEqual(t, 1, attempts)
+}
+
+func Test_Client_Agent_Retry_Idempotency(t *testing.T) {
+	t.Parallel()
+
+	r := NewRecorder().On(MethodPost, "/", RecorderResponse{Status: StatusBadGateway})
+	policy := RetryPolicy{InitialInterval: time.Millisecond}
+
+	// Non-idempotent requests are not retried
+	code, _, errs := Post("http://example.com").Transport(r).Retry(policy).String()
+	utils.AssertEqual(t, 0, len(errs))
+	utils.AssertEqual(t, StatusBadGateway, code)
+	utils.AssertEqual(t, 1, r.Count(MethodPost, "/"))
+
+	// unless they carry an Idempotency-Key
+	r.Reset()
+	code, _, errs = Post("http://example.com").Transport(r).Retry(policy).
+		Set(HeaderIdempotencyKey, "8e03978e-40d5-43e8-bc93-6894a57f9324").String()
+	utils.AssertEqual(t, 0, len(errs))
+	utils.AssertEqual(t, StatusBadGateway, code)
+	utils.AssertEqual(t, 3, r.Count(MethodPost, "/"))
+
+	// or RetryIf retries them
+	r.Reset()
+	policy.RetryIf = func(req *Request, resp *Response, err error) bool {
+		return resp.StatusCode() == StatusBadGateway
+	}
+	code, _, errs = Post("http://example.com").Transport(r).Retry(policy).String()
+	utils.AssertEqual(t, 0, len(errs))
+	utils.AssertEqual(t, StatusBadGateway, code)
+	utils.AssertEqual(t, 3, r.Count(MethodPost, "/"))
+}
+
+func Test_Client_RetryPolicy_Backoff(t *testing.T) {
+	t.Parallel()
+
+	policy := retryDefault(RetryPolicy{
+		InitialInterval: time.Second,
+		MaxInterval:     5 * time.Second,
+		Jitter:          -1,
+	})
+
+	utils.AssertEqual(t, time.Second, policy.backoff(1))
+	utils.AssertEqual(t, 2*time.Second, policy.backoff(2))
+	utils.AssertEqual(t, 4*time.Second, policy.backoff(3))
+	utils.AssertEqual(t, 5*time.Second, policy.backoff(4))
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		delay := policy.backoff(2)
+		utils.AssertEqual(t, true, delay >= time.Second && delay <= 3*time.Second)
+	}
+}
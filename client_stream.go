@@ -0,0 +1,397 @@
+package fiber
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/valyala/fasthttp"
+)
+
+// Stream returns the status code, a reader over the response body and errors
+// of url. The body is read from the connection as it is consumed instead of
+// being buffered in memory, and the connection is closed when the body is
+// closed. The body must always be closed.
+//
+// Middlewares, retries and redirects are applied as with [Agent.Bytes], the
+// responses of a transport are buffered. The
+// timeout of the agent applies until the response headers are received,
+// while the context of the agent aborts reading the body when it is done.
+//
+// Response headers can be read from a custom response set by
+// [Agent.SetResponse], the response must not be released before the body
+// is closed.
+//
+// it's not safe to use Agent after calling [Agent.Stream]
+func (a *Agent) Stream() (code int, body io.ReadCloser, errs []error) {
+	defer a.release()
+
+	if errs = append(errs, a.errs...); len(errs) > 0 {
+		return
+	}
+
+	var (
+		req     = a.req
+		resp    *Response
+		nilResp bool
+	)
+
+	if a.resp == nil {
+		resp = AcquireResponse()
+		nilResp = true
+	} else {
+		resp = a.resp
+	}
+
+	if a.ctx != nil {
+		PropagateContext(a.ctx, req)
+	}
+
+	a.stream = true
+	defer func() {
+		a.stream = false
+		a.streamBody = nil
+	}()
+
+	if err := a.doRedirects(a.doer(), req, resp); err != nil {
+		if nilResp {
+			ReleaseResponse(resp)
+		} else {
+			resp.ResetBody()
+		}
+		errs = append(errs, err)
+		return
+	}
+
+	if a.debugWriter != nil {
+		addr := resp.RemoteAddr()
+		if a.streamBody != nil {
+			addr = a.streamBody.conn.RemoteAddr()
+		}
+		msg := fmt.Sprintf("Connected to %s(%s)\r\n\r\n", req.URI().Host(), addr)
+		_, _ = a.debugWriter.Write(utils.UnsafeBytes(msg))
+		_, _ = req.WriteTo(a.debugWriter)
+		_, _ = resp.Header.WriteTo(a.debugWriter)
+	}
+
+	code = resp.StatusCode()
+	body = newStreamBody(resp, a.streamBody, nilResp)
+
+	return
+}
+
+// SaveTo saves the response body to the file at path and returns the status
+// code and errors of url. The body is streamed to a temporary file in the
+// same directory, which replaces the file once the body is complete, so the
+// file is never left partially written.
+//
+// Only successful responses with a 2xx status code are saved. The optional
+// progress callback is called after every write with the number of bytes
+// written and the total size of the body, or -1 if the size is unknown.
+//
+// it's not safe to use Agent after calling [Agent.SaveTo]
+func (a *Agent) SaveTo(path string, progress ...func(written, total int64)) (code int, errs []error) {
+	code, body, errs := a.Stream()
+	if len(errs) > 0 {
+		return
+	}
+	defer func() { _ = body.Close() }()
+
+	if code < StatusOK || code >= StatusMultipleChoices {
+		errs = append(errs, fmt.Errorf("failed to save response with status code %d", code))
+		return
+	}
+
+	if err := saveBody(path, body, progress); err != nil {
+		errs = append(errs, err)
+	}
+
+	return
+}
+
+// saveBody writes the body to a temporary file and renames it to path
+func saveBody(path string, body io.ReadCloser, progress []func(written, total int64)) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	// The temporary file no longer exists once it is renamed
+	defer func() { _ = os.Remove(f.Name()) }()
+
+	var w io.Writer = f
+	if len(progress) > 0 && progress[0] != nil {
+		w = &progressWriter{w: f, total: body.(*streamBody).size, progress: progress[0]}
+	}
+
+	if _, err = io.Copy(w, body); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err = f.Chmod(0o644); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// progressWriter reports the number of bytes written to w
+type progressWriter struct {
+	w        io.Writer
+	written  int64
+	total    int64
+	progress func(written, total int64)
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.written += int64(n)
+	w.progress(w.written, w.total)
+	return n, err
+}
+
+// streamBody is the body returned by Stream
+type streamBody struct {
+	r       io.Reader
+	resp    *Response
+	size    int64
+	release bool
+	once    sync.Once
+}
+
+// newStreamBody returns a reader over the body of the response, which is
+// the stream read from the connection unless a middleware buffered it
+func newStreamBody(resp *Response, stream *clientStream, release bool) *streamBody {
+	b := &streamBody{resp: resp, size: -1, release: release}
+	if resp.IsBodyStream() && stream != nil {
+		b.r = stream
+		if cl := resp.Header.ContentLength(); cl >= 0 {
+			b.size = int64(cl)
+		}
+	} else {
+		body := resp.Body()
+		b.r = bytes.NewReader(body)
+		b.size = int64(len(body))
+	}
+	return b
+}
+
+func (b *streamBody) Read(p []byte) (int, error) {
+	return b.r.Read(p)
+}
+
+// Close closes the connection and releases the response
+func (b *streamBody) Close() error {
+	b.once.Do(func() {
+		if b.release {
+			ReleaseResponse(b.resp)
+		} else {
+			b.resp.ResetBody()
+		}
+	})
+	return nil
+}
+
+// clientStream reads a response body from a connection, which is closed
+// when the stream is closed or the context is done
+type clientStream struct {
+	r         io.Reader
+	conn      net.Conn
+	ctx       context.Context
+	remaining int64
+	done      chan struct{}
+	once      sync.Once
+}
+
+func newClientStream(ctx context.Context, conn net.Conn) *clientStream {
+	s := &clientStream{conn: conn, ctx: ctx, remaining: -1, done: make(chan struct{})}
+	if ctx != nil && ctx.Done() != nil {
+		go func() {
+			select {
+			case <-ctx.Done():
+				_ = conn.Close()
+			case <-s.done:
+			}
+		}()
+	}
+	return s
+}
+
+func (s *clientStream) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	if s.remaining >= 0 {
+		s.remaining -= int64(n)
+		if err == io.EOF && s.remaining > 0 {
+			err = io.ErrUnexpectedEOF
+		}
+	}
+	if err != nil && err != io.EOF && s.ctx != nil && s.ctx.Err() != nil {
+		err = s.ctx.Err()
+	}
+	return n, err
+}
+
+func (s *clientStream) Close() error {
+	var err error
+	s.once.Do(func() {
+		close(s.done)
+		err = s.conn.Close()
+	})
+	return err
+}
+
+// sendStream returns a function that sends a request on a new connection
+// and sets the body of the response to a stream read from the connection
+func (a *Agent) sendStream(timeout time.Duration) func(req *Request, resp *Response) error {
+	hc := a.HostClient
+
+	return func(req *Request, resp *Response) error {
+		a.streamBody = nil
+
+		conn, err := dialStream(hc, timeout)
+		if err != nil {
+			return err
+		}
+
+		s := newClientStream(a.ctx, conn)
+		if err = s.roundTrip(hc, req, resp, timeout); err != nil {
+			_ = s.Close()
+			return err
+		}
+
+		if s.r == nil {
+			// The response has no body
+			_ = s.Close()
+			return nil
+		}
+
+		a.streamBody = s
+		resp.SetBodyStream(s, resp.Header.ContentLength())
+
+		return nil
+	}
+}
+
+// roundTrip writes the request to the connection and reads the response
+// headers. The timeout applies until the response headers are read.
+func (s *clientStream) roundTrip(hc *fasthttp.HostClient, req *Request, resp *Response, timeout time.Duration) error {
+	if timeout > 0 {
+		if err := s.conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+			return err
+		}
+	}
+
+	if !hc.NoDefaultUserAgentHeader && len(req.Header.UserAgent()) == 0 && hc.Name != "" {
+		req.Header.SetUserAgent(hc.Name)
+	}
+
+	// The connection isn't reused, as the body may not be read completely
+	connectionClose := req.Header.ConnectionClose()
+	req.Header.SetConnectionClose()
+
+	bw := bufio.NewWriter(s.conn)
+	err := req.Write(bw)
+	if err == nil {
+		err = bw.Flush()
+	}
+	if !connectionClose {
+		req.Header.ResetConnectionClose()
+	}
+	if err != nil {
+		return err
+	}
+
+	br := bufio.NewReader(s.conn)
+	for {
+		if err = resp.Header.Read(br); err != nil {
+			return err
+		}
+		// Skip informational responses
+		if status := resp.StatusCode(); status >= StatusOK || status == StatusSwitchingProtocols {
+			break
+		}
+	}
+
+	if timeout > 0 {
+		if err = s.conn.SetDeadline(time.Time{}); err != nil {
+			return err
+		}
+	}
+
+	status := resp.StatusCode()
+	if req.Header.IsHead() || status == StatusNoContent || status == StatusNotModified {
+		return nil
+	}
+
+	switch cl := resp.Header.ContentLength(); {
+	case cl >= 0:
+		s.r, s.remaining = io.LimitReader(br, int64(cl)), int64(cl)
+	case cl == -1:
+		s.r = httputil.NewChunkedReader(br)
+	default:
+		// The body is read until the connection is closed
+		s.r = br
+	}
+
+	return nil
+}
+
+// dialStream dials the address of the HostClient the way the HostClient does
+func dialStream(hc *fasthttp.HostClient, timeout time.Duration) (net.Conn, error) {
+	var (
+		conn net.Conn
+		err  error
+	)
+
+	switch {
+	case hc.Dial != nil:
+		conn, err = hc.Dial(hc.Addr)
+	case timeout > 0 && hc.DialDualStack:
+		conn, err = fasthttp.DialDualStackTimeout(hc.Addr, timeout)
+	case timeout > 0:
+		conn, err = fasthttp.DialTimeout(hc.Addr, timeout)
+	case hc.DialDualStack:
+		conn, err = fasthttp.DialDualStack(hc.Addr)
+	default:
+		conn, err = fasthttp.Dial(hc.Addr)
+	}
+	if err != nil || !hc.IsTLS {
+		return conn, err
+	}
+
+	var cfg *tls.Config
+	if hc.TLSConfig != nil {
+		cfg = hc.TLSConfig.Clone()
+	} else {
+		cfg = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	if cfg.ServerName == "" {
+		host, _, splitErr := net.SplitHostPort(hc.Addr)
+		if splitErr != nil {
+			host = hc.Addr
+		}
+		cfg.ServerName = host
+	}
+
+	tlsConn := tls.Client(conn, cfg)
+	if timeout > 0 {
+		_ = tlsConn.SetDeadline(time.Now().Add(timeout))
+	}
+	if err = tlsConn.Handshake(); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
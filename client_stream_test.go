@@ -0,0 +1,309 @@
+package fiber
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+func Test_Client_Agent_Stream(t *testing.T) {
+	t.Parallel()
+
+	ln := fasthttputil.NewInmemoryListener()
+
+	app := New(Config{DisableStartupMessage: true})
+
+	large := strings.Repeat("fiber", 100000)
+
+	app.Get("/", func(c *Ctx) error {
+		c.Set("X-Stream", "yes")
+		return c.SendString(large)
+	})
+	app.Get("/chunked", func(c *Ctx) error {
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			for i := 0; i < 10; i++ {
+				_, _ = w.WriteString("chunk" + strconv.Itoa(i))
+				_ = w.Flush()
+			}
+		})
+		return nil
+	})
+	app.Get("/redirect", func(c *Ctx) error {
+		return c.Redirect("/")
+	})
+
+	go func() { utils.AssertEqual(t, nil, app.Listener(ln)) }()
+
+	t.Run("content length", func(t *testing.T) {
+		resp := AcquireResponse()
+		defer ReleaseResponse(resp)
+
+		a := Get("http://example.com").SetResponse(resp)
+		a.HostClient.Dial = func(addr string) (net.Conn, error) { return ln.Dial() }
+
+		code, body, errs := a.Stream()
+
+		utils.AssertEqual(t, 0, len(errs))
+		utils.AssertEqual(t, StatusOK, code)
+		utils.AssertEqual(t, "yes", string(resp.Header.Peek("X-Stream")))
+
+		b, err := io.ReadAll(body)
+		utils.AssertEqual(t, nil, err)
+		utils.AssertEqual(t, large, string(b))
+		utils.AssertEqual(t, nil, body.Close())
+	})
+
+	t.Run("chunked", func(t *testing.T) {
+		a := Get("http://example.com/chunked")
+		a.HostClient.Dial = func(addr string) (net.Conn, error) { return ln.Dial() }
+
+		code, body, errs := a.Stream()
+
+		utils.AssertEqual(t, 0, len(errs))
+		utils.AssertEqual(t, StatusOK, code)
+
+		b, err := io.ReadAll(body)
+		utils.AssertEqual(t, nil, err)
+		utils.AssertEqual(t, "chunk0chunk1chunk2chunk3chunk4chunk5chunk6chunk7chunk8chunk9", string(b))
+		utils.AssertEqual(t, nil, body.Close())
+	})
+
+	t.Run("redirect", func(t *testing.T) {
+		a := Get("http://example.com/redirect").MaxRedirectsCount(1)
+		a.HostClient.Dial = func(addr string) (net.Conn, error) { return ln.Dial() }
+
+		code, body, errs := a.Stream()
+
+		utils.AssertEqual(t, 0, len(errs))
+		utils.AssertEqual(t, StatusOK, code)
+
+		b, err := io.ReadAll(body)
+		utils.AssertEqual(t, nil, err)
+		utils.AssertEqual(t, len(large), len(b))
+		utils.AssertEqual(t, nil, body.Close())
+	})
+
+	t.Run("head", func(t *testing.T) {
+		a := Head("http://example.com")
+		a.HostClient.Dial = func(addr string) (net.Conn, error) { return ln.Dial() }
+
+		code, body, errs := a.Stream()
+
+		utils.AssertEqual(t, 0, len(errs))
+		utils.AssertEqual(t, StatusOK, code)
+
+		b, err := io.ReadAll(body)
+		utils.AssertEqual(t, nil, err)
+		utils.AssertEqual(t, 0, len(b))
+		utils.AssertEqual(t, nil, body.Close())
+	})
+
+	t.Run("buffered by middleware", func(t *testing.T) {
+		a := Get("http://example.com").Use(func(next Doer) Doer {
+			return DoerFunc(func(req *Request, resp *Response) error {
+				err := next.Do(req, resp)
+				resp.Header.Set("X-Length", strconv.Itoa(len(resp.Body())))
+				return err
+			})
+		})
+		a.HostClient.Dial = func(addr string) (net.Conn, error) { return ln.Dial() }
+
+		code, body, errs := a.Stream()
+
+		utils.AssertEqual(t, 0, len(errs))
+		utils.AssertEqual(t, StatusOK, code)
+
+		b, err := io.ReadAll(body)
+		utils.AssertEqual(t, nil, err)
+		utils.AssertEqual(t, large, string(b))
+		utils.AssertEqual(t, nil, body.Close())
+	})
+
+	t.Run("dial error", func(t *testing.T) {
+		a := Get("http://example.com")
+		a.HostClient.Dial = func(addr string) (net.Conn, error) { return nil, net.ErrClosed }
+
+		_, body, errs := a.Stream()
+
+		utils.AssertEqual(t, 1, len(errs))
+		utils.AssertEqual(t, net.ErrClosed, errs[0])
+		utils.AssertEqual(t, nil, body)
+	})
+}
+
+func Test_Client_Agent_Stream_Context(t *testing.T) {
+	t.Parallel()
+
+	ln := fasthttputil.NewInmemoryListener()
+
+	app := New(Config{DisableStartupMessage: true})
+
+	app.Get("/", func(c *Ctx) error {
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			_, _ = w.WriteString("first")
+			_ = w.Flush()
+			time.Sleep(time.Second)
+			_, _ = w.WriteString("second")
+			_ = w.Flush()
+		})
+		return nil
+	})
+
+	go func() { utils.AssertEqual(t, nil, app.Listener(ln)) }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	a := Get("http://example.com").WithContext(ctx)
+	a.HostClient.Dial = func(addr string) (net.Conn, error) { return ln.Dial() }
+
+	code, body, errs := a.Stream()
+
+	utils.AssertEqual(t, 0, len(errs))
+	utils.AssertEqual(t, StatusOK, code)
+
+	buf := make([]byte, 5)
+	_, err := io.ReadFull(body, buf)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "first", string(buf))
+
+	cancel()
+
+	start := time.Now()
+	_, err = io.ReadAll(body)
+	utils.AssertEqual(t, context.Canceled, err)
+	utils.AssertEqual(t, true, time.Since(start) < 500*time.Millisecond)
+	utils.AssertEqual(t, nil, body.Close())
+}
+
+func Test_Client_Agent_Stream_Request_Body(t *testing.T) {
+	t.Parallel()
+
+	ln := fasthttputil.NewInmemoryListener()
+
+	app := New(Config{DisableStartupMessage: true})
+
+	app.Post("/", func(c *Ctx) error {
+		return c.SendString(strconv.Itoa(len(c.Body())) + " " + strconv.Itoa(c.Request().Header.ContentLength()))
+	})
+
+	go func() { utils.AssertEqual(t, nil, app.Listener(ln)) }()
+
+	size := 1 << 20
+
+	t.Run("bytes", func(t *testing.T) {
+		// An io.Reader of unknown size is sent with chunked encoding
+		a := Post("http://example.com").
+			BodyStream(io.LimitReader(zeroReader{}, int64(size)), -1)
+		a.HostClient.Dial = func(addr string) (net.Conn, error) { return ln.Dial() }
+
+		code, body, errs := a.String()
+
+		utils.AssertEqual(t, 0, len(errs))
+		utils.AssertEqual(t, StatusOK, code)
+		utils.AssertEqual(t, strconv.Itoa(size)+" "+strconv.Itoa(size), body)
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		a := Post("http://example.com").
+			BodyStream(io.LimitReader(zeroReader{}, int64(size)), -1)
+		a.HostClient.Dial = func(addr string) (net.Conn, error) { return ln.Dial() }
+
+		code, body, errs := a.Stream()
+
+		utils.AssertEqual(t, 0, len(errs))
+		utils.AssertEqual(t, StatusOK, code)
+
+		b, err := io.ReadAll(body)
+		utils.AssertEqual(t, nil, err)
+		utils.AssertEqual(t, strconv.Itoa(size)+" "+strconv.Itoa(size), string(b))
+		utils.AssertEqual(t, nil, body.Close())
+	})
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+func Test_Client_Agent_SaveTo(t *testing.T) {
+	t.Parallel()
+
+	ln := fasthttputil.NewInmemoryListener()
+
+	app := New(Config{DisableStartupMessage: true})
+
+	content := bytes.Repeat([]byte("fiber"), 20000)
+
+	app.Get("/", func(c *Ctx) error {
+		return c.Send(content)
+	})
+	app.Get("/notfound", func(c *Ctx) error {
+		return c.Status(StatusNotFound).SendString("not found")
+	})
+
+	go func() { utils.AssertEqual(t, nil, app.Listener(ln)) }()
+
+	dir := t.TempDir()
+
+	t.Run("success", func(t *testing.T) {
+		path := filepath.Join(dir, "download")
+
+		var written, total int64
+		calls := 0
+		a := Get("http://example.com")
+		a.HostClient.Dial = func(addr string) (net.Conn, error) { return ln.Dial() }
+
+		code, errs := a.SaveTo(path, func(w, t int64) {
+			written, total = w, t
+			calls++
+		})
+
+		utils.AssertEqual(t, 0, len(errs))
+		utils.AssertEqual(t, StatusOK, code)
+		utils.AssertEqual(t, int64(len(content)), written)
+		utils.AssertEqual(t, int64(len(content)), total)
+		utils.AssertEqual(t, true, calls > 0)
+
+		b, err := os.ReadFile(path)
+		utils.AssertEqual(t, nil, err)
+		utils.AssertEqual(t, content, b)
+	})
+
+	t.Run("status error", func(t *testing.T) {
+		path := filepath.Join(dir, "notfound")
+
+		a := Get("http://example.com/notfound")
+		a.HostClient.Dial = func(addr string) (net.Conn, error) { return ln.Dial() }
+
+		code, errs := a.SaveTo(path)
+
+		utils.AssertEqual(t, StatusNotFound, code)
+		utils.AssertEqual(t, 1, len(errs))
+		utils.AssertEqual(t, "failed to save response with status code 404", errs[0].Error())
+
+		_, err := os.Stat(path)
+		utils.AssertEqual(t, true, os.IsNotExist(err))
+	})
+
+	entries, err := os.ReadDir(dir)
+	utils.AssertEqual(t, nil, err)
+	for _, entry := range entries {
+		utils.AssertEqual(t, false, strings.HasSuffix(entry.Name(), ".tmp"))
+	}
+}
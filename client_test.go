@@ -2,6 +2,7 @@ package fiber
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
@@ -621,6 +622,234 @@ func Test_Client_Agent_RetryIf(t *testing.T) {
 	utils.AssertEqual(t, 0, len(errs))
 }
 
+func Test_Client_Defaults(t *testing.T) {
+	t.Parallel()
+
+	ln := fasthttputil.NewInmemoryListener()
+
+	app := New(Config{DisableStartupMessage: true})
+
+	app.Get("/api/users", func(c *Ctx) error {
+		time.Sleep(50 * time.Millisecond)
+		return c.SendString(c.Get("X-Key") + "," + c.Query("v") + "," + c.Query("page"))
+	})
+
+	go func() { utils.AssertEqual(t, nil, app.Listener(ln)) }()
+
+	c := AcquireClient()
+	defer ReleaseClient(c)
+	c.BaseURL = "http://example.com/api/"
+	c.Headers = map[string]string{"X-Key": "key"}
+	c.Query = map[string]string{"v": "1", "page": "1"}
+	c.TLSConfig = &tls.Config{ServerName: "example.com", MinVersion: tls.VersionTLS12}
+
+	a := c.Get("/users?page=2")
+	a.HostClient.Dial = func(addr string) (net.Conn, error) { return ln.Dial() }
+
+	utils.AssertEqual(t, "example.com", a.HostClient.TLSConfig.ServerName)
+	utils.AssertEqual(t, false, a.HostClient.TLSConfig == c.TLSConfig)
+
+	code, body, errs := a.String()
+
+	utils.AssertEqual(t, StatusOK, code)
+	utils.AssertEqual(t, "key,1,2", body)
+	utils.AssertEqual(t, 0, len(errs))
+
+	// Absolute URLs are not changed by the base URL
+	a = c.Get("http://example.com/api/users").Set("X-Key", "override")
+	a.HostClient.Dial = func(addr string) (net.Conn, error) { return ln.Dial() }
+
+	_, body, _ = a.String()
+	utils.AssertEqual(t, "override,1,1", body)
+
+	// Default timeout
+	c.Timeout = 10 * time.Millisecond
+	a = c.Get("users")
+	a.HostClient.Dial = func(addr string) (net.Conn, error) { return ln.Dial() }
+
+	_, _, errs = a.String()
+	utils.AssertEqual(t, 1, len(errs))
+	utils.AssertEqual(t, "timeout", errs[0].Error())
+}
+
+func Test_Client_Use(t *testing.T) {
+	t.Parallel()
+
+	ln := fasthttputil.NewInmemoryListener()
+
+	app := New(Config{DisableStartupMessage: true})
+
+	app.Get("/", func(c *Ctx) error {
+		c.Set("X-Response", "response")
+		return c.SendString(c.Get(HeaderAuthorization) + "," + c.Get("X-Agent"))
+	})
+
+	go func() { utils.AssertEqual(t, nil, app.Listener(ln)) }()
+
+	var calls []string
+	c := AcquireClient()
+	defer ReleaseClient(c)
+	c.Use(func(next Doer) Doer {
+		return DoerFunc(func(req *Request, resp *Response) error {
+			calls = append(calls, "client")
+			req.Header.Set(HeaderAuthorization, "Bearer token")
+			return next.Do(req, resp)
+		})
+	})
+
+	for i := 0; i < 2; i++ {
+		a := c.Get("http://example.com").Use(func(next Doer) Doer {
+			return DoerFunc(func(req *Request, resp *Response) error {
+				calls = append(calls, "agent")
+				req.Header.Set("X-Agent", "agent")
+				err := next.Do(req, resp)
+				calls = append(calls, string(resp.Header.Peek("X-Response")))
+				return err
+			})
+		})
+
+		a.HostClient.Dial = func(addr string) (net.Conn, error) { return ln.Dial() }
+
+		code, body, errs := a.String()
+
+		utils.AssertEqual(t, StatusOK, code)
+		utils.AssertEqual(t, "Bearer token,agent", body)
+		utils.AssertEqual(t, 0, len(errs))
+	}
+
+	utils.AssertEqual(t, []string{"client", "agent", "response", "client", "agent", "response"}, calls)
+}
+
+func Test_Client_Use_Error(t *testing.T) {
+	t.Parallel()
+
+	a := Get("http://example.com").Use(func(next Doer) Doer {
+		return DoerFunc(func(req *Request, resp *Response) error {
+			return errors.New("aborted")
+		})
+	})
+
+	code, _, errs := a.String()
+
+	utils.AssertEqual(t, 0, code)
+	utils.AssertEqual(t, 1, len(errs))
+	utils.AssertEqual(t, "aborted", errs[0].Error())
+}
+
+type propagationKey struct{}
+
+func Test_Client_Agent_WithContext_Propagation(t *testing.T) {
+	unregister := RegisterContextPropagator(func(ctx context.Context, req *Request) {
+		if v, ok := ctx.Value(propagationKey{}).(string); ok {
+			req.Header.Set("X-Propagated", v)
+		}
+	})
+	defer unregister()
+
+	handler := func(c *Ctx) error {
+		return c.SendString(c.Get("X-Propagated"))
+	}
+
+	wrapAgent := func(a *Agent) {
+		a.WithContext(context.WithValue(context.Background(), propagationKey{}, "from-context"))
+	}
+
+	testAgent(t, handler, wrapAgent, "from-context")
+}
+
+func Test_Client_ContextPropagator_Unregister(t *testing.T) {
+	first := RegisterContextPropagator(func(ctx context.Context, req *Request) {
+		req.Header.Add("X-Propagated", "first")
+	})
+	second := RegisterContextPropagator(func(ctx context.Context, req *Request) {
+		req.Header.Add("X-Propagated", "second")
+	})
+	defer second()
+
+	first()
+	first()
+
+	req := &Request{}
+	PropagateContext(context.Background(), req)
+	utils.AssertEqual(t, "second", string(req.Header.Peek("X-Propagated")))
+}
+
+func Test_Client_Agent_WithContext_Cancel(t *testing.T) {
+	t.Parallel()
+
+	ln := fasthttputil.NewInmemoryListener()
+
+	app := New(Config{DisableStartupMessage: true})
+
+	app.Get("/", func(c *Ctx) error {
+		time.Sleep(200 * time.Millisecond)
+		return c.SendString("slow")
+	})
+
+	go func() { utils.AssertEqual(t, nil, app.Listener(ln)) }()
+
+	t.Run("cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(20*time.Millisecond, cancel)
+
+		a := Get("http://example.com").WithContext(ctx)
+		a.HostClient.Dial = func(addr string) (net.Conn, error) { return ln.Dial() }
+
+		start := time.Now()
+		code, body, errs := a.String()
+
+		utils.AssertEqual(t, true, time.Since(start) < 150*time.Millisecond)
+		utils.AssertEqual(t, 0, code)
+		utils.AssertEqual(t, "", body)
+		utils.AssertEqual(t, []error{context.Canceled}, errs)
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		a := Get("http://example.com").WithContext(ctx).Timeout(time.Second)
+		a.HostClient.Dial = func(addr string) (net.Conn, error) { return ln.Dial() }
+
+		start := time.Now()
+		_, _, errs := a.String()
+
+		utils.AssertEqual(t, true, time.Since(start) < 150*time.Millisecond)
+		utils.AssertEqual(t, []error{context.DeadlineExceeded}, errs)
+	})
+
+	t.Run("cancelled before request", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		dials := 0
+		a := Get("http://example.com").WithContext(ctx)
+		a.HostClient.Dial = func(addr string) (net.Conn, error) {
+			dials++
+			return ln.Dial()
+		}
+
+		_, _, errs := a.String()
+
+		utils.AssertEqual(t, []error{context.Canceled}, errs)
+		utils.AssertEqual(t, 0, dials)
+	})
+
+	t.Run("completed", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		a := Get("http://example.com").WithContext(ctx)
+		a.HostClient.Dial = func(addr string) (net.Conn, error) { return ln.Dial() }
+
+		code, body, errs := a.String()
+
+		utils.AssertEqual(t, StatusOK, code)
+		utils.AssertEqual(t, "slow", body)
+		utils.AssertEqual(t, 0, len(errs))
+	})
+}
+
 func Test_Client_Agent_Json(t *testing.T) {
 	handler := func(c *Ctx) error {
 		utils.AssertEqual(t, MIMEApplicationJSON, string(c.Request().Header.ContentType()))
@@ -1109,8 +1338,7 @@ func Test_Client_Agent_Struct(t *testing.T) {
 
 	t.Run("nil jsonDecoder", func(t *testing.T) {
 		a := AcquireAgent()
-		defer ReleaseAgent(a)
-		defer a.ConnectionClose()
+		a.ConnectionClose()
 		request := a.Request()
 		request.Header.SetMethod("GET")
 		request.SetRequestURI("http://example.com")
@@ -0,0 +1,222 @@
+package fiber
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/valyala/fasthttp"
+)
+
+// AppTransport returns a transport that sends requests directly to the app
+// without a listener, the same way app.Test does. It allows testing code
+// sending requests with an Agent against an app.
+//
+//	c := fiber.AcquireClient()
+//	c.Transport = fiber.AppTransport(app)
+//	code, body, errs := c.Get("http://example.com/users").String()
+func AppTransport(app *App) Doer {
+	return DoerFunc(func(req *Request, resp *Response) error {
+		conn := new(testConn)
+
+		w := bufio.NewWriter(&conn.r)
+		if err := req.Write(w); err != nil {
+			return err
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		// prepare the server for the start
+		app.startupProcess()
+
+		if err := app.server.ServeConn(conn); err != nil && err != fasthttp.ErrGetOnly {
+			return err
+		}
+
+		skipBody := resp.SkipBody
+		resp.SkipBody = skipBody || req.Header.IsHead()
+		defer func() { resp.SkipBody = skipBody }()
+
+		return resp.Read(bufio.NewReader(&conn.w))
+	})
+}
+
+// RecorderResponse is a scripted response of a Recorder.
+type RecorderResponse struct {
+	// Status is the status code of the response.
+	//
+	// Default: 200
+	Status int
+
+	// Header holds the headers of the response.
+	Header map[string]string
+
+	// Body is the body of the response.
+	Body []byte
+
+	// Err is returned instead of the response, i.e. to simulate
+	// connection errors.
+	Err error
+}
+
+// Recorder is a transport that records the requests sent by agents and
+// replies with scripted responses, so code sending requests can be tested
+// without a server.
+//
+//	r := fiber.NewRecorder().
+//		On(fiber.MethodGet, "/users", fiber.RecorderResponse{Body: []byte(`[]`)})
+//	c := fiber.AcquireClient()
+//	c.Transport = r
+//	...
+//	utils.AssertEqual(t, 1, r.Count(fiber.MethodGet, "/users"))
+//
+// It is safe calling Recorder methods from concurrently running goroutines.
+type Recorder struct {
+	mu       sync.Mutex
+	routes   []*recorderRoute
+	requests []*Request
+}
+
+// recorderRoute holds the scripted responses of a method and url
+type recorderRoute struct {
+	method    string
+	url       string
+	responses []RecorderResponse
+	calls     int
+}
+
+// NewRecorder returns a recorder without scripted responses.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// On scripts the responses to requests with the method and url. The
+// responses are returned in order, the last one is repeated once all
+// responses have been returned.
+//
+// An empty method matches all methods. The url is matched against the path
+// of the request, or against the scheme, host and path if it contains
+// "://". An empty url matches all requests. Routes are matched in the order
+// they are added.
+func (r *Recorder) On(method, url string, responses ...RecorderResponse) *Recorder {
+	if len(responses) == 0 {
+		responses = []RecorderResponse{{}}
+	}
+
+	r.mu.Lock()
+	r.routes = append(r.routes, &recorderRoute{method: method, url: url, responses: responses})
+	r.mu.Unlock()
+
+	return r
+}
+
+// Do records the request and sets the scripted response. Requests without
+// a scripted response return an error.
+func (r *Recorder) Do(req *Request, resp *Response) error {
+	// Read a body stream, so it is copied
+	req.Body()
+
+	recorded := &Request{}
+	req.CopyTo(recorded)
+
+	r.mu.Lock()
+	r.requests = append(r.requests, recorded)
+	var scripted *RecorderResponse
+	for _, route := range r.routes {
+		if route.match(req) {
+			i := route.calls
+			if i >= len(route.responses) {
+				i = len(route.responses) - 1
+			}
+			route.calls++
+			scripted = &route.responses[i]
+			break
+		}
+	}
+	r.mu.Unlock()
+
+	if scripted == nil {
+		return fmt.Errorf("recorder: no response for %s %s", req.Header.Method(), req.URI().FullURI())
+	}
+	if scripted.Err != nil {
+		return scripted.Err
+	}
+
+	resp.Reset()
+	status := scripted.Status
+	if status == 0 {
+		status = StatusOK
+	}
+	resp.SetStatusCode(status)
+	for k, v := range scripted.Header {
+		resp.Header.Set(k, v)
+	}
+	if !req.Header.IsHead() {
+		resp.SetBody(scripted.Body)
+	}
+
+	return nil
+}
+
+// Requests returns the recorded requests in the order they were sent.
+func (r *Recorder) Requests() []*Request {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]*Request(nil), r.requests...)
+}
+
+// Last returns the last recorded request, or nil if no request was sent.
+func (r *Recorder) Last() *Request {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.requests) == 0 {
+		return nil
+	}
+	return r.requests[len(r.requests)-1]
+}
+
+// Count returns the number of recorded requests matching the method and
+// url, which are matched the same way as by On.
+func (r *Recorder) Count(method, url string) int {
+	route := &recorderRoute{method: method, url: url}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for _, req := range r.requests {
+		if route.match(req) {
+			n++
+		}
+	}
+	return n
+}
+
+// Reset removes the recorded requests and restarts the scripted responses.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.requests = nil
+	for _, route := range r.routes {
+		route.calls = 0
+	}
+}
+
+func (route *recorderRoute) match(req *Request) bool {
+	if route.method != "" && route.method != string(req.Header.Method()) {
+		return false
+	}
+	if route.url == "" {
+		return true
+	}
+	uri := req.URI()
+	if strings.Contains(route.url, "://") {
+		return route.url == string(uri.Scheme())+"://"+string(uri.Host())+string(uri.Path())
+	}
+	return route.url == string(uri.Path())
+}
@@ -0,0 +1,170 @@
+package fiber
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+func Test_Client_AppTransport(t *testing.T) {
+	t.Parallel()
+
+	app := New()
+
+	app.Get("/", func(c *Ctx) error {
+		return c.SendString("Hello, " + c.Get("X-Name") + "!")
+	})
+	app.Post("/echo", func(c *Ctx) error {
+		c.Set("X-Method", c.Method())
+		return c.Send(c.Body())
+	})
+
+	c := AcquireClient()
+	defer ReleaseClient(c)
+	c.Transport = AppTransport(app)
+
+	code, body, errs := c.Get("http://example.com").Set("X-Name", "World").String()
+
+	utils.AssertEqual(t, 0, len(errs))
+	utils.AssertEqual(t, StatusOK, code)
+	utils.AssertEqual(t, "Hello, World!", body)
+
+	resp := AcquireResponse()
+	defer ReleaseResponse(resp)
+
+	code, body, errs = c.Post("http://example.com/echo").SetResponse(resp).BodyString("echo").String()
+
+	utils.AssertEqual(t, 0, len(errs))
+	utils.AssertEqual(t, StatusOK, code)
+	utils.AssertEqual(t, "echo", body)
+	utils.AssertEqual(t, MethodPost, string(resp.Header.Peek("X-Method")))
+
+	code, body, errs = c.Head("http://example.com").String()
+
+	utils.AssertEqual(t, 0, len(errs))
+	utils.AssertEqual(t, StatusOK, code)
+	utils.AssertEqual(t, "", body)
+
+	code, _, errs = c.Get("http://example.com/notfound").String()
+
+	utils.AssertEqual(t, 0, len(errs))
+	utils.AssertEqual(t, StatusNotFound, code)
+}
+
+func Test_Client_AppTransport_Stream(t *testing.T) {
+	t.Parallel()
+
+	app := New()
+
+	app.Get("/", func(c *Ctx) error {
+		return c.SendString("stream")
+	})
+
+	code, body, errs := Get("http://example.com").Transport(AppTransport(app)).Stream()
+
+	utils.AssertEqual(t, 0, len(errs))
+	utils.AssertEqual(t, StatusOK, code)
+
+	b, err := io.ReadAll(body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "stream", string(b))
+	utils.AssertEqual(t, nil, body.Close())
+}
+
+func Test_Client_Recorder(t *testing.T) {
+	t.Parallel()
+
+	r := NewRecorder().
+		On(MethodGet, "/users", RecorderResponse{
+			Header: map[string]string{HeaderContentType: MIMEApplicationJSON},
+			Body:   []byte(`[{"name":"john"}]`),
+		}).
+		On(MethodPost, "/users",
+			RecorderResponse{Status: StatusServiceUnavailable},
+			RecorderResponse{Status: StatusCreated},
+		).
+		On("", "https://other.com/", RecorderResponse{Body: []byte("other")})
+
+	c := AcquireClient()
+	defer ReleaseClient(c)
+	c.Transport = r
+
+	resp := AcquireResponse()
+	defer ReleaseResponse(resp)
+
+	code, body, errs := c.Get("http://example.com/users").SetResponse(resp).String()
+
+	utils.AssertEqual(t, 0, len(errs))
+	utils.AssertEqual(t, StatusOK, code)
+	utils.AssertEqual(t, `[{"name":"john"}]`, body)
+	utils.AssertEqual(t, MIMEApplicationJSON, string(resp.Header.ContentType()))
+
+	for _, status := range []int{StatusServiceUnavailable, StatusCreated, StatusCreated} {
+		code, _, errs = c.Post("http://example.com/users").JSON(Map{"name": "doe"}).String()
+
+		utils.AssertEqual(t, 0, len(errs))
+		utils.AssertEqual(t, status, code)
+	}
+
+	code, body, errs = c.Delete("https://other.com/").String()
+
+	utils.AssertEqual(t, 0, len(errs))
+	utils.AssertEqual(t, StatusOK, code)
+	utils.AssertEqual(t, "other", body)
+
+	_, _, errs = c.Get("http://example.com/unknown").String()
+
+	utils.AssertEqual(t, 1, len(errs))
+	utils.AssertEqual(t, "recorder: no response for GET http://example.com/unknown", errs[0].Error())
+
+	utils.AssertEqual(t, 6, len(r.Requests()))
+	utils.AssertEqual(t, 1, r.Count(MethodGet, "/users"))
+	utils.AssertEqual(t, 3, r.Count(MethodPost, "/users"))
+	utils.AssertEqual(t, 4, r.Count("", "/users"))
+	utils.AssertEqual(t, 1, r.Count("", "https://other.com/"))
+	utils.AssertEqual(t, `{"name":"doe"}`, string(r.Requests()[1].Body()))
+	utils.AssertEqual(t, MIMEApplicationJSON, string(r.Requests()[1].Header.ContentType()))
+	utils.AssertEqual(t, "/unknown", string(r.Last().URI().Path()))
+
+	r.Reset()
+
+	utils.AssertEqual(t, 0, len(r.Requests()))
+	utils.AssertEqual(t, true, r.Last() == nil)
+
+	code, _, errs = c.Post("http://example.com/users").String()
+
+	utils.AssertEqual(t, 0, len(errs))
+	utils.AssertEqual(t, StatusServiceUnavailable, code)
+}
+
+func Test_Client_Recorder_Error(t *testing.T) {
+	t.Parallel()
+
+	errConn := errors.New("connection refused")
+
+	r := NewRecorder().On("", "", RecorderResponse{Err: errConn})
+
+	code, _, errs := Get("http://example.com").Transport(r).String()
+
+	utils.AssertEqual(t, 0, code)
+	utils.AssertEqual(t, 1, len(errs))
+	utils.AssertEqual(t, errConn, errs[0])
+}
+
+func Test_Client_Recorder_Body_Stream(t *testing.T) {
+	t.Parallel()
+
+	r := NewRecorder().On(MethodPost, "/upload")
+
+	code, _, errs := Post("http://example.com/upload").
+		Transport(r).
+		BodyStream(strings.NewReader("body stream"), -1).
+		String()
+
+	utils.AssertEqual(t, 0, len(errs))
+	utils.AssertEqual(t, StatusOK, code)
+	utils.AssertEqual(t, "body stream", string(r.Last().Body()))
+}
@@ -427,6 +427,31 @@ func (c *Ctx) Context() *fasthttp.RequestCtx {
 	return c.fasthttp
 }
 
+// Detach returns a copy of the context that is backed by its own copy of the
+// request and keeps the matched route, route params, locals and user context.
+// It can be used by goroutines that outlive the handler, but anything written to
+// its response is not sent to the client. Release it with App.ReleaseCtx when done.
+func (c *Ctx) Detach() *Ctx {
+	fctx := &fasthttp.RequestCtx{}
+	fctx.Init(&c.fasthttp.Request, c.fasthttp.RemoteAddr(), nil)
+	c.fasthttp.VisitUserValues(func(key []byte, value interface{}) {
+		fctx.SetUserValueBytes(key, value)
+	})
+
+	d := c.app.AcquireCtx(fctx)
+	d.route = c.route
+	d.indexRoute = c.indexRoute
+	d.indexHandler = c.indexHandler
+	d.matched = c.matched
+	d.baseURI = c.baseURI
+	if c.route != nil {
+		for i := range c.route.Params {
+			d.values[i] = utils.CopyString(c.values[i])
+		}
+	}
+	return d
+}
+
 // UserContext returns a context implementation that was set by
 // user earlier or returns a non-nil, empty context,if it was not set earlier.
 func (c *Ctx) UserContext() context.Context {
@@ -1474,6 +1499,81 @@ func (c *Ctx) Route() *Route {
 	return c.route
 }
 
+// Endpoint returns the route that handles the request if every middleware calls Next.
+// Inside a handler registered with Use, this is the first non-middleware route after
+// the current one that matches, otherwise it is the current route.
+// Optionally, the route of another method for the same path can be looked up,
+// e.g. the method announced by a CORS preflight request.
+// Returns nil if no route matches the request.
+func (c *Ctx) Endpoint(method ...string) *Route {
+	var values [maxParams]string
+	if len(method) > 0 && method[0] != c.method {
+		return c.endpointOf(c.app.methodInt(utils.ToUpper(method[0])), &values)
+	}
+	return c.endpoint(&values)
+}
+
+// EndpointParam is like Params, but returns the route parameter of the Endpoint route.
+// Inside a handler registered with Use, the parameters of the route that handles
+// the request are not known to Params yet.
+func (c *Ctx) EndpointParam(key string, defaultValue ...string) string {
+	if key == "*" || key == "+" {
+		key += "1"
+	}
+	var values [maxParams]string
+	route := c.endpoint(&values)
+	if route != nil {
+		for i := range route.Params {
+			if len(key) != len(route.Params[i]) {
+				continue
+			}
+			if route.Params[i] == key || (!c.app.config.CaseSensitive && utils.EqualFold(route.Params[i], key)) {
+				// in case values are not here
+				if len(values[i]) == 0 {
+					break
+				}
+				return values[i]
+			}
+		}
+	}
+	return defaultString("", defaultValue)
+}
+
+// endpoint finds the Endpoint route and stores its parameter values in values
+func (c *Ctx) endpoint(values *[maxParams]string) *Route {
+	if c.route != nil && !c.route.use {
+		*values = c.values
+		return c.route
+	}
+	tree, ok := c.app.treeStack[c.methodINT][c.treePath]
+	if !ok {
+		tree = c.app.treeStack[c.methodINT][""]
+	}
+	for i := c.indexRoute + 1; i < len(tree); i++ {
+		if route := tree[i]; !route.use && route.match(c.detectionPath, c.path, values) {
+			return route
+		}
+	}
+	return nil
+}
+
+// endpointOf finds the first non-middleware route of another method that matches the path
+func (c *Ctx) endpointOf(methodINT int, values *[maxParams]string) *Route {
+	if methodINT < 0 || methodINT >= len(c.app.treeStack) {
+		return nil
+	}
+	tree, ok := c.app.treeStack[methodINT][c.treePath]
+	if !ok {
+		tree = c.app.treeStack[methodINT][""]
+	}
+	for _, route := range tree {
+		if !route.use && route.match(c.detectionPath, c.path, values) {
+			return route
+		}
+	}
+	return nil
+}
+
 // SaveFile saves any multipart file to disk.
 func (c *Ctx) SaveFile(fileheader *multipart.FileHeader, path string) error {
 	return fasthttp.SaveMultipartFile(fileheader, path)
@@ -641,6 +641,30 @@ func Test_Ctx_SetUserContext(t *testing.T) {
 	utils.AssertEqual(t, testValue, c.UserContext().Value(testKey))
 }
 
+// go test -run Test_Ctx_Detach
+func Test_Ctx_Detach(t *testing.T) {
+	t.Parallel()
+	app := New()
+
+	var detached *Ctx
+	app.Get("/user/:name", func(c *Ctx) error {
+		c.Locals("role", "admin")
+		detached = c.Detach()
+		return c.SendString("original")
+	})
+
+	resp, err := app.Test(httptest.NewRequest(MethodGet, "/user/john?page=2", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, StatusOK, resp.StatusCode)
+
+	defer app.ReleaseCtx(detached)
+	utils.AssertEqual(t, "john", detached.Params("name"))
+	utils.AssertEqual(t, "2", detached.Query("page"))
+	utils.AssertEqual(t, "admin", detached.Locals("role"))
+	utils.AssertEqual(t, "/user/:name", detached.Route().Path)
+	utils.AssertEqual(t, "", string(detached.Response().Body()))
+}
+
 // go test -run Test_Ctx_UserContext_Multiple_Requests
 func Test_Ctx_UserContext_Multiple_Requests(t *testing.T) {
 	testKey := struct{}{}
@@ -2082,6 +2106,47 @@ func Test_Ctx_Route(t *testing.T) {
 	utils.AssertEqual(t, 0, len(c.Route().Handlers))
 }
 
+// go test -run Test_Ctx_Endpoint
+func Test_Ctx_Endpoint(t *testing.T) {
+	t.Parallel()
+	app := New()
+	app.Use(func(c *Ctx) error {
+		route := c.Endpoint()
+		if route == nil {
+			// The route of another method can be looked up
+			utils.AssertEqual(t, "/users/:id", c.Endpoint(MethodPost).Path)
+			utils.AssertEqual(t, true, c.Endpoint(MethodPut) == nil)
+			return c.SendString("none")
+		}
+		c.Set("X-Endpoint", route.Method+" "+route.Path)
+		return c.Next()
+	})
+	app.Use("/users", func(c *Ctx) error {
+		utils.AssertEqual(t, "/users/:id", c.Endpoint().Path)
+		utils.AssertEqual(t, "", c.Params("id"))
+		utils.AssertEqual(t, "42", c.EndpointParam("id"))
+		utils.AssertEqual(t, "none", c.EndpointParam("name", "none"))
+		return c.Next()
+	})
+	app.Post("/users/:id", func(c *Ctx) error {
+		utils.AssertEqual(t, c.Route(), c.Endpoint())
+		return c.SendString(c.Params("id"))
+	})
+
+	resp, err := app.Test(httptest.NewRequest(MethodPost, "/users/42", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, "POST /users/:id", resp.Header.Get("X-Endpoint"))
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "42", string(body))
+
+	resp, err = app.Test(httptest.NewRequest(MethodGet, "/users/42", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	body, err = io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "none", string(body))
+}
+
 // go test -run Test_Ctx_RouteNormalized
 func Test_Ctx_RouteNormalized(t *testing.T) {
 	t.Parallel()
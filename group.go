@@ -11,15 +11,23 @@ import (
 
 // Group struct
 type Group struct {
-	app         *App
-	parentGroup *Group
-	name        string
+	app             *App
+	parentGroup     *Group
+	name            string
+	anyRouteDefined bool
 
 	Prefix string
 }
 
-// Name Assign name to specific route.
+// Name Assign name to the group, which prefixes the names of its routes.
+// Once a route is registered on the group, the latest route is named
+// instead, like app.Name, so it can be chained after the route.
 func (grp *Group) Name(name string) Router {
+	if grp.anyRouteDefined {
+		grp.app.Name(name)
+		return grp
+	}
+
 	grp.app.mutex.Lock()
 
 	if grp.parentGroup != nil {
@@ -36,6 +44,14 @@ func (grp *Group) Name(name string) Router {
 	return grp
 }
 
+// Meta Assign metadata to the latest registered route.
+//
+//	grp.Post("/orders", handler).Meta("perm", "orders:write")
+func (grp *Group) Meta(key string, value interface{}) Router {
+	grp.app.Meta(key, value)
+	return grp
+}
+
 // Use registers a middleware route that will match requests
 // with the provided prefix (which is optional and defaults to "/").
 //
@@ -83,7 +99,10 @@ func (grp *Group) Use(args ...interface{}) Router {
 // of the specified resource. Requests using GET should only retrieve data.
 func (grp *Group) Get(path string, handlers ...Handler) Router {
 	grp.Add(MethodHead, path, handlers...)
-	return grp.Add(MethodGet, path, handlers...)
+	metadata := grp.app.latestRoute.Metadata
+	grp.Add(MethodGet, path, handlers...)
+	grp.app.shareMetadata(metadata)
+	return grp
 }
 
 // Head registers a route for HEAD methods that asks for a response identical
@@ -135,11 +154,13 @@ func (grp *Group) Patch(path string, handlers ...Handler) Router {
 
 // Add allows you to specify a HTTP method to register a route
 func (grp *Group) Add(method, path string, handlers ...Handler) Router {
+	grp.anyRouteDefined = true
 	return grp.app.register(method, getGroupPath(grp.Prefix, path), grp, handlers...)
 }
 
 // Static will create a file server serving static files
 func (grp *Group) Static(prefix, root string, config ...Static) Router {
+	grp.anyRouteDefined = true
 	return grp.app.registerStatic(getGroupPath(grp.Prefix, prefix), root, config...)
 }
 
@@ -605,6 +605,10 @@ const (
 	HeaderContentSecurityPolicy           = "Content-Security-Policy"
 	HeaderContentSecurityPolicyReportOnly = "Content-Security-Policy-Report-Only"
 	HeaderCrossOriginResourcePolicy       = "Cross-Origin-Resource-Policy"
+	HeaderCrossOriginEmbedderPolicy       = "Cross-Origin-Embedder-Policy"
+	HeaderCrossOriginOpenerPolicy         = "Cross-Origin-Opener-Policy"
+	HeaderOriginAgentCluster              = "Origin-Agent-Cluster"
+	HeaderReportingEndpoints              = "Reporting-Endpoints"
 	HeaderExpectCT                        = "Expect-CT"
 	// Deprecated: use HeaderPermissionsPolicy instead
 	HeaderFeaturePolicy           = "Feature-Policy"
@@ -636,6 +640,7 @@ const (
 	HeaderAcceptSignature         = "Accept-Signature"
 	HeaderAltSvc                  = "Alt-Svc"
 	HeaderDate                    = "Date"
+	HeaderIdempotencyKey          = "Idempotency-Key"
 	HeaderIndex                   = "Index"
 	HeaderLargeAllocation         = "Large-Allocation"
 	HeaderLink                    = "Link"
@@ -652,6 +657,10 @@ const (
 	HeaderXRequestedWith          = "X-Requested-With"
 	HeaderXRobotsTag              = "X-Robots-Tag"
 	HeaderXUACompatible           = "X-UA-Compatible"
+
+	// Private Network Access, see https://wicg.github.io/private-network-access/
+	HeaderAccessControlRequestPrivateNetwork = "Access-Control-Request-Private-Network"
+	HeaderAccessControlAllowPrivateNetwork   = "Access-Control-Allow-Private-Network"
 )
 
 // Network types that are commonly used
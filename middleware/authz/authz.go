@@ -0,0 +1,128 @@
+package authz
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// New creates a new middleware handler
+func New(config Config) fiber.Handler {
+	// Set default config
+	cfg := configDefault(config)
+
+	// Return new handler
+	return func(c *fiber.Ctx) error {
+		// Don't execute middleware if Next returns true
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		// The permissions are declared on the route that handles the request
+		route := c.Endpoint()
+		var perms []string
+		if route != nil {
+			var ok bool
+			if perms, ok = permissions(route.Metadata[cfg.MetaKey]); !ok {
+				// Fail closed on metadata that isn't a list of permissions
+				return cfg.Forbidden(c)
+			}
+		}
+
+		id := cfg.Identity(c)
+		if len(perms) == 0 {
+			if !cfg.DenyByDefault {
+				return c.Next()
+			}
+			if id == nil {
+				return cfg.Unauthorized(c)
+			}
+			return cfg.Forbidden(c)
+		}
+		if id == nil {
+			return cfg.Unauthorized(c)
+		}
+
+		if err := cfg.Policy.check(id, perms, attributes(c, id, route)); err != nil {
+			return cfg.Forbidden(c)
+		}
+		return c.Next()
+	}
+}
+
+// permissions converts the metadata value of the required permissions, a
+// string or a list of strings. It reports false for other values.
+func permissions(v interface{}) ([]string, bool) {
+	switch v := v.(type) {
+	case nil:
+		return nil, true
+	case string:
+		if v == "" {
+			return nil, true
+		}
+		return []string{v}, true
+	case []string:
+		return v, true
+	case []interface{}:
+		// i.e. metadata decoded from JSON
+		perms := make([]string, len(v))
+		for i, perm := range v {
+			s, ok := perm.(string)
+			if !ok {
+				return nil, false
+			}
+			perms[i] = s
+		}
+		return perms, true
+	}
+	return nil, false
+}
+
+// attributes returns a function that resolves the attributes of rule conditions
+func attributes(c *fiber.Ctx, id *Identity, route *fiber.Route) func(name string) (string, bool) {
+	return func(name string) (string, bool) {
+		switch name {
+		case "identity.id":
+			return id.ID, id.ID != ""
+		case "method":
+			return c.Method(), true
+		case "path":
+			return c.Path(), true
+		case "ip":
+			return c.IP(), true
+		}
+		i := strings.IndexByte(name, '.')
+		if i < 0 {
+			return "", false
+		}
+		source, key := name[:i], name[i+1:]
+		var v string
+		switch source {
+		case "identity":
+			value, found := id.Attributes[key]
+			if !found {
+				return "", false
+			}
+			return fmt.Sprint(value), true
+		case "param":
+			v = c.EndpointParam(key)
+		case "query":
+			v = c.Query(key)
+		case "header":
+			v = c.Get(key)
+		case "route":
+			if route == nil {
+				return "", false
+			}
+			value, found := route.Metadata[key]
+			if !found {
+				return "", false
+			}
+			return fmt.Sprint(value), true
+		default:
+			return "", false
+		}
+		return v, v != ""
+	}
+}
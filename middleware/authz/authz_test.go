@@ -0,0 +1,176 @@
+package authz
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+const testPolicy = `{
+	"roles": {
+		"viewer": {"permissions": ["orders:read"]},
+		"clerk":  {"permissions": ["orders:write"], "inherits": ["viewer"]},
+		"admin":  {"permissions": ["*"]}
+	},
+	"rules": [
+		{"effect": "deny", "permissions": ["orders:*"], "when": {"identity.suspended": "true"}},
+		{"effect": "allow", "permissions": ["orders:read"], "roles": ["customer"], "when": {"identity.id": "$param.customer"}}
+	]
+}`
+
+type claims map[string]interface{}
+
+func newTestApp(t *testing.T, cfg Config) *fiber.App {
+	t.Helper()
+	if cfg.Policy == nil && cfg.PolicyFile == "" {
+		policy, err := ParsePolicy([]byte(testPolicy))
+		utils.AssertEqual(t, nil, err)
+		cfg.Policy = policy
+	}
+
+	app := fiber.New()
+	// Stand-in for an authentication middleware
+	app.Use(func(c *fiber.Ctx) error {
+		switch c.Get("X-User") {
+		case "":
+		case "john":
+			c.Locals("user", claims{"sub": "john", "roles": []interface{}{"clerk"}})
+		case "jane":
+			c.Locals("user", claims{"sub": "jane", "roles": "admin", "suspended": true})
+		case "carl":
+			c.Locals("user", &Identity{ID: "carl", Roles: []string{"customer"}})
+		default:
+			c.Locals("user", c.Get("X-User"))
+		}
+		return c.Next()
+	})
+	app.Use(New(cfg))
+
+	handler := func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	}
+	app.Get("/orders", handler).Meta("perm", "orders:read")
+	app.Post("/orders", handler).Meta("perm", "orders:write")
+	app.Delete("/orders", handler).Meta("perm", []string{"orders:write", "orders:delete"})
+	app.Get("/customers/:customer/orders", handler).Meta("perm", "orders:read")
+	app.Put("/orders", handler).Meta("perm", []interface{}{"orders:write"})
+	app.Get("/invalid", handler).Meta("perm", []interface{}{"orders:read", 42})
+	app.Get("/public", handler)
+	return app
+}
+
+func status(t *testing.T, app *fiber.App, method, path, user string) int {
+	t.Helper()
+	req := httptest.NewRequest(method, path, nil)
+	if user != "" {
+		req.Header.Set("X-User", user)
+	}
+	resp, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	return resp.StatusCode
+}
+
+// go test -run Test_Authz
+func Test_Authz(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t, Config{})
+
+	tests := []struct {
+		method, path, user string
+		status             int
+	}{
+		{fiber.MethodGet, "/orders", "", fiber.StatusUnauthorized},
+		{fiber.MethodGet, "/orders", "john", fiber.StatusOK},
+		{fiber.MethodHead, "/orders", "john", fiber.StatusOK},
+		{fiber.MethodPost, "/orders", "john", fiber.StatusOK},
+		{fiber.MethodDelete, "/orders", "john", fiber.StatusForbidden},
+		{fiber.MethodHead, "/orders", "anonymous", fiber.StatusForbidden},
+		// Deny rules win over roles
+		{fiber.MethodGet, "/orders", "jane", fiber.StatusForbidden},
+		// Conditions on request attributes
+		{fiber.MethodGet, "/customers/carl/orders", "carl", fiber.StatusOK},
+		{fiber.MethodGet, "/customers/john/orders", "carl", fiber.StatusForbidden},
+		{fiber.MethodGet, "/orders", "carl", fiber.StatusForbidden},
+		// Decoded metadata, invalid metadata fails closed
+		{fiber.MethodPut, "/orders", "john", fiber.StatusOK},
+		{fiber.MethodPut, "/orders", "carl", fiber.StatusForbidden},
+		{fiber.MethodGet, "/invalid", "john", fiber.StatusForbidden},
+		// Routes without permissions
+		{fiber.MethodGet, "/public", "", fiber.StatusOK},
+		{fiber.MethodGet, "/missing", "", fiber.StatusNotFound},
+	}
+	for _, tt := range tests {
+		utils.AssertEqual(t, tt.status, status(t, app, tt.method, tt.path, tt.user), tt.method+" "+tt.path+" "+tt.user)
+	}
+}
+
+// go test -run Test_Authz_DenyByDefault
+func Test_Authz_DenyByDefault(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t, Config{DenyByDefault: true})
+
+	utils.AssertEqual(t, fiber.StatusUnauthorized, status(t, app, fiber.MethodGet, "/public", ""))
+	utils.AssertEqual(t, fiber.StatusForbidden, status(t, app, fiber.MethodGet, "/public", "john"))
+	utils.AssertEqual(t, fiber.StatusOK, status(t, app, fiber.MethodGet, "/orders", "john"))
+}
+
+// go test -run Test_Authz_PolicyFile
+func Test_Authz_PolicyFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "policy.json")
+	utils.AssertEqual(t, nil, os.WriteFile(path, []byte(testPolicy), 0o600))
+
+	app := newTestApp(t, Config{PolicyFile: path})
+	utils.AssertEqual(t, fiber.StatusOK, status(t, app, fiber.MethodPost, "/orders", "john"))
+	utils.AssertEqual(t, fiber.StatusForbidden, status(t, app, fiber.MethodDelete, "/orders", "john"))
+}
+
+// go test -run Test_Authz_Route_Handler
+func Test_Authz_Route_Handler(t *testing.T) {
+	t.Parallel()
+
+	policy, err := ParsePolicy([]byte(testPolicy))
+	utils.AssertEqual(t, nil, err)
+
+	app := fiber.New()
+	app.Post("/orders", func(c *fiber.Ctx) error {
+		c.Locals("username", "john")
+		return c.Next()
+	}, New(Config{
+		Policy: policy,
+		Identity: func(c *fiber.Ctx) *Identity {
+			return &Identity{ID: c.Locals("username").(string), Roles: []string{"viewer"}}
+		},
+	}), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	}).Meta("perm", "orders:write")
+
+	utils.AssertEqual(t, fiber.StatusForbidden, status(t, app, fiber.MethodPost, "/orders", ""))
+}
+
+// go test -run Test_Authz_ParsePolicy
+func Test_Authz_ParsePolicy(t *testing.T) {
+	t.Parallel()
+
+	_, err := ParsePolicy([]byte(`{"roles": {"a": {"inherits": ["b"]}}}`))
+	utils.AssertEqual(t, `role "a" inherits unknown role "b"`, err.Error())
+
+	_, err = ParsePolicy([]byte(`{"rules": [{"effect": "maybe", "permissions": ["*"]}]}`))
+	utils.AssertEqual(t, `rule 0: unknown effect "maybe"`, err.Error())
+
+	_, err = ParsePolicy([]byte(`{"rules": [{"effect": "allow"}]}`))
+	utils.AssertEqual(t, `rule 0: no permissions`, err.Error())
+
+	// Inheritance cycles are allowed
+	policy, err := ParsePolicy([]byte(`{"roles": {"a": {"inherits": ["b"]}, "b": {"inherits": ["a"], "permissions": ["x"]}}}`))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, true, policy.roleGrants([]string{"a"}, "x", make(map[string]bool)))
+	utils.AssertEqual(t, false, policy.roleGrants([]string{"a"}, "y", make(map[string]bool)))
+}
@@ -0,0 +1,116 @@
+package authz
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// Config defines the config for middleware.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	//
+	// Optional. Default: nil
+	Next func(c *fiber.Ctx) bool
+
+	// Policy defines the roles and rules permissions are checked against
+	//
+	// Required if PolicyFile is not set. Default: nil
+	Policy *Policy
+
+	// PolicyFile is the path of a JSON policy file
+	//
+	// Required if Policy is not set. Default: ""
+	PolicyFile string
+
+	// MetaKey is the route metadata key holding the required permissions,
+	// either a string or a []string of which all must be granted. Routes
+	// with other values are forbidden.
+	//
+	// Optional. Default: "perm"
+	MetaKey string
+
+	// DenyByDefault rejects requests to routes without permission metadata
+	//
+	// Optional. Default: false
+	DenyByDefault bool
+
+	// ContextKey is the key in Locals holding the identity stored by an
+	// authentication middleware: an *Identity, a username string, or a claims map
+	//
+	// Optional. Default: "user"
+	ContextKey string
+
+	// RolesClaim is the claim holding the roles if the identity is a claims map
+	//
+	// Optional. Default: "roles"
+	RolesClaim string
+
+	// Identity returns the identity of the request, or nil for anonymous requests
+	//
+	// Optional. Default: reads the identity from Locals using ContextKey
+	Identity func(c *fiber.Ctx) *Identity
+
+	// Unauthorized is called if permissions are required but there is no identity
+	//
+	// Optional. Default: func(c *fiber.Ctx) error {
+	//   return fiber.ErrUnauthorized
+	// }
+	Unauthorized fiber.Handler
+
+	// Forbidden is called if a permission is not granted
+	//
+	// Optional. Default: func(c *fiber.Ctx) error {
+	//   return fiber.ErrForbidden
+	// }
+	Forbidden fiber.Handler
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	Next:          nil,
+	MetaKey:       "perm",
+	DenyByDefault: false,
+	ContextKey:    "user",
+	RolesClaim:    "roles",
+	Unauthorized: func(c *fiber.Ctx) error {
+		return fiber.ErrUnauthorized
+	},
+	Forbidden: func(c *fiber.Ctx) error {
+		return fiber.ErrForbidden
+	},
+}
+
+// Helper function to set default values
+func configDefault(config Config) Config {
+	cfg := config
+
+	// Set default values
+	if cfg.Policy == nil && cfg.PolicyFile == "" {
+		panic("[AUTHZ] Policy or PolicyFile is required")
+	}
+	if cfg.Policy == nil {
+		policy, err := LoadPolicy(cfg.PolicyFile)
+		if err != nil {
+			panic("[AUTHZ] failed to load policy: " + err.Error())
+		}
+		cfg.Policy = policy
+	}
+	if cfg.MetaKey == "" {
+		cfg.MetaKey = ConfigDefault.MetaKey
+	}
+	if cfg.ContextKey == "" {
+		cfg.ContextKey = ConfigDefault.ContextKey
+	}
+	if cfg.RolesClaim == "" {
+		cfg.RolesClaim = ConfigDefault.RolesClaim
+	}
+	if cfg.Identity == nil {
+		cfg.Identity = identityFromLocals(cfg.ContextKey, cfg.RolesClaim)
+	}
+	if cfg.Unauthorized == nil {
+		cfg.Unauthorized = ConfigDefault.Unauthorized
+	}
+	if cfg.Forbidden == nil {
+		cfg.Forbidden = ConfigDefault.Forbidden
+	}
+	return cfg
+}
@@ -0,0 +1,82 @@
+package authz
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Identity is the authenticated subject of a request
+type Identity struct {
+	// ID identifies the subject, e.g. a username or the "sub" claim
+	ID string
+	// Roles are the roles granted to the subject
+	Roles []string
+	// Attributes are available to policy conditions as identity.<name>
+	Attributes map[string]interface{}
+}
+
+// identityFromLocals returns a function that reads the identity stored by an
+// authentication middleware. Claims maps, like the ones of the jwt middleware,
+// are converted using their "sub" and roles claims.
+func identityFromLocals(key, rolesClaim string) func(c *fiber.Ctx) *Identity {
+	return func(c *fiber.Ctx) *Identity {
+		switch v := c.Locals(key).(type) {
+		case nil:
+			return nil
+		case *Identity:
+			return v
+		case Identity:
+			return &v
+		case string:
+			if v == "" {
+				return nil
+			}
+			return &Identity{ID: v}
+		default:
+			claims := toMap(v)
+			if claims == nil {
+				return nil
+			}
+			id, _ := claims["sub"].(string)
+			return &Identity{ID: id, Roles: toStrings(claims[rolesClaim]), Attributes: claims}
+		}
+	}
+}
+
+// toMap converts a map with string keys, e.g. a named claims type
+func toMap(v interface{}) map[string]interface{} {
+	if m, ok := v.(map[string]interface{}); ok {
+		return m
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return nil
+	}
+	m := make(map[string]interface{}, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		m[iter.Key().String()] = iter.Value().Interface()
+	}
+	return m
+}
+
+// toStrings converts a roles claim, which can be an array or a space separated string
+func toStrings(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return strings.Fields(v)
+	case []string:
+		return v
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, e := range v {
+			if s, ok := e.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
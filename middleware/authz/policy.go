@@ -0,0 +1,187 @@
+package authz
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Policy grants permissions to roles (RBAC) and through rules with
+// conditions on the identity and request attributes (ABAC).
+//
+//	{
+//	  "roles": {
+//	    "viewer": {"permissions": ["orders:read"]},
+//	    "clerk":  {"permissions": ["orders:write"], "inherits": ["viewer"]}
+//	  },
+//	  "rules": [
+//	    {"effect": "deny", "permissions": ["orders:*"], "when": {"identity.suspended": "true"}},
+//	    {"effect": "allow", "permissions": ["orders:read"], "when": {"identity.id": "$param.customer"}}
+//	  ]
+//	}
+type Policy struct {
+	Roles map[string]Role `json:"roles"`
+	Rules []Rule          `json:"rules"`
+}
+
+// Role is a named set of permissions
+type Role struct {
+	// Permissions granted to the role, "*" and "prefix:*" patterns are allowed
+	Permissions []string `json:"permissions"`
+	// Inherits lists roles whose permissions are granted as well
+	Inherits []string `json:"inherits"`
+}
+
+// Rule allows or denies permissions if all of its conditions match.
+// Deny rules take precedence over everything else.
+type Rule struct {
+	// Effect is either "allow" or "deny"
+	Effect string `json:"effect"`
+	// Permissions the rule applies to, "*" and "prefix:*" patterns are allowed
+	Permissions []string `json:"permissions"`
+	// Roles restricts the rule to identities with one of the roles
+	Roles []string `json:"roles"`
+	// When maps attributes to expected values. Attributes are identity.id,
+	// identity.<attribute>, param.<name>, query.<name>, header.<name>,
+	// route.<metadata key>, method, path and ip. A value starting with $
+	// refers to another attribute.
+	When map[string]string `json:"when"`
+}
+
+// Rule effects
+const (
+	EffectAllow = "allow"
+	EffectDeny  = "deny"
+)
+
+// ParsePolicy parses and validates a JSON policy
+func ParsePolicy(data []byte) (*Policy, error) {
+	var p Policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	for name, role := range p.Roles {
+		for _, parent := range role.Inherits {
+			if _, ok := p.Roles[parent]; !ok {
+				return nil, fmt.Errorf("role %q inherits unknown role %q", name, parent)
+			}
+		}
+	}
+	for i := range p.Rules {
+		switch p.Rules[i].Effect {
+		case "":
+			p.Rules[i].Effect = EffectAllow
+		case EffectAllow, EffectDeny:
+		default:
+			return nil, fmt.Errorf("rule %d: unknown effect %q", i, p.Rules[i].Effect)
+		}
+		if len(p.Rules[i].Permissions) == 0 {
+			return nil, fmt.Errorf("rule %d: no permissions", i)
+		}
+	}
+	return &p, nil
+}
+
+// LoadPolicy reads a JSON policy file
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePolicy(data)
+}
+
+// errDenied is returned by check if a permission is not granted
+var errDenied = errors.New("permission denied")
+
+// check returns nil if all permissions are granted to the identity.
+// attr resolves request attributes used in rule conditions.
+func (p *Policy) check(id *Identity, perms []string, attr func(name string) (string, bool)) error {
+	for _, perm := range perms {
+		allowed := p.roleGrants(id.Roles, perm, make(map[string]bool))
+		for i := range p.Rules {
+			rule := &p.Rules[i]
+			if !rule.matches(id, perm, attr) {
+				continue
+			}
+			if rule.Effect == EffectDeny {
+				return errDenied
+			}
+			allowed = true
+		}
+		if !allowed {
+			return errDenied
+		}
+	}
+	return nil
+}
+
+// roleGrants reports whether one of the roles or their parents grants perm
+func (p *Policy) roleGrants(roles []string, perm string, seen map[string]bool) bool {
+	for _, name := range roles {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		role, ok := p.Roles[name]
+		if !ok {
+			continue
+		}
+		if matchAny(role.Permissions, perm) || p.roleGrants(role.Inherits, perm, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether the rule applies to the identity and permission
+func (r *Rule) matches(id *Identity, perm string, attr func(name string) (string, bool)) bool {
+	if !matchAny(r.Permissions, perm) {
+		return false
+	}
+	if len(r.Roles) > 0 && !hasAny(id.Roles, r.Roles) {
+		return false
+	}
+	for name, want := range r.When {
+		got, ok := attr(name)
+		if !ok {
+			return false
+		}
+		if strings.HasPrefix(want, "$") {
+			if want, ok = attr(want[1:]); !ok {
+				return false
+			}
+		}
+		if got != want {
+			return false
+		}
+	}
+	return true
+}
+
+// matchAny reports whether perm matches one of the patterns
+func matchAny(patterns []string, perm string) bool {
+	for _, pattern := range patterns {
+		if pattern == "*" || pattern == perm {
+			return true
+		}
+		if strings.HasSuffix(pattern, "*") && strings.HasPrefix(perm, pattern[:len(pattern)-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAny reports whether a and b have a common element
+func hasAny(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
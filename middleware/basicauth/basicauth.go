@@ -2,7 +2,9 @@ package basicauth
 
 import (
 	"encoding/base64"
+	"errors"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/utils"
@@ -13,6 +15,11 @@ func New(config Config) fiber.Handler {
 	// Set default config
 	cfg := configDefault(config)
 
+	var locks *lockout
+	if cfg.MaxAttempts > 0 {
+		locks = &lockout{storage: cfg.Storage, cfg: &cfg}
+	}
+
 	// Return new handler
 	return func(c *fiber.Ctx) error {
 		// Don't execute middleware if Next returns true
@@ -48,13 +55,82 @@ func New(config Config) fiber.Handler {
 		username := creds[:index]
 		password := creds[index+1:]
 
+		// Reject locked out requests without checking the password
+		var userKey, ipKey string
+		if locks != nil {
+			userKey, ipKey = cfg.LockoutKey(c, username), cfg.LockoutIPKey(c)
+			now := time.Now()
+			retryAfter := locks.locked(userKey, now)
+			if ipRetryAfter := locks.locked(ipKey, now); ipRetryAfter > retryAfter {
+				retryAfter = ipRetryAfter
+			}
+			if retryAfter > 0 {
+				return cfg.Locked(c, retryAfter)
+			}
+		}
+
 		if cfg.Authorizer(username, password) {
+			// The failures of the IP are kept, so a known password can't be
+			// used to reset them while guessing other passwords
+			if locks != nil {
+				locks.reset(userKey)
+			}
 			c.Locals(cfg.ContextUsername, username)
 			c.Locals(cfg.ContextPassword, password)
 			return c.Next()
 		}
 
 		// Authentication failed
+		if locks != nil {
+			now := time.Now()
+			retryAfter := locks.fail(userKey, cfg.MaxAttempts, now)
+			if ipRetryAfter := locks.fail(ipKey, cfg.MaxIPAttempts, now); ipRetryAfter > retryAfter {
+				retryAfter = ipRetryAfter
+			}
+			if retryAfter > 0 {
+				return cfg.Locked(c, retryAfter)
+			}
+		}
 		return cfg.Unauthorized(c)
 	}
 }
+
+// newAuthorizer returns an authorizer that checks the credentials of users and
+// the htpasswd file. Passwords are always compared in constant time, and unknown
+// usernames are checked against a stored credential so they take as long as known ones.
+func newAuthorizer(users map[string]string, file string) func(string, string) bool {
+	verifiers := make(map[string]verifier, len(users))
+	var dummy verifier
+	for user, pass := range users {
+		v, err := parseHash(pass)
+		if errors.Is(err, errUnknownHash) {
+			v, err = plain(pass), nil
+		}
+		if err != nil {
+			panic("[BASICAUTH] invalid password hash of user " + user + ": " + err.Error())
+		}
+		verifiers[user] = v
+		dummy = v
+	}
+	if file != "" {
+		fromFile, err := loadHtpasswd(file)
+		if err != nil {
+			panic("[BASICAUTH] failed to load users file: " + err.Error())
+		}
+		for user, v := range fromFile {
+			verifiers[user] = v
+			dummy = v
+		}
+	}
+
+	return func(user, pass string) bool {
+		v, exist := verifiers[user]
+		if !exist {
+			if dummy != nil {
+				_ = dummy(pass)
+			}
+			return false
+		}
+		return v(pass)
+	}
+}
@@ -4,15 +4,33 @@ import (
 	"fmt"
 	"io"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
 	"testing"
+	"time"
 
 	b64 "encoding/base64"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/utils"
 	"github.com/valyala/fasthttp"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
 )
 
+func authRequest(t *testing.T, app *fiber.App, username, password string, ip ...string) int {
+	t.Helper()
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+	req.Header.Set(fiber.HeaderAuthorization, "Basic "+b64.StdEncoding.EncodeToString([]byte(username+":"+password)))
+	if len(ip) > 0 {
+		req.Header.Set(fiber.HeaderXForwardedFor, ip[0])
+	}
+	resp, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	return resp.StatusCode
+}
+
 // go test -run Test_BasicAuth_Next
 func Test_BasicAuth_Next(t *testing.T) {
 	t.Parallel()
@@ -93,6 +111,229 @@ func Test_Middleware_BasicAuth(t *testing.T) {
 	}
 }
 
+// go test -run Test_BasicAuth_SHA512Crypt
+func Test_BasicAuth_SHA512Crypt(t *testing.T) {
+	t.Parallel()
+
+	// Test vectors from https://www.akkadia.org/drepper/SHA-crypt.txt
+	tests := []struct {
+		salt     string
+		rounds   int
+		password string
+		hash     string
+	}{
+		{"saltstring", 0, "Hello world!", "$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1"},
+		{"saltstringsaltstring", 10000, "Hello world!", "$6$rounds=10000$saltstringsaltst$OW1/O6BYHV6BcXZu8QVeXbDWra3Oeqh0sbHbbMCVNSnCM/UrjmM0Dp8vOuZeHBy/YTBmSK6H9qs/y3RnOaw5v."},
+		{"toolongsaltstring", 5000, "This is just a test", "$6$rounds=5000$toolongsaltstrin$lQ8jolhgVRVhY4b5pZKaysCLi0QBxGoNeKQzQ3glMhwllF7oGDZxUhx1yxdYcz/e1JSbq3y6JMxxl8audkUEm0"},
+		{"anotherlongsaltstring", 1400, "a very much longer text to encrypt.  This one even stretches over morethan one line.", "$6$rounds=1400$anotherlongsalts$POfYwTEok97VWcjxIiSOjiykti.o/pQs.wPvMxQ6Fm7I6IoYN3CmLs66x9t0oSwbtEW7o7UmJEiDwGqd8p4ur1"},
+		{"roundstoolow", 10, "the minimum number is still observed", "$6$rounds=1000$roundstoolow$kUMsbe306n21p9R.FRkW3IGn.S9NPN0x50YhH1xhLsPuWGsUSklZt58jaTfF4ZEQpyUNGc0dqbpBYYBaHHrsX."},
+	}
+	for _, tt := range tests {
+		utils.AssertEqual(t, tt.hash, sha512Crypt(tt.password, tt.salt, tt.rounds))
+
+		v, err := parseHash(tt.hash)
+		utils.AssertEqual(t, nil, err)
+		utils.AssertEqual(t, true, v(tt.password))
+		utils.AssertEqual(t, false, v("wrong"))
+	}
+
+	// Rounds outside the allowed range are kept as stored
+	v, err := parseHash("$6$rounds=10$roundstoolow$kUMsbe306n21p9R.FRkW3IGn.S9NPN0x50YhH1xhLsPuWGsUSklZt58jaTfF4ZEQpyUNGc0dqbpBYYBaHHrsX.")
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, true, v("the minimum number is still observed"))
+	utils.AssertEqual(t, false, v("wrong"))
+}
+
+// go test -run Test_BasicAuth_APR1
+func Test_BasicAuth_APR1(t *testing.T) {
+	t.Parallel()
+
+	// Test vectors from openssl passwd -apr1
+	tests := []struct {
+		salt     string
+		password string
+		hash     string
+	}{
+		{"r31abcde", "password", "$apr1$r31abcde$ouL8QL9v/FwrkrtBccxbL."},
+		{"x", "", "$apr1$x$tMwYqBfQwi3FYAr0aJc8M/"},
+		{"longersaltvalue", "a much longer password that exceeds sixteen bytes", "$apr1$longersa$iw4tjyY6Vl1.RoqqV35B/0"},
+	}
+	for _, tt := range tests {
+		utils.AssertEqual(t, tt.hash, apr1Crypt(tt.password, tt.salt))
+
+		v, err := parseHash(tt.hash)
+		utils.AssertEqual(t, nil, err)
+		utils.AssertEqual(t, true, v(tt.password))
+		utils.AssertEqual(t, false, v("wrong"))
+	}
+
+	_, err := parseHash("$apr1$salt")
+	utils.AssertEqual(t, "invalid apr1 MD5 hash", err.Error())
+}
+
+// go test -run Test_BasicAuth_Hashed_Users
+func Test_BasicAuth_Hashed_Users(t *testing.T) {
+	t.Parallel()
+
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("bcrypt-pass"), bcrypt.MinCost)
+	utils.AssertEqual(t, nil, err)
+	salt := []byte("somesaltsomesalt")
+	argonHash := fmt.Sprintf("$argon2id$v=19$m=1024,t=1,p=1$%s$%s",
+		b64.RawStdEncoding.EncodeToString(salt),
+		b64.RawStdEncoding.EncodeToString(argon2.IDKey([]byte("argon-pass"), salt, 1, 1024, 1, 32)))
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Users: map[string]string{
+			"plain":  "plain-pass",
+			"bcrypt": string(bcryptHash),
+			"argon":  argonHash,
+			"sha512": sha512Crypt("sha512-pass", "saltsalt", 0),
+			"sha1":   "{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=", // password
+		},
+	}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString(c.Locals("username").(string))
+	})
+
+	for user, pass := range map[string]string{
+		"plain":  "plain-pass",
+		"bcrypt": "bcrypt-pass",
+		"argon":  "argon-pass",
+		"sha512": "sha512-pass",
+		"sha1":   "password",
+	} {
+		utils.AssertEqual(t, fiber.StatusOK, authRequest(t, app, user, pass), user)
+		utils.AssertEqual(t, fiber.StatusUnauthorized, authRequest(t, app, user, pass+"x"), user)
+	}
+	utils.AssertEqual(t, fiber.StatusUnauthorized, authRequest(t, app, "unknown", "plain-pass"))
+}
+
+// go test -run Test_BasicAuth_UsersFile
+func Test_BasicAuth_UsersFile(t *testing.T) {
+	t.Parallel()
+
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("doe"), bcrypt.MinCost)
+	utils.AssertEqual(t, nil, err)
+	path := filepath.Join(t.TempDir(), ".htpasswd")
+	content := "# users\n\njohn:" + string(bcryptHash) + "\nadmin:" + sha512Crypt("123456", "salt", 0) + "\n"
+	utils.AssertEqual(t, nil, os.WriteFile(path, []byte(content), 0o600))
+
+	app := fiber.New()
+	app.Use(New(Config{UsersFile: path}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	utils.AssertEqual(t, fiber.StatusOK, authRequest(t, app, "john", "doe"))
+	utils.AssertEqual(t, fiber.StatusOK, authRequest(t, app, "admin", "123456"))
+	utils.AssertEqual(t, fiber.StatusUnauthorized, authRequest(t, app, "admin", "doe"))
+
+	// Plaintext passwords are not allowed in the file
+	utils.AssertEqual(t, nil, os.WriteFile(path, []byte("john:doe\n"), 0o600))
+	defer func() {
+		utils.AssertEqual(t, true, recover() != nil)
+	}()
+	New(Config{UsersFile: path})
+}
+
+// go test -run Test_BasicAuth_Lockout
+func Test_BasicAuth_Lockout(t *testing.T) {
+	t.Parallel()
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Users:           map[string]string{"john": "doe"},
+		MaxAttempts:     2,
+		LockoutDuration: 2 * time.Second,
+	}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	// A success resets the failures
+	utils.AssertEqual(t, fiber.StatusUnauthorized, authRequest(t, app, "john", "wrong"))
+	utils.AssertEqual(t, fiber.StatusOK, authRequest(t, app, "john", "doe"))
+	utils.AssertEqual(t, fiber.StatusUnauthorized, authRequest(t, app, "john", "wrong"))
+
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+	req.Header.Set(fiber.HeaderAuthorization, "Basic "+b64.StdEncoding.EncodeToString([]byte("john:wrong")))
+	resp, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusTooManyRequests, resp.StatusCode)
+	utils.AssertEqual(t, "2", resp.Header.Get(fiber.HeaderRetryAfter))
+
+	// Locked out even with the correct password, other usernames are not affected
+	utils.AssertEqual(t, fiber.StatusTooManyRequests, authRequest(t, app, "john", "doe"))
+	utils.AssertEqual(t, fiber.StatusUnauthorized, authRequest(t, app, "jane", "doe"))
+
+	time.Sleep(2 * time.Second)
+	utils.AssertEqual(t, fiber.StatusOK, authRequest(t, app, "john", "doe"))
+}
+
+// go test -run Test_BasicAuth_Lockout_Spraying
+func Test_BasicAuth_Lockout_Spraying(t *testing.T) {
+	t.Parallel()
+
+	app := fiber.New(fiber.Config{ProxyHeader: fiber.HeaderXForwardedFor})
+	app.Use(New(Config{
+		Users:         map[string]string{"john": "doe", "jane": "doe"},
+		MaxAttempts:   2,
+		MaxIPAttempts: 3,
+	}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	// A username is locked out for all IPs
+	utils.AssertEqual(t, fiber.StatusUnauthorized, authRequest(t, app, "john", "wrong", "10.0.0.1"))
+	utils.AssertEqual(t, fiber.StatusTooManyRequests, authRequest(t, app, "john", "wrong", "10.0.0.2"))
+	utils.AssertEqual(t, fiber.StatusTooManyRequests, authRequest(t, app, "john", "doe", "10.0.0.3"))
+
+	// An IP is locked out for all usernames, successful attempts don't
+	// reset its failures
+	utils.AssertEqual(t, fiber.StatusUnauthorized, authRequest(t, app, "a", "wrong", "10.0.0.4"))
+	utils.AssertEqual(t, fiber.StatusOK, authRequest(t, app, "jane", "doe", "10.0.0.4"))
+	utils.AssertEqual(t, fiber.StatusUnauthorized, authRequest(t, app, "b", "wrong", "10.0.0.4"))
+	utils.AssertEqual(t, fiber.StatusTooManyRequests, authRequest(t, app, "c", "wrong", "10.0.0.4"))
+	utils.AssertEqual(t, fiber.StatusTooManyRequests, authRequest(t, app, "jane", "doe", "10.0.0.4"))
+	utils.AssertEqual(t, fiber.StatusOK, authRequest(t, app, "jane", "doe", "10.0.0.5"))
+}
+
+// go test -run Test_BasicAuth_Lockout_Backoff
+func Test_BasicAuth_Lockout_Backoff(t *testing.T) {
+	t.Parallel()
+
+	cfg := configDefault(Config{MaxAttempts: 1, LockoutDuration: time.Minute, MaxLockoutDuration: 3 * time.Minute})
+	l := &lockout{storage: cfg.Storage, cfg: &cfg}
+	now := time.Now()
+
+	utils.AssertEqual(t, time.Minute, l.fail("key", 1, now))
+	utils.AssertEqual(t, time.Minute, l.locked("key", now))
+	utils.AssertEqual(t, 2*time.Minute, l.fail("key", 1, now))
+	utils.AssertEqual(t, 3*time.Minute, l.fail("key", 1, now))
+	l.reset("key")
+	utils.AssertEqual(t, time.Duration(0), l.locked("key", now))
+}
+
+// go test -run Test_BasicAuth_Lockout_Backoff_Overflow
+func Test_BasicAuth_Lockout_Backoff_Overflow(t *testing.T) {
+	t.Parallel()
+
+	cfg := configDefault(Config{MaxAttempts: 1, LockoutDuration: 3 * time.Second, MaxLockoutDuration: 20 * 365 * 24 * time.Hour})
+	l := &lockout{storage: cfg.Storage, cfg: &cfg}
+	now := time.Now()
+
+	// Shifting by the lockout count overflows after enough lockouts
+	var last time.Duration
+	for i := 0; i < 100; i++ {
+		duration := l.fail("key", 1, now)
+		utils.AssertEqual(t, true, duration >= last, strconv.Itoa(i))
+		last = duration
+	}
+	utils.AssertEqual(t, cfg.MaxLockoutDuration, last)
+}
+
 // go test -v -run=^$ -bench=Benchmark_Middleware_BasicAuth -benchmem -count=4
 func Benchmark_Middleware_BasicAuth(b *testing.B) {
 	app := fiber.New()
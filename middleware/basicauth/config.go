@@ -1,10 +1,11 @@
 package basicauth
 
 import (
-	"crypto/subtle"
+	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/utils"
+	"github.com/gofiber/fiber/v2/internal/storage/memory"
 )
 
 // Config defines the config for middleware.
@@ -14,11 +15,19 @@ type Config struct {
 	// Optional. Default: nil
 	Next func(c *fiber.Ctx) bool
 
-	// Users defines the allowed credentials
+	// Users defines the allowed credentials. Passwords can be given in plaintext
+	// or as bcrypt ($2a$, $2b$, $2y$), argon2 ($argon2id$, $argon2i$),
+	// SHA-512 crypt ($6$), apr1 MD5 ($apr1$) or {SHA} hashes.
 	//
 	// Required. Default: map[string]string{}
 	Users map[string]string
 
+	// UsersFile is the path of an htpasswd file with additional credentials.
+	// All passwords in the file must be hashed.
+	//
+	// Optional. Default: ""
+	UsersFile string
+
 	// Realm is a string to define realm attribute of BasicAuth.
 	// the realm identifies the system to authenticate against
 	// and can be used by clients to save credentials
@@ -50,17 +59,81 @@ type Config struct {
 	//
 	// Optional. Default: "password"
 	ContextPassword string
+
+	// MaxAttempts is the number of failed attempts after which a username
+	// is locked out. Lockout is disabled if 0.
+	//
+	// Optional. Default: 0
+	MaxAttempts int
+
+	// MaxIPAttempts is the number of failed attempts after which a client
+	// IP is locked out, counting the failures of all usernames. Unlike the
+	// failures of a username, they aren't reset by successful attempts.
+	//
+	// Optional. Default: 10 * MaxAttempts
+	MaxIPAttempts int
+
+	// LockoutDuration is the duration of the first lockout,
+	// it doubles with every consecutive lockout.
+	//
+	// Optional. Default: 1 * time.Minute
+	LockoutDuration time.Duration
+
+	// MaxLockoutDuration is the upper limit of the lockout duration.
+	// Failures are forgotten after a quiet period of this duration.
+	//
+	// Optional. Default: 1 * time.Hour
+	MaxLockoutDuration time.Duration
+
+	// LockoutKey generates the key failed attempts of a username are
+	// counted by
+	//
+	// Optional. Default: func(c *fiber.Ctx, username string) string {
+	//   return "user|" + username
+	// }
+	LockoutKey func(c *fiber.Ctx, username string) string
+
+	// LockoutIPKey generates the key failed attempts of a client IP are
+	// counted by
+	//
+	// Optional. Default: func(c *fiber.Ctx) string {
+	//   return "ip|" + c.IP()
+	// }
+	LockoutIPKey func(c *fiber.Ctx) string
+
+	// Locked defines the response for locked out requests
+	//
+	// Optional. Default: 429 with a Retry-After header
+	Locked func(c *fiber.Ctx, retryAfter time.Duration) error
+
+	// Storage is used to store the failed attempts
+	//
+	// Default: an in memory store for this process only
+	Storage fiber.Storage
 }
 
 // ConfigDefault is the default config
 var ConfigDefault = Config{
-	Next:            nil,
-	Users:           map[string]string{},
-	Realm:           "Restricted",
-	Authorizer:      nil,
-	Unauthorized:    nil,
-	ContextUsername: "username",
-	ContextPassword: "password",
+	Next:               nil,
+	Users:              map[string]string{},
+	Realm:              "Restricted",
+	Authorizer:         nil,
+	Unauthorized:       nil,
+	ContextUsername:    "username",
+	ContextPassword:    "password",
+	MaxAttempts:        0,
+	LockoutDuration:    1 * time.Minute,
+	MaxLockoutDuration: 1 * time.Hour,
+	LockoutKey: func(c *fiber.Ctx, username string) string {
+		return "user|" + username
+	},
+	LockoutIPKey: func(c *fiber.Ctx) string {
+		return "ip|" + c.IP()
+	},
+	Locked: func(c *fiber.Ctx, retryAfter time.Duration) error {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int((retryAfter+time.Second-1)/time.Second)))
+		return c.SendStatus(fiber.StatusTooManyRequests)
+	},
 }
 
 // Helper function to set default values
@@ -84,10 +157,7 @@ func configDefault(config ...Config) Config {
 		cfg.Realm = ConfigDefault.Realm
 	}
 	if cfg.Authorizer == nil {
-		cfg.Authorizer = func(user, pass string) bool {
-			userPwd, exist := cfg.Users[user]
-			return exist && subtle.ConstantTimeCompare(utils.UnsafeBytes(userPwd), utils.UnsafeBytes(pass)) == 1
-		}
+		cfg.Authorizer = newAuthorizer(cfg.Users, cfg.UsersFile)
 	}
 	if cfg.Unauthorized == nil {
 		cfg.Unauthorized = func(c *fiber.Ctx) error {
@@ -101,5 +171,26 @@ func configDefault(config ...Config) Config {
 	if cfg.ContextPassword == "" {
 		cfg.ContextPassword = ConfigDefault.ContextPassword
 	}
+	if cfg.LockoutDuration <= 0 {
+		cfg.LockoutDuration = ConfigDefault.LockoutDuration
+	}
+	if cfg.MaxLockoutDuration <= 0 {
+		cfg.MaxLockoutDuration = ConfigDefault.MaxLockoutDuration
+	}
+	if cfg.MaxIPAttempts <= 0 {
+		cfg.MaxIPAttempts = 10 * cfg.MaxAttempts
+	}
+	if cfg.LockoutKey == nil {
+		cfg.LockoutKey = ConfigDefault.LockoutKey
+	}
+	if cfg.LockoutIPKey == nil {
+		cfg.LockoutIPKey = ConfigDefault.LockoutIPKey
+	}
+	if cfg.Locked == nil {
+		cfg.Locked = ConfigDefault.Locked
+	}
+	if cfg.MaxAttempts > 0 && cfg.Storage == nil {
+		cfg.Storage = memory.New()
+	}
 	return cfg
 }
@@ -0,0 +1,374 @@
+package basicauth
+
+import (
+	"bufio"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2/utils"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// verifier reports whether a password matches a stored credential
+type verifier func(password string) bool
+
+// errUnknownHash is returned for credentials that are not in a supported hash format
+var errUnknownHash = errors.New("unsupported password hash")
+
+// parseHash returns a verifier for a password hash. Supported formats are
+// bcrypt ($2a$, $2b$, $2y$), argon2 ($argon2id$, $argon2i$),
+// SHA-512 crypt ($6$) and the htpasswd {SHA} and MD5 ($apr1$) formats.
+func parseHash(hash string) (verifier, error) {
+	switch {
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+			return nil, err
+		}
+		return func(password string) bool {
+			return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+		}, nil
+	case strings.HasPrefix(hash, "$argon2id$"), strings.HasPrefix(hash, "$argon2i$"):
+		return parseArgon2(hash)
+	case strings.HasPrefix(hash, "$6$"):
+		rounds, salt, err := parseSHA512Crypt(hash)
+		if err != nil {
+			return nil, err
+		}
+		// Keep the rounds and salt fields as stored, they may have been clamped
+		prefix := hash[:strings.LastIndexByte(hash, '$')+1]
+		return func(password string) bool {
+			return subtle.ConstantTimeCompare([]byte(prefix+sha512CryptDigest(password, salt, rounds)), []byte(hash)) == 1
+		}, nil
+	case strings.HasPrefix(hash, "$apr1$"):
+		parts := strings.Split(hash, "$")
+		if len(parts) != 4 {
+			return nil, errors.New("invalid apr1 MD5 hash")
+		}
+		salt := parts[2]
+		return func(password string) bool {
+			return subtle.ConstantTimeCompare([]byte(apr1Crypt(password, salt)), []byte(hash)) == 1
+		}, nil
+	case strings.HasPrefix(hash, "{SHA}"):
+		expected, err := base64.StdEncoding.DecodeString(hash[5:])
+		if err != nil {
+			return nil, err
+		}
+		return func(password string) bool {
+			sum := sha1.Sum([]byte(password))
+			return subtle.ConstantTimeCompare(sum[:], expected) == 1
+		}, nil
+	}
+	return nil, errUnknownHash
+}
+
+// plain returns a verifier for a plaintext password
+func plain(password string) verifier {
+	return func(pass string) bool {
+		return subtle.ConstantTimeCompare(utils.UnsafeBytes(password), utils.UnsafeBytes(pass)) == 1
+	}
+}
+
+// parseArgon2 parses a hash in the PHC string format,
+// e.g. $argon2id$v=19$m=65536,t=3,p=4$<salt>$<key>
+func parseArgon2(hash string) (verifier, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[2] != "v=19" {
+		return nil, errors.New("invalid argon2 hash")
+	}
+	var memory, time uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+		return nil, errors.New("invalid argon2 parameters")
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, err
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return nil, err
+	}
+	derive := argon2.IDKey
+	if parts[1] == "argon2i" {
+		derive = argon2.Key
+	}
+	return func(password string) bool {
+		return subtle.ConstantTimeCompare(derive([]byte(password), salt, time, memory, threads, uint32(len(key))), key) == 1
+	}, nil
+}
+
+// SHA-512 crypt parameters, see https://www.akkadia.org/drepper/SHA-crypt.txt
+const (
+	sha512CryptRounds    = 5000
+	sha512CryptMinRounds = 1000
+	sha512CryptMaxRounds = 999999999
+	sha512CryptMaxSalt   = 16
+)
+
+// parseSHA512Crypt returns the rounds and salt of a $6$ hash. The rounds
+// are 0 if the hash has no rounds field.
+func parseSHA512Crypt(hash string) (int, string, error) {
+	parts := strings.Split(hash[3:], "$")
+	rounds := 0
+	if len(parts) == 3 && strings.HasPrefix(parts[0], "rounds=") {
+		n, err := strconv.Atoi(parts[0][7:])
+		if err != nil {
+			return 0, "", errors.New("invalid SHA-512 crypt rounds")
+		}
+		rounds = n
+		parts = parts[1:]
+	}
+	if len(parts) != 2 {
+		return 0, "", errors.New("invalid SHA-512 crypt hash")
+	}
+	return rounds, parts[0], nil
+}
+
+const cryptAlphabet = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// sha512CryptOrder is the byte order of the encoded digest
+var sha512CryptOrder = [...]int{
+	0, 21, 42, 22, 43, 1, 44, 2, 23, 3, 24, 45, 25, 46, 4, 47, 5, 26, 6, 27, 48,
+	28, 49, 7, 50, 8, 29, 9, 30, 51, 31, 52, 10, 53, 11, 32, 12, 33, 54, 34, 55, 13,
+	56, 14, 35, 15, 36, 57, 37, 58, 16, 59, 17, 38, 18, 39, 60, 40, 61, 19, 62, 20, 41,
+	63,
+}
+
+// sha512Crypt computes the SHA-512 crypt hash of password. The hash has a
+// rounds field unless rounds is 0, which uses the default rounds.
+func sha512Crypt(password, salt string, rounds int) string {
+	var out strings.Builder
+	out.WriteString("$6$")
+	if rounds != 0 {
+		out.WriteString("rounds=" + strconv.Itoa(clampRounds(rounds)) + "$")
+	}
+	if len(salt) > sha512CryptMaxSalt {
+		salt = salt[:sha512CryptMaxSalt]
+	}
+	out.WriteString(salt + "$")
+	out.WriteString(sha512CryptDigest(password, salt, rounds))
+	return out.String()
+}
+
+// clampRounds limits rounds to the range allowed by SHA-512 crypt
+func clampRounds(rounds int) int {
+	if rounds < sha512CryptMinRounds {
+		return sha512CryptMinRounds
+	}
+	if rounds > sha512CryptMaxRounds {
+		return sha512CryptMaxRounds
+	}
+	return rounds
+}
+
+// sha512CryptDigest returns the encoded digest of a SHA-512 crypt hash,
+// rounds of 0 uses the default rounds
+func sha512CryptDigest(password, salt string, rounds int) string {
+	if rounds == 0 {
+		rounds = sha512CryptRounds
+	}
+	rounds = clampRounds(rounds)
+	if len(salt) > sha512CryptMaxSalt {
+		salt = salt[:sha512CryptMaxSalt]
+	}
+	p, s := []byte(password), []byte(salt)
+
+	alt := sha512.New()
+	alt.Write(p)
+	alt.Write(s)
+	alt.Write(p)
+	b := alt.Sum(nil)
+
+	h := sha512.New()
+	h.Write(p)
+	h.Write(s)
+	i := len(p)
+	for ; i > sha512.Size; i -= sha512.Size {
+		h.Write(b)
+	}
+	h.Write(b[:i])
+	for i = len(p); i > 0; i >>= 1 {
+		if i&1 != 0 {
+			h.Write(b)
+		} else {
+			h.Write(p)
+		}
+	}
+	a := h.Sum(nil)
+
+	h.Reset()
+	for i = 0; i < len(p); i++ {
+		h.Write(p)
+	}
+	dp := repeat(h.Sum(nil), len(p))
+
+	h.Reset()
+	for i = 0; i < 16+int(a[0]); i++ {
+		h.Write(s)
+	}
+	ds := repeat(h.Sum(nil), len(s))
+
+	c := a
+	for i = 0; i < rounds; i++ {
+		h.Reset()
+		if i&1 != 0 {
+			h.Write(dp)
+		} else {
+			h.Write(c)
+		}
+		if i%3 != 0 {
+			h.Write(ds)
+		}
+		if i%7 != 0 {
+			h.Write(dp)
+		}
+		if i&1 != 0 {
+			h.Write(c)
+		} else {
+			h.Write(dp)
+		}
+		c = h.Sum(c[:0])
+	}
+
+	return cryptEncode(c, sha512CryptOrder[:])
+}
+
+// apr1MaxSalt is the maximum salt length of an apr1 hash
+const apr1MaxSalt = 8
+
+// apr1Order is the byte order of the encoded apr1 digest
+var apr1Order = [...]int{0, 6, 12, 1, 7, 13, 2, 8, 14, 3, 9, 15, 4, 10, 5, 11}
+
+// apr1Crypt computes the Apache MD5 hash of password, as created by htpasswd -m,
+// see https://httpd.apache.org/docs/2.4/misc/password_encryptions.html
+func apr1Crypt(password, salt string) string {
+	if len(salt) > apr1MaxSalt {
+		salt = salt[:apr1MaxSalt]
+	}
+	p, s := []byte(password), []byte(salt)
+
+	alt := md5.New()
+	alt.Write(p)
+	alt.Write(s)
+	alt.Write(p)
+	b := alt.Sum(nil)
+
+	h := md5.New()
+	h.Write(p)
+	h.Write([]byte("$apr1$"))
+	h.Write(s)
+	i := len(p)
+	for ; i > md5.Size; i -= md5.Size {
+		h.Write(b)
+	}
+	h.Write(b[:i])
+	for i = len(p); i > 0; i >>= 1 {
+		if i&1 != 0 {
+			h.Write([]byte{0})
+		} else {
+			h.Write(p[:1])
+		}
+	}
+	c := h.Sum(nil)
+
+	for i = 0; i < 1000; i++ {
+		h.Reset()
+		if i&1 != 0 {
+			h.Write(p)
+		} else {
+			h.Write(c)
+		}
+		if i%3 != 0 {
+			h.Write(s)
+		}
+		if i%7 != 0 {
+			h.Write(p)
+		}
+		if i&1 != 0 {
+			h.Write(c)
+		} else {
+			h.Write(p)
+		}
+		c = h.Sum(c[:0])
+	}
+	return "$apr1$" + salt + "$" + cryptEncode(c, apr1Order[:])
+}
+
+// cryptEncode encodes a digest in the crypt base64 alphabet, taking the bytes
+// in order three at a time and the last one on its own
+func cryptEncode(c []byte, order []int) string {
+	var out strings.Builder
+	last := len(order) - 1
+	for i := 0; i < last; i += 3 {
+		w := uint(c[order[i]])<<16 | uint(c[order[i+1]])<<8 | uint(c[order[i+2]])
+		for n := 0; n < 4; n++ {
+			out.WriteByte(cryptAlphabet[w&0x3f])
+			w >>= 6
+		}
+	}
+	w := uint(c[order[last]])
+	for n := 0; n < 2; n++ {
+		out.WriteByte(cryptAlphabet[w&0x3f])
+		w >>= 6
+	}
+	return out.String()
+}
+
+// repeat fills n bytes with copies of b
+func repeat(b []byte, n int) []byte {
+	out := make([]byte, n)
+	for i := 0; i < n; i += len(b) {
+		copy(out[i:], b)
+	}
+	return out
+}
+
+// readHtpasswd reads "username:hash" lines, empty lines and
+// lines starting with # are ignored
+func readHtpasswd(r io.Reader) (map[string]string, error) {
+	users := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || entry[0] == '#' {
+			continue
+		}
+		index := strings.IndexByte(entry, ':')
+		if index <= 0 {
+			return nil, fmt.Errorf("line %d: expected username:hash", line)
+		}
+		users[entry[:index]] = entry[index+1:]
+	}
+	return users, scanner.Err()
+}
+
+// loadHtpasswd returns the verifiers of the users in an htpasswd file
+func loadHtpasswd(path string) (map[string]verifier, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	users, err := readHtpasswd(f)
+	if err != nil {
+		return nil, err
+	}
+	verifiers := make(map[string]verifier, len(users))
+	for user, hash := range users {
+		if verifiers[user], err = parseHash(hash); err != nil {
+			return nil, fmt.Errorf("user %q: %w", user, err)
+		}
+	}
+	return verifiers, nil
+}
@@ -0,0 +1,94 @@
+package basicauth
+
+import (
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// attempts is the failure record of a lockout key
+type attempts struct {
+	failures uint32
+	lockouts uint32
+	until    int64
+}
+
+// lockout locks out keys after repeated failures. Each consecutive lockout
+// doubles the duration up to the configured maximum.
+type lockout struct {
+	mu      sync.Mutex
+	storage fiber.Storage
+	cfg     *Config
+}
+
+// get returns the record of a key. The caller must hold the lock.
+func (l *lockout) get(key string) attempts {
+	var a attempts
+	if raw, _ := l.storage.Get(key); len(raw) == 16 {
+		a.failures = binary.BigEndian.Uint32(raw[0:])
+		a.lockouts = binary.BigEndian.Uint32(raw[4:])
+		a.until = int64(binary.BigEndian.Uint64(raw[8:]))
+	}
+	return a
+}
+
+// set stores the record of a key. The caller must hold the lock.
+func (l *lockout) set(key string, a attempts, exp time.Duration) {
+	raw := make([]byte, 16)
+	binary.BigEndian.PutUint32(raw[0:], a.failures)
+	binary.BigEndian.PutUint32(raw[4:], a.lockouts)
+	binary.BigEndian.PutUint64(raw[8:], uint64(a.until))
+	_ = l.storage.Set(key, raw, exp)
+}
+
+// locked returns the remaining lockout time of a key
+func (l *lockout) locked(key string, now time.Time) time.Duration {
+	l.mu.Lock()
+	a := l.get(key)
+	l.mu.Unlock()
+	if remaining := time.Duration(a.until - now.UnixNano()); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// fail records a failed attempt and returns the lockout duration if the key
+// got locked after maxAttempts failures
+func (l *lockout) fail(key string, maxAttempts int, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	a := l.get(key)
+	a.failures++
+	var duration time.Duration
+	if int(a.failures) >= maxAttempts {
+		duration = l.cfg.LockoutDuration
+		// Double per previous lockout, stopping at the maximum so the
+		// duration can't overflow
+		for i := uint32(0); i < a.lockouts && duration < l.cfg.MaxLockoutDuration; i++ {
+			if duration > l.cfg.MaxLockoutDuration/2 {
+				duration = l.cfg.MaxLockoutDuration
+				break
+			}
+			duration <<= 1
+		}
+		if duration > l.cfg.MaxLockoutDuration {
+			duration = l.cfg.MaxLockoutDuration
+		}
+		a.failures = 0
+		a.lockouts++
+		a.until = now.Add(duration).UnixNano()
+	}
+	// Forget the record after a quiet period of the maximum lockout duration
+	l.set(key, a, duration+l.cfg.MaxLockoutDuration)
+	return duration
+}
+
+// reset forgets the failures of a key after a successful attempt
+func (l *lockout) reset(key string) {
+	l.mu.Lock()
+	_ = l.storage.Delete(key)
+	l.mu.Unlock()
+}
@@ -0,0 +1,158 @@
+package circuitbreaker
+
+import (
+	"sync"
+	"time"
+)
+
+// State is the state of a circuit
+type State int
+
+// Circuit states
+const (
+	// StateClosed lets all requests through
+	StateClosed State = iota
+	// StateOpen fails fast by calling the fallback handler
+	StateOpen
+	// StateHalfOpen lets a limited number of probe requests through
+	StateHalfOpen
+)
+
+// String returns the name of the state
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// buckets is the number of buckets the rolling window is divided into
+const buckets = 10
+
+// bucket counts the requests of a slice of the rolling window
+type bucket struct {
+	epoch    int64
+	requests int
+	failures int
+}
+
+// transition describes a state change
+type transition struct {
+	from, to State
+}
+
+// circuit tracks the failure ratio of a single key
+type circuit struct {
+	mu        sync.Mutex
+	cfg       *Config
+	width     time.Duration
+	state     State
+	window    [buckets]bucket
+	openedAt  time.Time
+	probes    int
+	successes int
+	pending   int
+	lastUsed  time.Time
+}
+
+func newCircuit(cfg *Config) *circuit {
+	width := cfg.Window / buckets
+	if width <= 0 {
+		width = 1
+	}
+	return &circuit{cfg: cfg, width: width}
+}
+
+// allow reports whether a request may be passed to the next handler.
+// The returned transition is non-nil if the state changed.
+func (c *circuit) allow(now time.Time) (bool, *transition) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lastUsed = now
+
+	var t *transition
+	if c.state == StateOpen && now.Sub(c.openedAt) >= c.cfg.OpenTimeout {
+		t = c.set(StateHalfOpen)
+	}
+	switch c.state {
+	case StateOpen:
+		return false, t
+	case StateHalfOpen:
+		if c.probes >= c.cfg.HalfOpenRequests {
+			return false, t
+		}
+		c.probes++
+	}
+	c.pending++
+	return true, t
+}
+
+// record stores the outcome of a request that was allowed through.
+// The returned transition is non-nil if the state changed.
+func (c *circuit) record(now time.Time, failed bool) *transition {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lastUsed = now
+	c.pending--
+
+	switch c.state {
+	case StateHalfOpen:
+		if failed {
+			c.openedAt = now
+			return c.set(StateOpen)
+		}
+		c.successes++
+		if c.successes >= c.cfg.HalfOpenRequests {
+			c.window = [buckets]bucket{}
+			return c.set(StateClosed)
+		}
+	case StateClosed:
+		epoch := now.UnixNano() / int64(c.width)
+		b := &c.window[epoch%buckets]
+		if b.epoch != epoch {
+			*b = bucket{epoch: epoch}
+		}
+		b.requests++
+		if failed {
+			b.failures++
+		}
+
+		var requests, failures int
+		for i := range c.window {
+			if c.window[i].epoch > epoch-buckets {
+				requests += c.window[i].requests
+				failures += c.window[i].failures
+			}
+		}
+		if requests >= c.cfg.MinRequests && float64(failures)/float64(requests) >= c.cfg.FailureRatio {
+			c.openedAt = now
+			return c.set(StateOpen)
+		}
+	}
+	return nil
+}
+
+// idle reports whether the circuit has no requests in progress and
+// wasn't used for at least d.
+func (c *circuit) idle(now time.Time, d time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.pending == 0 && now.Sub(c.lastUsed) >= d
+}
+
+// set changes the state. The caller must hold the lock.
+func (c *circuit) set(state State) *transition {
+	t := &transition{from: c.state, to: state}
+	c.state = state
+	c.probes = 0
+	c.successes = 0
+	return t
+}
@@ -0,0 +1,86 @@
+package circuitbreaker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// New creates a new middleware handler
+func New(config ...Config) fiber.Handler {
+	// Set default config
+	cfg := configDefault(config...)
+
+	var (
+		mux       sync.Mutex
+		circuits  = make(map[string]*circuit)
+		lastSweep = time.Now()
+	)
+
+	// Circuits that weren't used for this long have an empty window and
+	// would have left the open state, so they are removed. This keeps the
+	// map small for keys generated from user input.
+	expiration := cfg.Window + cfg.OpenTimeout
+
+	// Get or create the circuit of a key
+	get := func(key string, now time.Time) *circuit {
+		mux.Lock()
+		defer mux.Unlock()
+		if now.Sub(lastSweep) >= expiration {
+			for k, cb := range circuits {
+				if cb.idle(now, expiration) {
+					delete(circuits, k)
+				}
+			}
+			lastSweep = now
+		}
+		cb, ok := circuits[key]
+		if !ok {
+			// The key may point into the request buffer
+			key = utils.CopyString(key)
+			cb = newCircuit(&cfg)
+			circuits[key] = cb
+		}
+		return cb
+	}
+
+	// Notify about state changes
+	notify := func(key string, t *transition) {
+		if t != nil && cfg.OnStateChange != nil {
+			cfg.OnStateChange(utils.CopyString(key), t.from, t.to)
+		}
+	}
+
+	// Return new handler
+	return func(c *fiber.Ctx) error {
+		// Don't execute middleware if Next returns true
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		key := cfg.KeyGenerator(c)
+		now := time.Now()
+		cb := get(key, now)
+
+		ok, t := cb.allow(now)
+		notify(key, t)
+		if !ok {
+			return cfg.Fallback(c)
+		}
+
+		// A panic counts as failure, otherwise a half-open circuit
+		// would never get the outcome of its probe
+		defer func() {
+			if r := recover(); r != nil {
+				notify(key, cb.record(time.Now(), true))
+				panic(r)
+			}
+		}()
+
+		err := c.Next()
+		notify(key, cb.record(time.Now(), cfg.IsFailure(c, err)))
+		return err
+	}
+}
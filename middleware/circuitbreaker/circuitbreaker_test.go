@@ -0,0 +1,249 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// go test -run Test_CircuitBreaker_Open
+func Test_CircuitBreaker_Open(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mux         sync.Mutex
+		transitions []string
+	)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		MinRequests: 4,
+		OpenTimeout: 100 * time.Millisecond,
+		KeyGenerator: func(c *fiber.Ctx) string {
+			return "downstream"
+		},
+		OnStateChange: func(key string, from, to State) {
+			mux.Lock()
+			transitions = append(transitions, key+":"+from.String()+"->"+to.String())
+			mux.Unlock()
+		},
+	}))
+
+	fail := true
+	app.Get("/", func(c *fiber.Ctx) error {
+		if fail {
+			return errors.New("downstream unavailable")
+		}
+		return c.SendString("ok")
+	})
+
+	request := func() int {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+		utils.AssertEqual(t, nil, err)
+		return resp.StatusCode
+	}
+
+	// Two successes and two failures reach the failure ratio
+	fail = false
+	utils.AssertEqual(t, fiber.StatusOK, request())
+	utils.AssertEqual(t, fiber.StatusOK, request())
+	fail = true
+	utils.AssertEqual(t, fiber.StatusInternalServerError, request())
+	utils.AssertEqual(t, fiber.StatusInternalServerError, request())
+
+	// Fail fast without calling the handler
+	fail = false
+	utils.AssertEqual(t, fiber.StatusServiceUnavailable, request())
+
+	// A successful probe closes the circuit
+	time.Sleep(150 * time.Millisecond)
+	utils.AssertEqual(t, fiber.StatusOK, request())
+	utils.AssertEqual(t, fiber.StatusOK, request())
+
+	mux.Lock()
+	defer mux.Unlock()
+	utils.AssertEqual(t, []string{
+		"downstream:closed->open",
+		"downstream:open->half-open",
+		"downstream:half-open->closed",
+	}, transitions)
+}
+
+// go test -run Test_CircuitBreaker_Default_Key
+func Test_CircuitBreaker_Default_Key(t *testing.T) {
+	t.Parallel()
+
+	app := fiber.New()
+	app.Use(New(Config{MinRequests: 1}))
+	app.Get("/users/:id", func(c *fiber.Ctx) error {
+		return errors.New("downstream unavailable")
+	})
+	app.Get("/orders/:id", func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+
+	request := func(path string) int {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, path, nil))
+		utils.AssertEqual(t, nil, err)
+		return resp.StatusCode
+	}
+
+	// Every route has its own circuit
+	utils.AssertEqual(t, fiber.StatusInternalServerError, request("/users/1"))
+	utils.AssertEqual(t, fiber.StatusServiceUnavailable, request("/users/2"))
+	utils.AssertEqual(t, fiber.StatusOK, request("/orders/1"))
+	utils.AssertEqual(t, fiber.StatusOK, request("/orders/2"))
+}
+
+// go test -run Test_CircuitBreaker_HalfOpen_Failure
+func Test_CircuitBreaker_HalfOpen_Failure(t *testing.T) {
+	t.Parallel()
+
+	app := fiber.New()
+	app.Get("/", New(Config{
+		MinRequests: 1,
+		OpenTimeout: 50 * time.Millisecond,
+		Fallback: func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusServiceUnavailable).SendString("fallback")
+		},
+	}), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusBadGateway)
+	})
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusBadGateway, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusServiceUnavailable, resp.StatusCode)
+
+	// The failed probe opens the circuit again
+	time.Sleep(100 * time.Millisecond)
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusBadGateway, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusServiceUnavailable, resp.StatusCode)
+}
+
+// go test -run Test_CircuitBreaker_Client_Errors
+func Test_CircuitBreaker_Client_Errors(t *testing.T) {
+	t.Parallel()
+
+	app := fiber.New()
+	app.Get("/", New(Config{MinRequests: 1}), func(c *fiber.Ctx) error {
+		return fiber.ErrNotFound
+	})
+
+	for i := 0; i < 3; i++ {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+		utils.AssertEqual(t, nil, err)
+		utils.AssertEqual(t, fiber.StatusNotFound, resp.StatusCode)
+	}
+}
+
+// go test -run Test_CircuitBreaker_Window
+func Test_CircuitBreaker_Window(t *testing.T) {
+	t.Parallel()
+
+	cfg := configDefault(Config{MinRequests: 2, Window: 10 * time.Second})
+	cb := newCircuit(&cfg)
+	now := time.Now()
+
+	utils.AssertEqual(t, true, cb.record(now, true) == nil)
+
+	// The first failure has left the window
+	later := now.Add(11 * time.Second)
+	utils.AssertEqual(t, true, cb.record(later, false) == nil)
+	utils.AssertEqual(t, true, cb.record(later, false) == nil)
+
+	tr := cb.record(later, true)
+	utils.AssertEqual(t, true, tr == nil)
+	tr = cb.record(later, true)
+	utils.AssertEqual(t, StateOpen, tr.to)
+
+	ok, _ := cb.allow(later)
+	utils.AssertEqual(t, false, ok)
+}
+
+// go test -run Test_CircuitBreaker_HalfOpen_Panic
+func Test_CircuitBreaker_HalfOpen_Panic(t *testing.T) {
+	t.Parallel()
+
+	app := fiber.New()
+	app.Use(recover.New())
+	app.Use(New(Config{MinRequests: 1, OpenTimeout: 50 * time.Millisecond}))
+
+	panics := true
+	app.Get("/", func(c *fiber.Ctx) error {
+		if panics {
+			panic("downstream unavailable")
+		}
+		return c.SendString("ok")
+	})
+
+	request := func() int {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+		utils.AssertEqual(t, nil, err)
+		return resp.StatusCode
+	}
+
+	// The panic opens the circuit
+	utils.AssertEqual(t, fiber.StatusInternalServerError, request())
+	utils.AssertEqual(t, fiber.StatusServiceUnavailable, request())
+
+	// The panicking probe opens the circuit again
+	time.Sleep(50 * time.Millisecond)
+	utils.AssertEqual(t, fiber.StatusInternalServerError, request())
+	utils.AssertEqual(t, fiber.StatusServiceUnavailable, request())
+
+	// The next probe isn't blocked by the panicked one
+	panics = false
+	time.Sleep(50 * time.Millisecond)
+	utils.AssertEqual(t, fiber.StatusOK, request())
+	utils.AssertEqual(t, fiber.StatusOK, request())
+}
+
+// go test -run Test_CircuitBreaker_Expiration
+func Test_CircuitBreaker_Expiration(t *testing.T) {
+	t.Parallel()
+
+	cfg := configDefault(Config{Window: 10 * time.Second, OpenTimeout: 5 * time.Second})
+	cb := newCircuit(&cfg)
+	now := time.Now()
+
+	ok, _ := cb.allow(now)
+	utils.AssertEqual(t, true, ok)
+
+	// A request in progress keeps the circuit
+	utils.AssertEqual(t, false, cb.idle(now.Add(time.Minute), 15*time.Second))
+
+	cb.record(now, false)
+	utils.AssertEqual(t, false, cb.idle(now.Add(14*time.Second), 15*time.Second))
+	utils.AssertEqual(t, true, cb.idle(now.Add(15*time.Second), 15*time.Second))
+}
+
+// go test -run Test_CircuitBreaker_Next
+func Test_CircuitBreaker_Next(t *testing.T) {
+	t.Parallel()
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Next: func(_ *fiber.Ctx) bool {
+			return true
+		},
+	}))
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,143 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Config defines the config for middleware.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	//
+	// Optional. Default: nil
+	Next func(c *fiber.Ctx) bool
+
+	// KeyGenerator allows you to generate custom keys, every key has its own circuit
+	//
+	// Optional. Default: the path of the route that handles the request,
+	// see c.Endpoint()
+	KeyGenerator func(*fiber.Ctx) string
+
+	// FailureRatio is the ratio of failed requests within Window
+	// at which the circuit opens.
+	//
+	// Optional. Default: 0.5
+	FailureRatio float64
+
+	// MinRequests is the minimum number of requests within Window
+	// before the failure ratio is evaluated.
+	//
+	// Optional. Default: 10
+	MinRequests int
+
+	// Window is the rolling time window the failure ratio is computed over
+	//
+	// Optional. Default: 1 * time.Minute
+	Window time.Duration
+
+	// OpenTimeout is how long an open circuit fails fast
+	// before it lets probe requests through in half-open state.
+	//
+	// Optional. Default: 30 * time.Second
+	OpenTimeout time.Duration
+
+	// HalfOpenRequests is the number of probe requests let through in
+	// half-open state. The circuit closes when all of them succeed and
+	// opens again as soon as one of them fails.
+	//
+	// Optional. Default: 1
+	HalfOpenRequests int
+
+	// IsFailure reports whether a request counts as failed
+	//
+	// Optional. Default: errors other than a *fiber.Error below 500,
+	// and responses with a status code of 500 or higher
+	IsFailure func(c *fiber.Ctx, err error) bool
+
+	// Fallback is called instead of the next handler when the circuit is open
+	//
+	// Optional. Default: func(c *fiber.Ctx) error {
+	//   return c.SendStatus(fiber.StatusServiceUnavailable)
+	// }
+	Fallback fiber.Handler
+
+	// OnStateChange is called every time a circuit changes its state.
+	// It is called synchronously, so it should not block.
+	//
+	// Optional. Default: nil
+	OnStateChange func(key string, from, to State)
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	Next: nil,
+	KeyGenerator: func(c *fiber.Ctx) string {
+		// Under app.Use the current route is the middleware route
+		if route := c.Endpoint(); route != nil {
+			return route.Path
+		}
+		return c.Route().Path
+	},
+	FailureRatio:     0.5,
+	MinRequests:      10,
+	Window:           1 * time.Minute,
+	OpenTimeout:      30 * time.Second,
+	HalfOpenRequests: 1,
+	IsFailure:        isFailure,
+	Fallback: func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusServiceUnavailable)
+	},
+}
+
+// Helper function to set default values
+func configDefault(config ...Config) Config {
+	// Return default config if nothing provided
+	if len(config) < 1 {
+		return ConfigDefault
+	}
+
+	// Override default config
+	cfg := config[0]
+
+	// Set default values
+	if cfg.KeyGenerator == nil {
+		cfg.KeyGenerator = ConfigDefault.KeyGenerator
+	}
+	if cfg.FailureRatio <= 0 || cfg.FailureRatio > 1 {
+		cfg.FailureRatio = ConfigDefault.FailureRatio
+	}
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = ConfigDefault.MinRequests
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = ConfigDefault.Window
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = ConfigDefault.OpenTimeout
+	}
+	if cfg.HalfOpenRequests <= 0 {
+		cfg.HalfOpenRequests = ConfigDefault.HalfOpenRequests
+	}
+	if cfg.IsFailure == nil {
+		cfg.IsFailure = ConfigDefault.IsFailure
+	}
+	if cfg.Fallback == nil {
+		cfg.Fallback = ConfigDefault.Fallback
+	}
+	return cfg
+}
+
+// isFailure treats server errors as failures, client errors are not
+// a sign of an unhealthy downstream.
+func isFailure(c *fiber.Ctx, err error) bool {
+	if err != nil {
+		var e *fiber.Error
+		if errors.As(err, &e) {
+			return e.Code >= fiber.StatusInternalServerError
+		}
+		return true
+	}
+	return c.Response().StatusCode() >= fiber.StatusInternalServerError
+}
@@ -1,13 +1,25 @@
 package cors
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"os"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
 )
 
+// MetaKey is the route metadata key of a per-route Config, which replaces
+// the config of the middleware for requests to the route. An invalid
+// per-route Config allows no origins and is reported on stderr.
+//
+//	app.Get("/public", handler).Meta(cors.MetaKey, cors.Config{AllowOrigins: "*"})
+const MetaKey = "cors"
+
 // Config defines the config for middleware.
 type Config struct {
 	// Next defines a function to skip this middleware when returned true.
@@ -15,11 +27,18 @@ type Config struct {
 	// Optional. Default: nil
 	Next func(c *fiber.Ctx) bool
 
-	// AllowOrigin defines a list of origins that may access the resource.
+	// AllowOrigin defines a comma separated list of origins that may access the resource.
+	// Subdomains can be matched with patterns like "https://*.example.com".
 	//
-	// Optional. Default value "*"
+	// Optional. Default value "*", or "" if AllowOriginsFunc is set
 	AllowOrigins string
 
+	// AllowOriginsFunc defines a function to allow origins dynamically.
+	// It is called for origins that are not allowed by AllowOrigins.
+	//
+	// Optional. Default: nil
+	AllowOriginsFunc func(origin string) bool
+
 	// AllowMethods defines a list methods allowed when accessing the resource.
 	// This is used in response to a preflight request.
 	//
@@ -36,6 +55,7 @@ type Config struct {
 	// can be exposed when the credentials flag is true. When used as part of
 	// a response to a preflight request, this indicates whether or not the
 	// actual request can be made using credentials.
+	// It can't be combined with the wildcard origin "*".
 	//
 	// Optional. Default value false.
 	AllowCredentials bool
@@ -51,6 +71,12 @@ type Config struct {
 	//
 	// Optional. Default value 0.
 	MaxAge int
+
+	// AllowPrivateNetwork allows requests from public websites to the private network
+	// by answering preflight requests with Access-Control-Request-Private-Network.
+	//
+	// Optional. Default value false.
+	AllowPrivateNetwork bool
 }
 
 // ConfigDefault is the default config
@@ -65,40 +91,131 @@ var ConfigDefault = Config{
 		fiber.MethodDelete,
 		fiber.MethodPatch,
 	}, ","),
-	AllowHeaders:     "",
-	AllowCredentials: false,
-	ExposeHeaders:    "",
-	MaxAge:           0,
+	AllowHeaders:        "",
+	AllowCredentials:    false,
+	ExposeHeaders:       "",
+	MaxAge:              0,
+	AllowPrivateNetwork: false,
 }
 
-// New creates a new middleware handler
-func New(config ...Config) fiber.Handler {
-	// Set default config
-	cfg := ConfigDefault
+// Helper function to set default values
+func configDefault(config ...Config) Config {
+	// Return default config if nothing provided
+	if len(config) < 1 {
+		return ConfigDefault
+	}
+
+	// Override default config
+	cfg := config[0]
+
+	// Set default values
+	if cfg.AllowMethods == "" {
+		cfg.AllowMethods = ConfigDefault.AllowMethods
+	}
+	if cfg.AllowOrigins == "" && cfg.AllowOriginsFunc == nil {
+		cfg.AllowOrigins = ConfigDefault.AllowOrigins
+	}
+	return cfg
+}
+
+// policy is a validated config
+type policy struct {
+	cfg           Config
+	wildcard      bool
+	origins       []string
+	allowMethods  string
+	allowHeaders  string
+	exposeHeaders string
+	maxAge        string
+}
 
-	// Override config if provided
-	if len(config) > 0 {
-		cfg = config[0]
+// errCredentialsWildcard is returned for a config allowing credentials
+// for the wildcard origin
+var errCredentialsWildcard = errors.New("[CORS] AllowCredentials can't be combined with the wildcard origin \"*\", " +
+	"list the allowed origins or use AllowOriginsFunc instead")
 
-		// Set default values
-		if cfg.AllowMethods == "" {
-			cfg.AllowMethods = ConfigDefault.AllowMethods
+func newPolicy(cfg Config) (*policy, error) {
+	p := &policy{
+		cfg: cfg,
+		// Strip white spaces
+		allowMethods:  strings.ReplaceAll(cfg.AllowMethods, " ", ""),
+		allowHeaders:  strings.ReplaceAll(cfg.AllowHeaders, " ", ""),
+		exposeHeaders: strings.ReplaceAll(cfg.ExposeHeaders, " ", ""),
+		// Convert int to string
+		maxAge: strconv.Itoa(cfg.MaxAge),
+	}
+
+	// Convert string to slice
+	for _, origin := range strings.Split(strings.ReplaceAll(cfg.AllowOrigins, " ", ""), ",") {
+		switch origin {
+		case "":
+		case "*":
+			p.wildcard = true
+		default:
+			p.origins = append(p.origins, utils.ToLower(strings.TrimSuffix(origin, "/")))
 		}
-		if cfg.AllowOrigins == "" {
-			cfg.AllowOrigins = ConfigDefault.AllowOrigins
+	}
+
+	if p.wildcard && cfg.AllowCredentials {
+		return nil, errCredentialsWildcard
+	}
+	return p, nil
+}
+
+// allowOrigin returns the value of the Access-Control-Allow-Origin header,
+// or "" if the origin is not allowed
+func (p *policy) allowOrigin(origin string) string {
+	if p.wildcard {
+		return "*"
+	}
+	if origin == "" {
+		return ""
+	}
+	lower := utils.ToLower(origin)
+	for _, o := range p.origins {
+		if o == lower || (strings.Contains(o, "*") && matchSubdomain(lower, o)) {
+			return origin
 		}
 	}
+	if p.cfg.AllowOriginsFunc != nil && p.cfg.AllowOriginsFunc(origin) {
+		return origin
+	}
+	return ""
+}
 
-	// Convert string to slice
-	allowOrigins := strings.Split(strings.ReplaceAll(cfg.AllowOrigins, " ", ""), ",")
+// New creates a new middleware handler
+func New(config ...Config) fiber.Handler {
+	// Set default config
+	cfg := configDefault(config...)
+	defaultPolicy, err := newPolicy(cfg)
+	if err != nil {
+		panic(err.Error())
+	}
 
-	// Strip white spaces
-	allowMethods := strings.ReplaceAll(cfg.AllowMethods, " ", "")
-	allowHeaders := strings.ReplaceAll(cfg.AllowHeaders, " ", "")
-	exposeHeaders := strings.ReplaceAll(cfg.ExposeHeaders, " ", "")
+	// Policies of routes with a Config in their metadata
+	var routePolicies sync.Map
 
-	// Convert int to string
-	maxAge := strconv.Itoa(cfg.MaxAge)
+	// Get the policy of the route handling the request
+	policyOf := func(route *fiber.Route) *policy {
+		if route == nil {
+			return defaultPolicy
+		}
+		if p, ok := routePolicies.Load(route); ok {
+			return p.(*policy)
+		}
+		routeCfg, ok := route.Metadata[MetaKey].(Config)
+		if !ok {
+			return defaultPolicy
+		}
+		p, err := newPolicy(configDefault(routeCfg))
+		if err != nil {
+			// Don't panic while handling a request, allow no origins instead
+			fmt.Fprintf(os.Stderr, "%v, route %s %s allows no origins\n", err, route.Method, route.Path)
+			p = &policy{}
+		}
+		stored, _ := routePolicies.LoadOrStore(route, p)
+		return stored.(*policy)
+	}
 
 	// Return new handler
 	return func(c *fiber.Ctx) error {
@@ -107,55 +224,57 @@ func New(config ...Config) fiber.Handler {
 			return c.Next()
 		}
 
-		// Get origin header
-		origin := c.Get(fiber.HeaderOrigin)
-		allowOrigin := ""
-
-		// Check allowed origins
-		for _, o := range allowOrigins {
-			if o == "*" && cfg.AllowCredentials {
-				allowOrigin = origin
-				break
-			}
-			if o == "*" || o == origin {
-				allowOrigin = o
-				break
-			}
-			if matchSubdomain(origin, o) {
-				allowOrigin = origin
-				break
-			}
-		}
+		// The response depends on the origin, even if it is not allowed
+		c.Vary(fiber.HeaderOrigin)
 
 		// Simple request
 		if c.Method() != http.MethodOptions {
-			c.Vary(fiber.HeaderOrigin)
+			p := policyOf(c.Endpoint())
+			allowOrigin := p.allowOrigin(c.Get(fiber.HeaderOrigin))
+			if allowOrigin == "" {
+				return c.Next()
+			}
 			c.Set(fiber.HeaderAccessControlAllowOrigin, allowOrigin)
 
-			if cfg.AllowCredentials {
+			if p.cfg.AllowCredentials {
 				c.Set(fiber.HeaderAccessControlAllowCredentials, "true")
 			}
-			if exposeHeaders != "" {
-				c.Set(fiber.HeaderAccessControlExposeHeaders, exposeHeaders)
+			if p.exposeHeaders != "" {
+				c.Set(fiber.HeaderAccessControlExposeHeaders, p.exposeHeaders)
 			}
 			return c.Next()
 		}
 
-		// Preflight request
-		c.Vary(fiber.HeaderOrigin)
+		// Preflight request for the route of the requested method
 		c.Vary(fiber.HeaderAccessControlRequestMethod)
 		c.Vary(fiber.HeaderAccessControlRequestHeaders)
+
+		var route *fiber.Route
+		if method := c.Get(fiber.HeaderAccessControlRequestMethod); method != "" {
+			route = c.Endpoint(method)
+		} else {
+			route = c.Endpoint()
+		}
+		p := policyOf(route)
+		if p.cfg.AllowPrivateNetwork {
+			c.Vary(fiber.HeaderAccessControlRequestPrivateNetwork)
+		}
+
+		allowOrigin := p.allowOrigin(c.Get(fiber.HeaderOrigin))
+		if allowOrigin == "" {
+			return c.SendStatus(fiber.StatusNoContent)
+		}
 		c.Set(fiber.HeaderAccessControlAllowOrigin, allowOrigin)
-		c.Set(fiber.HeaderAccessControlAllowMethods, allowMethods)
+		c.Set(fiber.HeaderAccessControlAllowMethods, p.allowMethods)
 
 		// Set Allow-Credentials if set to true
-		if cfg.AllowCredentials {
+		if p.cfg.AllowCredentials {
 			c.Set(fiber.HeaderAccessControlAllowCredentials, "true")
 		}
 
 		// Set Allow-Headers if not empty
-		if allowHeaders != "" {
-			c.Set(fiber.HeaderAccessControlAllowHeaders, allowHeaders)
+		if p.allowHeaders != "" {
+			c.Set(fiber.HeaderAccessControlAllowHeaders, p.allowHeaders)
 		} else {
 			h := c.Get(fiber.HeaderAccessControlRequestHeaders)
 			if h != "" {
@@ -163,9 +282,14 @@ func New(config ...Config) fiber.Handler {
 			}
 		}
 
+		// Set Allow-Private-Network if requested and allowed
+		if p.cfg.AllowPrivateNetwork && c.Get(fiber.HeaderAccessControlRequestPrivateNetwork) == "true" {
+			c.Set(fiber.HeaderAccessControlAllowPrivateNetwork, "true")
+		}
+
 		// Set MaxAge is set
-		if cfg.MaxAge > 0 {
-			c.Set(fiber.HeaderAccessControlMaxAge, maxAge)
+		if p.cfg.MaxAge > 0 {
+			c.Set(fiber.HeaderAccessControlMaxAge, p.maxAge)
 		}
 
 		// Send 204 No Content
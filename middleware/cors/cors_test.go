@@ -2,6 +2,7 @@ package cors
 
 import (
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/gofiber/fiber/v2"
@@ -53,11 +54,10 @@ func Test_CORS_Wildcard(t *testing.T) {
 	app := fiber.New()
 	// OPTIONS (preflight) response headers when AllowOrigins is *
 	app.Use(New(Config{
-		AllowOrigins:     "*",
-		AllowCredentials: true,
-		MaxAge:           3600,
-		ExposeHeaders:    "X-Request-ID",
-		AllowHeaders:     "Authentication",
+		AllowOrigins:  "*",
+		MaxAge:        3600,
+		ExposeHeaders: "X-Request-ID",
+		AllowHeaders:  "Authentication",
 	}))
 	// Get handler pointer
 	handler := app.Handler()
@@ -72,8 +72,8 @@ func Test_CORS_Wildcard(t *testing.T) {
 	handler(ctx)
 
 	// Check result
-	utils.AssertEqual(t, "localhost", string(ctx.Response.Header.Peek(fiber.HeaderAccessControlAllowOrigin)))
-	utils.AssertEqual(t, "true", string(ctx.Response.Header.Peek(fiber.HeaderAccessControlAllowCredentials)))
+	utils.AssertEqual(t, "*", string(ctx.Response.Header.Peek(fiber.HeaderAccessControlAllowOrigin)))
+	utils.AssertEqual(t, "", string(ctx.Response.Header.Peek(fiber.HeaderAccessControlAllowCredentials)))
 	utils.AssertEqual(t, "3600", string(ctx.Response.Header.Peek(fiber.HeaderAccessControlMaxAge)))
 	utils.AssertEqual(t, "Authentication", string(ctx.Response.Header.Peek(fiber.HeaderAccessControlAllowHeaders)))
 
@@ -82,10 +82,157 @@ func Test_CORS_Wildcard(t *testing.T) {
 	ctx.Request.Header.SetMethod(fiber.MethodGet)
 	handler(ctx)
 
-	utils.AssertEqual(t, "true", string(ctx.Response.Header.Peek(fiber.HeaderAccessControlAllowCredentials)))
+	utils.AssertEqual(t, "*", string(ctx.Response.Header.Peek(fiber.HeaderAccessControlAllowOrigin)))
 	utils.AssertEqual(t, "X-Request-ID", string(ctx.Response.Header.Peek(fiber.HeaderAccessControlExposeHeaders)))
 }
 
+// go test -run Test_CORS_Wildcard_AllowCredentials
+func Test_CORS_Wildcard_AllowCredentials(t *testing.T) {
+	defer func() {
+		utils.AssertEqual(t, true, recover() != nil)
+	}()
+	New(Config{AllowOrigins: "https://example.com, *", AllowCredentials: true})
+}
+
+// go test -run Test_CORS_AllowCredentials
+func Test_CORS_AllowCredentials(t *testing.T) {
+	app := fiber.New()
+	app.Use(New(Config{AllowOrigins: "https://example.com", AllowCredentials: true}))
+	handler := app.Handler()
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(fiber.MethodGet)
+	ctx.Request.Header.Set(fiber.HeaderOrigin, "https://example.com")
+	handler(ctx)
+
+	utils.AssertEqual(t, "https://example.com", string(ctx.Response.Header.Peek(fiber.HeaderAccessControlAllowOrigin)))
+	utils.AssertEqual(t, "true", string(ctx.Response.Header.Peek(fiber.HeaderAccessControlAllowCredentials)))
+	utils.AssertEqual(t, "Origin", string(ctx.Response.Header.Peek(fiber.HeaderVary)))
+
+	// No CORS headers for other origins, but the response still varies by origin
+	ctx = &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(fiber.MethodGet)
+	ctx.Request.Header.Set(fiber.HeaderOrigin, "https://evil.com")
+	handler(ctx)
+
+	utils.AssertEqual(t, "", string(ctx.Response.Header.Peek(fiber.HeaderAccessControlAllowOrigin)))
+	utils.AssertEqual(t, "", string(ctx.Response.Header.Peek(fiber.HeaderAccessControlAllowCredentials)))
+	utils.AssertEqual(t, "Origin", string(ctx.Response.Header.Peek(fiber.HeaderVary)))
+}
+
+// go test -run Test_CORS_AllowOriginsFunc
+func Test_CORS_AllowOriginsFunc(t *testing.T) {
+	app := fiber.New()
+	app.Use(New(Config{
+		AllowOrigins: "https://example.com",
+		AllowOriginsFunc: func(origin string) bool {
+			return strings.HasSuffix(origin, ".gofiber.io")
+		},
+		AllowCredentials: true,
+	}))
+	handler := app.Handler()
+
+	for origin, allowed := range map[string]string{
+		"https://example.com":     "https://example.com",
+		"https://docs.gofiber.io": "https://docs.gofiber.io",
+		"https://gofiber.io.evil": "",
+		"https://www.example.com": "",
+	} {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.Header.SetMethod(fiber.MethodOptions)
+		ctx.Request.Header.Set(fiber.HeaderOrigin, origin)
+		handler(ctx)
+		utils.AssertEqual(t, allowed, string(ctx.Response.Header.Peek(fiber.HeaderAccessControlAllowOrigin)), origin)
+	}
+}
+
+// go test -run Test_CORS_PrivateNetwork
+func Test_CORS_PrivateNetwork(t *testing.T) {
+	app := fiber.New()
+	app.Use(New(Config{AllowPrivateNetwork: true}))
+	handler := app.Handler()
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(fiber.MethodOptions)
+	ctx.Request.Header.Set(fiber.HeaderOrigin, "https://example.com")
+	ctx.Request.Header.Set(fiber.HeaderAccessControlRequestMethod, fiber.MethodGet)
+	ctx.Request.Header.Set(fiber.HeaderAccessControlRequestPrivateNetwork, "true")
+	handler(ctx)
+
+	utils.AssertEqual(t, "true", string(ctx.Response.Header.Peek(fiber.HeaderAccessControlAllowPrivateNetwork)))
+	utils.AssertEqual(t, true, strings.Contains(string(ctx.Response.Header.Peek(fiber.HeaderVary)), fiber.HeaderAccessControlRequestPrivateNetwork))
+
+	// Not allowed by default
+	app = fiber.New()
+	app.Use(New())
+	handler = app.Handler()
+	ctx.Response.Reset()
+	handler(ctx)
+	utils.AssertEqual(t, "", string(ctx.Response.Header.Peek(fiber.HeaderAccessControlAllowPrivateNetwork)))
+}
+
+// go test -run Test_CORS_Route_Override
+func Test_CORS_Route_Override(t *testing.T) {
+	app := fiber.New()
+	app.Use(New(Config{AllowOrigins: "https://example.com", AllowCredentials: true}))
+	app.Get("/private", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	app.Put("/public", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	}).Meta(MetaKey, Config{AllowOrigins: "*", AllowMethods: "PUT"})
+	handler := app.Handler()
+
+	request := func(method, path, preflightMethod string) *fasthttp.Response {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.Header.SetMethod(method)
+		ctx.Request.SetRequestURI(path)
+		ctx.Request.Header.Set(fiber.HeaderOrigin, "https://other.com")
+		if preflightMethod != "" {
+			ctx.Request.Header.Set(fiber.HeaderAccessControlRequestMethod, preflightMethod)
+		}
+		handler(ctx)
+		return &ctx.Response
+	}
+
+	resp := request(fiber.MethodGet, "/private", "")
+	utils.AssertEqual(t, "", string(resp.Header.Peek(fiber.HeaderAccessControlAllowOrigin)))
+
+	resp = request(fiber.MethodPut, "/public", "")
+	utils.AssertEqual(t, "*", string(resp.Header.Peek(fiber.HeaderAccessControlAllowOrigin)))
+	utils.AssertEqual(t, "", string(resp.Header.Peek(fiber.HeaderAccessControlAllowCredentials)))
+
+	// Preflight requests use the config of the route of the requested method
+	resp = request(fiber.MethodOptions, "/public", fiber.MethodPut)
+	utils.AssertEqual(t, fiber.StatusNoContent, resp.StatusCode())
+	utils.AssertEqual(t, "*", string(resp.Header.Peek(fiber.HeaderAccessControlAllowOrigin)))
+	utils.AssertEqual(t, "PUT", string(resp.Header.Peek(fiber.HeaderAccessControlAllowMethods)))
+
+	resp = request(fiber.MethodOptions, "/private", fiber.MethodGet)
+	utils.AssertEqual(t, "", string(resp.Header.Peek(fiber.HeaderAccessControlAllowOrigin)))
+}
+
+// go test -run Test_CORS_Route_Override_Invalid
+func Test_CORS_Route_Override_Invalid(t *testing.T) {
+	app := fiber.New()
+	app.Use(New(Config{AllowOrigins: "https://example.com"}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	}).Meta(MetaKey, Config{AllowCredentials: true})
+	handler := app.Handler()
+
+	// The invalid config doesn't panic and allows no origins
+	for _, method := range []string{fiber.MethodGet, fiber.MethodOptions} {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.Header.SetMethod(method)
+		ctx.Request.SetRequestURI("/")
+		ctx.Request.Header.Set(fiber.HeaderOrigin, "https://example.com")
+		ctx.Request.Header.Set(fiber.HeaderAccessControlRequestMethod, fiber.MethodGet)
+		handler(ctx)
+		utils.AssertEqual(t, "", string(ctx.Response.Header.Peek(fiber.HeaderAccessControlAllowOrigin)))
+	}
+}
+
 // go test -run -v Test_CORS_Subdomain
 func Test_CORS_Subdomain(t *testing.T) {
 	// New fiber instance
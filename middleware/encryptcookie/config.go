@@ -10,25 +10,60 @@ type Config struct {
 	Next func(c *fiber.Ctx) bool
 
 	// Array of cookie keys that should not be encrypted.
+	// Ignored when Only is set.
 	//
-	// Optional. Default: []
+	// Optional. Default: ["csrf_"]
 	Except []string
 
+	// Array of cookie keys that should be encrypted. When set, only these
+	// cookies are encrypted and all other cookies are left untouched.
+	//
+	// Optional. Default: nil
+	Only []string
+
+	// Array of cookie keys that should be signed instead of encrypted.
+	// Signed cookies remain readable by the client, but are rejected by the
+	// middleware when their value has been tampered with.
+	//
+	// Optional. Default: nil
+	SignOnly []string
+
 	// Base64 encoded unique key to encode & decode cookies.
 	//
-	// Required. Key length should be 32 characters.
+	// Required if Keys is not set. Key length should be 32 bytes.
 	// You may use `encryptcookie.GenerateKey()` to generate a new key.
 	Key string
 
+	// Keys is a key ring of base64 encoded keys used for key rotation.
+	// The first key is used to encrypt and sign cookies, all keys are used
+	// to decrypt and verify them. To rotate, prepend a new key and remove
+	// the old key once all cookies encrypted with it have expired.
+	// If Key is also set, it is used as the first key of the ring.
+	//
+	// Required if Key is not set.
+	Keys []string
+
 	// Custom function to encrypt cookies.
 	//
-	// Optional. Default: EncryptCookie
+	// Optional. Default: AES-GCM with the cookie name as associated data
 	Encryptor func(decryptedString, key string) (string, error)
 
 	// Custom function to decrypt cookies.
 	//
-	// Optional. Default: DecryptCookie
+	// Optional. Default: AES-GCM with the cookie name as associated data,
+	// see AllowLegacy
 	Decryptor func(encryptedString, key string) (string, error)
+
+	// AllowLegacy makes the default decryptor accept cookies encrypted
+	// without associated data, as by EncryptCookie and previous versions of
+	// the middleware. These values are not bound to the cookie name, so only
+	// enable it to migrate existing cookies, and disable it once they have
+	// expired. Legacy cookies are not re-issued, they are stored in the
+	// current format when the app sets them again. It is always enabled with
+	// a custom Encryptor, as it doesn't bind values to the cookie name.
+	//
+	// Optional. Default: false
+	AllowLegacy bool
 }
 
 // ConfigDefault is the default config
@@ -36,8 +71,8 @@ var ConfigDefault = Config{
 	Next:      nil,
 	Except:    []string{"csrf_"},
 	Key:       "",
-	Encryptor: EncryptCookie,
-	Decryptor: DecryptCookie,
+	Encryptor: nil,
+	Decryptor: nil,
 }
 
 // Helper function to set default values
@@ -58,17 +93,13 @@ func configDefault(config ...Config) Config {
 		if cfg.Except == nil {
 			cfg.Except = ConfigDefault.Except
 		}
+	}
 
-		if cfg.Encryptor == nil {
-			cfg.Encryptor = ConfigDefault.Encryptor
-		}
-
-		if cfg.Decryptor == nil {
-			cfg.Decryptor = ConfigDefault.Decryptor
-		}
+	if cfg.Key != "" {
+		cfg.Keys = append([]string{cfg.Key}, cfg.Keys...)
 	}
 
-	if cfg.Key == "" {
+	if len(cfg.Keys) == 0 {
 		panic("fiber: encrypt cookie middleware requires key")
 	}
 
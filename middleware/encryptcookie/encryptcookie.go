@@ -10,6 +10,35 @@ func New(config ...Config) fiber.Handler {
 	// Set default config
 	cfg := configDefault(config...)
 
+	// Parse key ring
+	ring, err := newKeyRing(cfg.Keys, cfg.Encryptor == nil || cfg.Decryptor == nil)
+	if err != nil {
+		panic("fiber: encrypt cookie middleware requires base64 encoded 16, 24 or 32 byte keys: " + err.Error())
+	}
+
+	encrypt := func(name, value string) (string, error) {
+		if cfg.Encryptor != nil {
+			return cfg.Encryptor(value, ring.keys[0])
+		}
+		return ring.encrypt(name, value)
+	}
+
+	decrypt := func(name, value string) (string, error) {
+		if cfg.Decryptor == nil {
+			return ring.decrypt(name, value, cfg.AllowLegacy || cfg.Encryptor != nil)
+		}
+		var (
+			decrypted string
+			err       error
+		)
+		for _, key := range ring.keys {
+			if decrypted, err = cfg.Decryptor(value, key); err == nil {
+				break
+			}
+		}
+		return decrypted, err
+	}
+
 	// Return new handler
 	return func(c *fiber.Ctx) error {
 		// Don't execute middleware if Next returns true
@@ -20,13 +49,22 @@ func New(config ...Config) fiber.Handler {
 		// Decrypt request cookies
 		c.Request().Header.VisitAllCookie(func(key, value []byte) {
 			keyString := string(key)
-			if !isDisabled(keyString, cfg.Except) {
-				decryptedValue, err := cfg.Decryptor(string(value), cfg.Key)
-				if err != nil {
-					c.Request().Header.SetCookieBytesKV(key, nil)
-				} else {
-					c.Request().Header.SetCookie(string(key), decryptedValue)
-				}
+			var (
+				decryptedValue string
+				err            error
+			)
+			switch {
+			case contains(keyString, cfg.SignOnly):
+				decryptedValue, err = ring.verify(keyString, string(value))
+			case isEncrypted(keyString, cfg):
+				decryptedValue, err = decrypt(keyString, string(value))
+			default:
+				return
+			}
+			if err != nil {
+				c.Request().Header.SetCookieBytesKV(key, nil)
+			} else {
+				c.Request().Header.SetCookie(keyString, decryptedValue)
 			}
 		})
 
@@ -36,17 +74,24 @@ func New(config ...Config) fiber.Handler {
 		// Encrypt response cookies
 		c.Response().Header.VisitAllCookie(func(key, value []byte) {
 			keyString := string(key)
-			if !isDisabled(keyString, cfg.Except) {
-				cookieValue := fasthttp.Cookie{}
-				cookieValue.SetKeyBytes(key)
-				if c.Response().Header.Cookie(&cookieValue) {
-					encryptedValue, err := cfg.Encryptor(string(cookieValue.Value()), cfg.Key)
-					if err == nil {
-						cookieValue.SetValue(encryptedValue)
-						c.Response().Header.SetCookie(&cookieValue)
-					} else {
-						panic(err)
-					}
+			signed := contains(keyString, cfg.SignOnly)
+			if !signed && !isEncrypted(keyString, cfg) {
+				return
+			}
+			cookieValue := fasthttp.Cookie{}
+			cookieValue.SetKeyBytes(key)
+			if c.Response().Header.Cookie(&cookieValue) {
+				if signed {
+					cookieValue.SetValue(ring.sign(keyString, string(cookieValue.Value())))
+					c.Response().Header.SetCookie(&cookieValue)
+					return
+				}
+				encryptedValue, err := encrypt(keyString, string(cookieValue.Value()))
+				if err == nil {
+					cookieValue.SetValue(encryptedValue)
+					c.Response().Header.SetCookie(&cookieValue)
+				} else {
+					panic(err)
 				}
 			}
 		})
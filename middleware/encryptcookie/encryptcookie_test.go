@@ -2,7 +2,10 @@ package encryptcookie
 
 import (
 	"encoding/base64"
+	"io"
+	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/gofiber/fiber/v2"
@@ -12,6 +15,18 @@ import (
 
 var testKey = GenerateKey()
 
+func decryptTestCookie(t *testing.T, name, value string, keys ...string) string {
+	t.Helper()
+	if len(keys) == 0 {
+		keys = []string{testKey}
+	}
+	ring, err := newKeyRing(keys, true)
+	utils.AssertEqual(t, nil, err)
+	decrypted, err := ring.decrypt(name, value, false)
+	utils.AssertEqual(t, nil, err)
+	return decrypted
+}
+
 func Test_Middleware_Encrypt_Cookie(t *testing.T) {
 	app := fiber.New()
 
@@ -60,8 +75,7 @@ func Test_Middleware_Encrypt_Cookie(t *testing.T) {
 	encryptedCookie := fasthttp.Cookie{}
 	encryptedCookie.SetKey("test")
 	utils.AssertEqual(t, true, ctx.Response.Header.Cookie(&encryptedCookie), "Get cookie value")
-	decryptedCookieValue, _ := DecryptCookie(string(encryptedCookie.Value()), testKey)
-	utils.AssertEqual(t, "SomeThing", decryptedCookieValue)
+	utils.AssertEqual(t, "SomeThing", decryptTestCookie(t, "test", string(encryptedCookie.Value())))
 
 	ctx = &fasthttp.RequestCtx{}
 	ctx.Request.Header.SetMethod("GET")
@@ -132,8 +146,7 @@ func Test_Encrypt_Cookie_Except(t *testing.T) {
 	encryptedCookie := fasthttp.Cookie{}
 	encryptedCookie.SetKey("test2")
 	utils.AssertEqual(t, true, ctx.Response.Header.Cookie(&encryptedCookie), "Get cookie value")
-	decryptedCookieValue, _ := DecryptCookie(string(encryptedCookie.Value()), testKey)
-	utils.AssertEqual(t, "SomeThing", decryptedCookieValue)
+	utils.AssertEqual(t, "SomeThing", decryptTestCookie(t, "test2", string(encryptedCookie.Value())))
 }
 
 func Test_Encrypt_Cookie_Custom_Encryptor(t *testing.T) {
@@ -182,3 +195,181 @@ func Test_Encrypt_Cookie_Custom_Encryptor(t *testing.T) {
 	utils.AssertEqual(t, 200, ctx.Response.StatusCode())
 	utils.AssertEqual(t, "value=SomeThing", string(ctx.Response.Body()))
 }
+
+func cookieApp(config Config) *fiber.App {
+	app := fiber.New()
+
+	app.Use(New(config))
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString(c.Cookies("test1") + "," + c.Cookies("test2"))
+	})
+	app.Post("/", func(c *fiber.Ctx) error {
+		c.Cookie(&fiber.Cookie{Name: "test1", Value: "One"})
+		c.Cookie(&fiber.Cookie{Name: "test2", Value: "Two"})
+		return nil
+	})
+
+	return app
+}
+
+func responseCookies(t *testing.T, app *fiber.App) map[string]string {
+	t.Helper()
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodPost, "/", nil))
+	utils.AssertEqual(t, nil, err)
+	cookies := map[string]string{}
+	for _, cookie := range resp.Cookies() {
+		cookies[cookie.Name] = cookie.Value
+	}
+	return cookies
+}
+
+func requestCookies(t *testing.T, app *fiber.App, test1, test2 string) string {
+	t.Helper()
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+	req.Header.Set(fiber.HeaderCookie, "test1="+test1+"; test2="+test2)
+	resp, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	return string(body)
+}
+
+// go test -run Test_Encrypt_Cookie_Key_Rotation
+func Test_Encrypt_Cookie_Key_Rotation(t *testing.T) {
+	t.Parallel()
+
+	oldKey, newKey := GenerateKey(), GenerateKey()
+
+	cookies := responseCookies(t, cookieApp(Config{Key: oldKey}))
+
+	app := cookieApp(Config{Keys: []string{newKey, oldKey}})
+
+	// Cookies encrypted with the old key can still be decrypted
+	utils.AssertEqual(t, "One,Two", requestCookies(t, app, cookies["test1"], cookies["test2"]))
+
+	// New cookies are encrypted with the newest key
+	rotated := responseCookies(t, app)
+	utils.AssertEqual(t, "One", decryptTestCookie(t, "test1", rotated["test1"], newKey))
+
+	// Cookies encrypted with a removed key are rejected
+	app = cookieApp(Config{Keys: []string{GenerateKey(), newKey}})
+	utils.AssertEqual(t, ",", requestCookies(t, app, cookies["test1"], cookies["test2"]))
+	utils.AssertEqual(t, "One,Two", requestCookies(t, app, rotated["test1"], rotated["test2"]))
+}
+
+// go test -run Test_Encrypt_Cookie_Associated_Data
+func Test_Encrypt_Cookie_Associated_Data(t *testing.T) {
+	t.Parallel()
+
+	app := cookieApp(Config{Key: testKey})
+	cookies := responseCookies(t, app)
+
+	utils.AssertEqual(t, "One,Two", requestCookies(t, app, cookies["test1"], cookies["test2"]))
+
+	// Values are bound to the name of the cookie
+	utils.AssertEqual(t, ",", requestCookies(t, app, cookies["test2"], cookies["test1"]))
+}
+
+// go test -run Test_Encrypt_Cookie_Legacy
+func Test_Encrypt_Cookie_Legacy(t *testing.T) {
+	t.Parallel()
+
+	legacyApp := func(allow bool) *fiber.App {
+		app := fiber.New()
+		app.Use(New(Config{Key: testKey, AllowLegacy: allow}))
+		app.Get("/", func(c *fiber.Ctx) error {
+			return c.SendString(c.Cookies("test1") + "," + c.Cookies("test2"))
+		})
+		return app
+	}
+
+	legacy, err := EncryptCookie("One", testKey)
+	utils.AssertEqual(t, nil, err)
+	current := responseCookies(t, cookieApp(Config{Key: testKey}))
+
+	request := func(app *fiber.App) (string, []*http.Cookie) {
+		req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+		req.Header.Set(fiber.HeaderCookie, "test1="+legacy+"; test2="+current["test2"])
+		resp, err := app.Test(req)
+		utils.AssertEqual(t, nil, err)
+		body, err := io.ReadAll(resp.Body)
+		utils.AssertEqual(t, nil, err)
+		return string(body), resp.Cookies()
+	}
+
+	// Cookies encrypted without associated data are rejected by default
+	body, _ := request(legacyApp(false))
+	utils.AssertEqual(t, ",Two", body)
+
+	// and accepted for migration, without being re-issued
+	body, cookies := request(legacyApp(true))
+	utils.AssertEqual(t, "One,Two", body)
+	utils.AssertEqual(t, 0, len(cookies))
+}
+
+// go test -run Test_Encrypt_Cookie_Default_Decryptor
+func Test_Encrypt_Cookie_Default_Decryptor(t *testing.T) {
+	t.Parallel()
+
+	// The default decryptor accepts values of EncryptCookie
+	app := cookieApp(Config{Key: testKey, Encryptor: EncryptCookie})
+	cookies := responseCookies(t, app)
+	decrypted, err := DecryptCookie(cookies["test1"], testKey)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "One", decrypted)
+	utils.AssertEqual(t, "One,Two", requestCookies(t, app, cookies["test1"], cookies["test2"]))
+
+	// The default encryptor is used with a custom decryptor
+	app = cookieApp(Config{Key: testKey, Decryptor: DecryptCookie})
+	cookies = responseCookies(t, app)
+	utils.AssertEqual(t, "One", decryptTestCookie(t, "test1", cookies["test1"]))
+}
+
+// go test -run Test_Encrypt_Cookie_Only
+func Test_Encrypt_Cookie_Only(t *testing.T) {
+	t.Parallel()
+
+	app := cookieApp(Config{Key: testKey, Only: []string{"test2"}})
+	cookies := responseCookies(t, app)
+
+	utils.AssertEqual(t, "One", cookies["test1"])
+	utils.AssertEqual(t, "Two", decryptTestCookie(t, "test2", cookies["test2"]))
+	utils.AssertEqual(t, "One,Two", requestCookies(t, app, cookies["test1"], cookies["test2"]))
+}
+
+// go test -run Test_Encrypt_Cookie_SignOnly
+func Test_Encrypt_Cookie_SignOnly(t *testing.T) {
+	t.Parallel()
+
+	oldKey := GenerateKey()
+	cookies := responseCookies(t, cookieApp(Config{Key: oldKey, SignOnly: []string{"test1"}}))
+
+	utils.AssertEqual(t, true, strings.HasPrefix(cookies["test1"], "One."))
+	utils.AssertEqual(t, "Two", decryptTestCookie(t, "test2", cookies["test2"], oldKey))
+
+	app := cookieApp(Config{Keys: []string{testKey, oldKey}, SignOnly: []string{"test1"}})
+	utils.AssertEqual(t, "One,Two", requestCookies(t, app, cookies["test1"], cookies["test2"]))
+
+	// Tampered values are rejected
+	signature := cookies["test1"][len("One"):]
+	utils.AssertEqual(t, ",Two", requestCookies(t, app, "Two"+signature, cookies["test2"]))
+	utils.AssertEqual(t, ",Two", requestCookies(t, app, "One", cookies["test2"]))
+
+	// Signatures are bound to the name of the cookie
+	app = cookieApp(Config{Key: oldKey, SignOnly: []string{"test1", "test2"}})
+	signed := responseCookies(t, app)
+	utils.AssertEqual(t, "One,Two", requestCookies(t, app, signed["test1"], signed["test2"]))
+	utils.AssertEqual(t, ",", requestCookies(t, app, signed["test2"], signed["test1"]))
+}
+
+// go test -run Test_Encrypt_Cookie_Invalid_Key
+func Test_Encrypt_Cookie_Invalid_Key(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		utils.AssertEqual(t, true, recover() != nil)
+	}()
+
+	New(Config{Keys: []string{"secret-thirty-2-character-string"}})
+}
@@ -0,0 +1,126 @@
+package encryptcookie
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+)
+
+var (
+	errInvalidValue     = errors.New("encrypted value is not valid")
+	errInvalidSignature = errors.New("cookie signature is not valid")
+)
+
+// keyRing holds the keys of the middleware, the first key is the newest
+type keyRing struct {
+	keys  []string
+	aeads []cipher.AEAD
+	signs [][]byte
+}
+
+// newKeyRing parses the given keys. The AES-GCM ciphers are only
+// created when aead is true, as custom encryptors may use other formats.
+func newKeyRing(keys []string, aead bool) (*keyRing, error) {
+	r := &keyRing{keys: keys}
+	for _, key := range keys {
+		mac := hmac.New(sha256.New, []byte(key))
+		_, _ = mac.Write([]byte("encryptcookie signing key"))
+		r.signs = append(r.signs, mac.Sum(nil))
+
+		if !aead {
+			continue
+		}
+		keyDecoded, err := base64.StdEncoding.DecodeString(key)
+		if err != nil {
+			return nil, err
+		}
+		block, err := aes.NewCipher(keyDecoded)
+		if err != nil {
+			return nil, err
+		}
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		r.aeads = append(r.aeads, gcm)
+	}
+	return r, nil
+}
+
+// encrypt encrypts the value with the newest key, binding it to the cookie name
+func (r *keyRing) encrypt(name, value string) (string, error) {
+	gcm := r.aeads[0]
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(value)+gcm.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	ciphertext := gcm.Seal(nonce, nonce, []byte(value), []byte(name))
+
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// decrypt decrypts the value with any key of the ring. Values encrypted for
+// another cookie name are rejected. Values encrypted without associated
+// data by previous versions are only accepted if legacy is true.
+func (r *keyRing) decrypt(name, value string, legacy bool) (string, error) {
+	enc, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return "", errInvalidValue
+	}
+	ads := [][]byte{[]byte(name)}
+	if legacy {
+		ads = append(ads, nil)
+	}
+	for _, ad := range ads {
+		for _, gcm := range r.aeads {
+			nonceSize := gcm.NonceSize()
+			if len(enc) < nonceSize {
+				return "", errInvalidValue
+			}
+			plaintext, err := gcm.Open(nil, enc[:nonceSize], enc[nonceSize:], ad)
+			if err == nil {
+				return string(plaintext), nil
+			}
+		}
+	}
+	return "", errInvalidValue
+}
+
+// sign appends a signature of the cookie name and value made with the newest key
+func (r *keyRing) sign(name, value string) string {
+	return value + "." + base64.RawURLEncoding.EncodeToString(signature(r.signs[0], name, value))
+}
+
+// verify checks the signature of the value with any key of the ring and
+// returns the value without signature
+func (r *keyRing) verify(name, value string) (string, error) {
+	i := strings.LastIndexByte(value, '.')
+	if i < 0 {
+		return "", errInvalidSignature
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(value[i+1:])
+	if err != nil {
+		return "", errInvalidSignature
+	}
+	value = value[:i]
+	for _, key := range r.signs {
+		if hmac.Equal(sig, signature(key, name, value)) {
+			return value, nil
+		}
+	}
+	return "", errInvalidSignature
+}
+
+func signature(key []byte, name, value string) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(name))
+	_, _ = mac.Write([]byte{'='})
+	_, _ = mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
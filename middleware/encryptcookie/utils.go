@@ -78,7 +78,19 @@ func GenerateKey() string {
 
 // Check given cookie key is disabled for encryption or not
 func isDisabled(key string, except []string) bool {
-	for _, k := range except {
+	return contains(key, except)
+}
+
+// Check given cookie key should be encrypted with the given config
+func isEncrypted(key string, cfg Config) bool {
+	if len(cfg.Only) > 0 {
+		return contains(key, cfg.Only)
+	}
+	return !isDisabled(key, cfg.Except)
+}
+
+func contains(key string, keys []string) bool {
+	for _, k := range keys {
 		if key == k {
 			return true
 		}
@@ -0,0 +1,198 @@
+package helmet
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// Config defines the config for middleware.
+// Header values set to "-" are not sent.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	//
+	// Optional. Default: nil
+	Next func(c *fiber.Ctx) bool
+
+	// XSSProtection is the value of the X-XSS-Protection header.
+	// The browser XSS filter is disabled as it introduces vulnerabilities.
+	//
+	// Optional. Default: "0"
+	XSSProtection string
+
+	// ContentTypeNosniff is the value of the X-Content-Type-Options header
+	//
+	// Optional. Default: "nosniff"
+	ContentTypeNosniff string
+
+	// XFrameOptions is the value of the X-Frame-Options header
+	//
+	// Optional. Default: "SAMEORIGIN"
+	XFrameOptions string
+
+	// HSTSMaxAge is the max-age in seconds of the Strict-Transport-Security
+	// header, which is only sent over HTTPS. It is disabled if negative.
+	//
+	// Optional. Default: 15552000 (180 days)
+	HSTSMaxAge int
+
+	// HSTSExcludeSubdomains omits includeSubDomains from the Strict-Transport-Security header
+	//
+	// Optional. Default: false
+	HSTSExcludeSubdomains bool
+
+	// HSTSPreloadEnabled adds preload to the Strict-Transport-Security header
+	//
+	// Optional. Default: false
+	HSTSPreloadEnabled bool
+
+	// ContentSecurityPolicy is a static Content-Security-Policy.
+	// It is ignored if CSP is set.
+	//
+	// Optional. Default: ""
+	ContentSecurityPolicy string
+
+	// CSP builds the Content-Security-Policy. If it contains SourceNonce,
+	// a nonce is generated for every request.
+	//
+	// Optional. Default: nil
+	CSP *CSP
+
+	// CSPReportOnly sends the policy as Content-Security-Policy-Report-Only,
+	// so violations are reported but not enforced
+	//
+	// Optional. Default: false
+	CSPReportOnly bool
+
+	// ReportPath is the path the middleware collects CSP violation reports at.
+	// It is added to the policy with the report-uri and report-to directives.
+	//
+	// Optional. Default: ""
+	ReportPath string
+
+	// OnReport is called for every violation report received at ReportPath
+	//
+	// Optional. Default: nil
+	OnReport func(c *fiber.Ctx, report Report)
+
+	// NonceContextKey is the key the nonce is stored with in Locals.
+	// With PassLocalsToViews enabled, templates can use it like
+	// <script nonce="{{.cspNonce}}">.
+	//
+	// Optional. Default: "cspNonce"
+	NonceContextKey string
+
+	// ReferrerPolicy is the value of the Referrer-Policy header
+	//
+	// Optional. Default: "no-referrer"
+	ReferrerPolicy string
+
+	// PermissionPolicy is the value of the Permissions-Policy header
+	//
+	// Optional. Default: ""
+	PermissionPolicy string
+
+	// CrossOriginEmbedderPolicy is the value of the Cross-Origin-Embedder-Policy header
+	//
+	// Optional. Default: "require-corp"
+	CrossOriginEmbedderPolicy string
+
+	// CrossOriginOpenerPolicy is the value of the Cross-Origin-Opener-Policy header
+	//
+	// Optional. Default: "same-origin"
+	CrossOriginOpenerPolicy string
+
+	// CrossOriginResourcePolicy is the value of the Cross-Origin-Resource-Policy header
+	//
+	// Optional. Default: "same-origin"
+	CrossOriginResourcePolicy string
+
+	// OriginAgentCluster is the value of the Origin-Agent-Cluster header
+	//
+	// Optional. Default: "?1"
+	OriginAgentCluster string
+
+	// XDNSPrefetchControl is the value of the X-DNS-Prefetch-Control header
+	//
+	// Optional. Default: "off"
+	XDNSPrefetchControl string
+
+	// XDownloadOptions is the value of the X-Download-Options header
+	//
+	// Optional. Default: "noopen"
+	XDownloadOptions string
+
+	// XPermittedCrossDomain is the value of the X-Permitted-Cross-Domain-Policies header
+	//
+	// Optional. Default: "none"
+	XPermittedCrossDomain string
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	Next:                      nil,
+	XSSProtection:             "0",
+	ContentTypeNosniff:        "nosniff",
+	XFrameOptions:             "SAMEORIGIN",
+	HSTSMaxAge:                15552000,
+	NonceContextKey:           "cspNonce",
+	ReferrerPolicy:            "no-referrer",
+	CrossOriginEmbedderPolicy: "require-corp",
+	CrossOriginOpenerPolicy:   "same-origin",
+	CrossOriginResourcePolicy: "same-origin",
+	OriginAgentCluster:        "?1",
+	XDNSPrefetchControl:       "off",
+	XDownloadOptions:          "noopen",
+	XPermittedCrossDomain:     "none",
+}
+
+// Helper function to set default values
+func configDefault(config ...Config) Config {
+	// Return default config if nothing provided
+	if len(config) < 1 {
+		return ConfigDefault
+	}
+
+	// Override default config
+	cfg := config[0]
+
+	// Set default values
+	if cfg.XSSProtection == "" {
+		cfg.XSSProtection = ConfigDefault.XSSProtection
+	}
+	if cfg.ContentTypeNosniff == "" {
+		cfg.ContentTypeNosniff = ConfigDefault.ContentTypeNosniff
+	}
+	if cfg.XFrameOptions == "" {
+		cfg.XFrameOptions = ConfigDefault.XFrameOptions
+	}
+	if cfg.HSTSMaxAge == 0 {
+		cfg.HSTSMaxAge = ConfigDefault.HSTSMaxAge
+	}
+	if cfg.NonceContextKey == "" {
+		cfg.NonceContextKey = ConfigDefault.NonceContextKey
+	}
+	if cfg.ReferrerPolicy == "" {
+		cfg.ReferrerPolicy = ConfigDefault.ReferrerPolicy
+	}
+	if cfg.CrossOriginEmbedderPolicy == "" {
+		cfg.CrossOriginEmbedderPolicy = ConfigDefault.CrossOriginEmbedderPolicy
+	}
+	if cfg.CrossOriginOpenerPolicy == "" {
+		cfg.CrossOriginOpenerPolicy = ConfigDefault.CrossOriginOpenerPolicy
+	}
+	if cfg.CrossOriginResourcePolicy == "" {
+		cfg.CrossOriginResourcePolicy = ConfigDefault.CrossOriginResourcePolicy
+	}
+	if cfg.OriginAgentCluster == "" {
+		cfg.OriginAgentCluster = ConfigDefault.OriginAgentCluster
+	}
+	if cfg.XDNSPrefetchControl == "" {
+		cfg.XDNSPrefetchControl = ConfigDefault.XDNSPrefetchControl
+	}
+	if cfg.XDownloadOptions == "" {
+		cfg.XDownloadOptions = ConfigDefault.XDownloadOptions
+	}
+	if cfg.XPermittedCrossDomain == "" {
+		cfg.XPermittedCrossDomain = ConfigDefault.XPermittedCrossDomain
+	}
+	return cfg
+}
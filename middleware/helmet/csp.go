@@ -0,0 +1,106 @@
+package helmet
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+)
+
+// Source expressions of a Content-Security-Policy
+const (
+	SourceSelf          = "'self'"
+	SourceNone          = "'none'"
+	SourceUnsafeInline  = "'unsafe-inline'"
+	SourceUnsafeEval    = "'unsafe-eval'"
+	SourceStrictDynamic = "'strict-dynamic'"
+	SourceReportSample  = "'report-sample'"
+	SourceData          = "data:"
+	SourceHTTPS         = "https:"
+	SourceBlob          = "blob:"
+	// SourceNonce is replaced with the nonce of the request
+	SourceNonce = "'nonce'"
+)
+
+// CSP builds a Content-Security-Policy. Directives are kept in the order they were added.
+type CSP struct {
+	names   []string
+	sources map[string][]string
+}
+
+// NewCSP creates an empty policy
+func NewCSP() *CSP {
+	return &CSP{sources: make(map[string][]string)}
+}
+
+// DefaultCSP returns a strict policy that only allows resources of the same
+// origin and scripts carrying the nonce of the request
+func DefaultCSP() *CSP {
+	return NewCSP().
+		Add("default-src", SourceSelf).
+		Add("base-uri", SourceSelf).
+		Add("font-src", SourceSelf, SourceHTTPS, SourceData).
+		Add("form-action", SourceSelf).
+		Add("frame-ancestors", SourceSelf).
+		Add("img-src", SourceSelf, SourceData).
+		Add("object-src", SourceNone).
+		Add("script-src", SourceSelf, SourceNonce).
+		Add("script-src-attr", SourceNone).
+		Add("style-src", SourceSelf, SourceNonce).
+		Add("upgrade-insecure-requests")
+}
+
+// Add appends sources to a directive, the directive is created if it doesn't exist
+func (p *CSP) Add(directive string, sources ...string) *CSP {
+	if _, ok := p.sources[directive]; !ok {
+		p.names = append(p.names, directive)
+		p.sources[directive] = []string{}
+	}
+	p.sources[directive] = append(p.sources[directive], sources...)
+	return p
+}
+
+// Set replaces the sources of a directive
+func (p *CSP) Set(directive string, sources ...string) *CSP {
+	p.Remove(directive)
+	return p.Add(directive, sources...)
+}
+
+// Remove deletes a directive
+func (p *CSP) Remove(directive string) *CSP {
+	if _, ok := p.sources[directive]; !ok {
+		return p
+	}
+	delete(p.sources, directive)
+	for i, name := range p.names {
+		if name == directive {
+			p.names = append(p.names[:i], p.names[i+1:]...)
+			break
+		}
+	}
+	return p
+}
+
+// String returns the policy, SourceNonce is not replaced
+func (p *CSP) String() string {
+	var b strings.Builder
+	for i, name := range p.names {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString(name)
+		for _, source := range p.sources[name] {
+			b.WriteByte(' ')
+			b.WriteString(source)
+		}
+	}
+	return b.String()
+}
+
+// newNonce returns a random base64 encoded nonce
+func newNonce() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
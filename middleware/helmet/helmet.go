@@ -0,0 +1,104 @@
+package helmet
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// reportGroup is the Reporting API endpoint name of ReportPath
+const reportGroup = "csp-endpoint"
+
+// New creates a new middleware handler
+func New(config ...Config) fiber.Handler {
+	// Set default config
+	cfg := configDefault(config...)
+
+	// Build the policy once, only the nonce changes per request
+	policy := cfg.ContentSecurityPolicy
+	if cfg.CSP != nil {
+		policy = cfg.CSP.String()
+	}
+	if policy != "" && cfg.ReportPath != "" {
+		policy += "; report-uri " + cfg.ReportPath + "; report-to " + reportGroup
+	}
+	withNonce := strings.Contains(policy, SourceNonce)
+	cspHeader := fiber.HeaderContentSecurityPolicy
+	if cfg.CSPReportOnly {
+		cspHeader = fiber.HeaderContentSecurityPolicyReportOnly
+	}
+
+	hsts := ""
+	if cfg.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.Itoa(cfg.HSTSMaxAge)
+		if !cfg.HSTSExcludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if cfg.HSTSPreloadEnabled {
+			hsts += "; preload"
+		}
+	}
+
+	// Return new handler
+	return func(c *fiber.Ctx) error {
+		// Don't execute middleware if Next returns true
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		// Collect violation reports
+		if cfg.ReportPath != "" && c.Method() == fiber.MethodPost && c.Path() == cfg.ReportPath {
+			reports, err := parseReports(c.Body())
+			if err != nil {
+				return fiber.ErrBadRequest
+			}
+			if cfg.OnReport != nil {
+				for _, report := range reports {
+					cfg.OnReport(c, report)
+				}
+			}
+			return c.SendStatus(fiber.StatusNoContent)
+		}
+
+		setHeader(c, fiber.HeaderXXSSProtection, cfg.XSSProtection)
+		setHeader(c, fiber.HeaderXContentTypeOptions, cfg.ContentTypeNosniff)
+		setHeader(c, fiber.HeaderXFrameOptions, cfg.XFrameOptions)
+		setHeader(c, fiber.HeaderReferrerPolicy, cfg.ReferrerPolicy)
+		setHeader(c, fiber.HeaderPermissionsPolicy, cfg.PermissionPolicy)
+		setHeader(c, fiber.HeaderCrossOriginEmbedderPolicy, cfg.CrossOriginEmbedderPolicy)
+		setHeader(c, fiber.HeaderCrossOriginOpenerPolicy, cfg.CrossOriginOpenerPolicy)
+		setHeader(c, fiber.HeaderCrossOriginResourcePolicy, cfg.CrossOriginResourcePolicy)
+		setHeader(c, fiber.HeaderOriginAgentCluster, cfg.OriginAgentCluster)
+		setHeader(c, fiber.HeaderXDNSPrefetchControl, cfg.XDNSPrefetchControl)
+		setHeader(c, fiber.HeaderXDownloadOptions, cfg.XDownloadOptions)
+		setHeader(c, fiber.HeaderXPermittedCrossDomainPolicies, cfg.XPermittedCrossDomain)
+
+		// Browsers ignore HSTS received over plain HTTP
+		if hsts != "" && c.Protocol() == "https" {
+			c.Set(fiber.HeaderStrictTransportSecurity, hsts)
+		}
+
+		if policy != "" {
+			if withNonce {
+				nonce := newNonce()
+				c.Locals(cfg.NonceContextKey, nonce)
+				c.Set(cspHeader, strings.ReplaceAll(policy, SourceNonce, "'nonce-"+nonce+"'"))
+			} else {
+				c.Set(cspHeader, policy)
+			}
+			if cfg.ReportPath != "" {
+				c.Set(fiber.HeaderReportingEndpoints, reportGroup+`="`+cfg.ReportPath+`"`)
+			}
+		}
+
+		return c.Next()
+	}
+}
+
+// setHeader sets a header if the value is not "-", which disables it
+func setHeader(c *fiber.Ctx, key, value string) {
+	if value != "" && value != "-" {
+		c.Set(key, value)
+	}
+}
@@ -0,0 +1,190 @@
+package helmet
+
+import (
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/valyala/fasthttp"
+)
+
+// go test -run Test_Helmet_Default
+func Test_Helmet_Default(t *testing.T) {
+	t.Parallel()
+
+	app := fiber.New()
+	app.Use(New())
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("Hello, World!")
+	})
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "0", resp.Header.Get(fiber.HeaderXXSSProtection))
+	utils.AssertEqual(t, "nosniff", resp.Header.Get(fiber.HeaderXContentTypeOptions))
+	utils.AssertEqual(t, "SAMEORIGIN", resp.Header.Get(fiber.HeaderXFrameOptions))
+	utils.AssertEqual(t, "no-referrer", resp.Header.Get(fiber.HeaderReferrerPolicy))
+	utils.AssertEqual(t, "require-corp", resp.Header.Get(fiber.HeaderCrossOriginEmbedderPolicy))
+	utils.AssertEqual(t, "same-origin", resp.Header.Get(fiber.HeaderCrossOriginOpenerPolicy))
+	utils.AssertEqual(t, "same-origin", resp.Header.Get(fiber.HeaderCrossOriginResourcePolicy))
+	utils.AssertEqual(t, "?1", resp.Header.Get(fiber.HeaderOriginAgentCluster))
+	utils.AssertEqual(t, "off", resp.Header.Get(fiber.HeaderXDNSPrefetchControl))
+	utils.AssertEqual(t, "noopen", resp.Header.Get(fiber.HeaderXDownloadOptions))
+	utils.AssertEqual(t, "none", resp.Header.Get(fiber.HeaderXPermittedCrossDomainPolicies))
+	utils.AssertEqual(t, "", resp.Header.Get(fiber.HeaderPermissionsPolicy))
+	utils.AssertEqual(t, "", resp.Header.Get(fiber.HeaderContentSecurityPolicy))
+	// Not sent over HTTP
+	utils.AssertEqual(t, "", resp.Header.Get(fiber.HeaderStrictTransportSecurity))
+}
+
+// go test -run Test_Helmet_Custom
+func Test_Helmet_Custom(t *testing.T) {
+	t.Parallel()
+
+	app := fiber.New()
+	app.Use(New(Config{
+		XFrameOptions:             "DENY",
+		PermissionPolicy:          "geolocation=(), camera=()",
+		CrossOriginEmbedderPolicy: "-",
+		ContentSecurityPolicy:     "default-src 'self'",
+		HSTSPreloadEnabled:        true,
+	}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+	req.Header.Set(fiber.HeaderXForwardedProto, "https")
+	resp, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "DENY", resp.Header.Get(fiber.HeaderXFrameOptions))
+	utils.AssertEqual(t, "geolocation=(), camera=()", resp.Header.Get(fiber.HeaderPermissionsPolicy))
+	utils.AssertEqual(t, "", resp.Header.Get(fiber.HeaderCrossOriginEmbedderPolicy))
+	utils.AssertEqual(t, "default-src 'self'", resp.Header.Get(fiber.HeaderContentSecurityPolicy))
+	utils.AssertEqual(t, "max-age=15552000; includeSubDomains; preload", resp.Header.Get(fiber.HeaderStrictTransportSecurity))
+}
+
+// go test -run Test_Helmet_CSP_Builder
+func Test_Helmet_CSP_Builder(t *testing.T) {
+	t.Parallel()
+
+	csp := NewCSP().
+		Add("default-src", SourceSelf).
+		Add("img-src", SourceSelf).
+		Add("img-src", SourceData).
+		Add("frame-src", SourceNone).
+		Set("default-src", SourceNone).
+		Remove("frame-src").
+		Add("upgrade-insecure-requests")
+	utils.AssertEqual(t, "img-src 'self' data:; default-src 'none'; upgrade-insecure-requests", csp.String())
+}
+
+// go test -run Test_Helmet_CSP_Nonce
+func Test_Helmet_CSP_Nonce(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	tmpl := filepath.Join(dir, "index.html")
+	utils.AssertEqual(t, nil, os.WriteFile(tmpl, []byte(`<script nonce="{{.cspNonce}}"></script>`), 0o600))
+
+	app := fiber.New(fiber.Config{PassLocalsToViews: true})
+	app.Use(New(Config{CSP: DefaultCSP()}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.Render(tmpl, fiber.Map{})
+	})
+
+	nonces := make(map[string]bool)
+	pattern := regexp.MustCompile(`script-src 'self' 'nonce-([^']+)'`)
+	for i := 0; i < 2; i++ {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+		utils.AssertEqual(t, nil, err)
+		policy := resp.Header.Get(fiber.HeaderContentSecurityPolicy)
+		match := pattern.FindStringSubmatch(policy)
+		utils.AssertEqual(t, 2, len(match), policy)
+		utils.AssertEqual(t, true, strings.Contains(policy, "style-src 'self' 'nonce-"+match[1]+"'"))
+
+		body, err := io.ReadAll(resp.Body)
+		utils.AssertEqual(t, nil, err)
+		utils.AssertEqual(t, `<script nonce="`+match[1]+`"></script>`, string(body))
+		nonces[match[1]] = true
+	}
+	utils.AssertEqual(t, 2, len(nonces))
+}
+
+// go test -run Test_Helmet_CSP_Report
+func Test_Helmet_CSP_Report(t *testing.T) {
+	t.Parallel()
+
+	var reports []Report
+	app := fiber.New()
+	app.Use(New(Config{
+		ContentSecurityPolicy: "default-src 'self'",
+		CSPReportOnly:         true,
+		ReportPath:            "/csp-report",
+		OnReport: func(c *fiber.Ctx, report Report) {
+			reports = append(reports, report)
+		},
+	}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "", resp.Header.Get(fiber.HeaderContentSecurityPolicy))
+	utils.AssertEqual(t, "default-src 'self'; report-uri /csp-report; report-to csp-endpoint", resp.Header.Get(fiber.HeaderContentSecurityPolicyReportOnly))
+	utils.AssertEqual(t, `csp-endpoint="/csp-report"`, resp.Header.Get(fiber.HeaderReportingEndpoints))
+
+	legacy := `{"csp-report": {"document-uri": "https://example.com/", "blocked-uri": "https://evil.com/x.js", "violated-directive": "script-src-elem", "line-number": 3}}`
+	req := httptest.NewRequest(fiber.MethodPost, "/csp-report", strings.NewReader(legacy))
+	req.Header.Set(fiber.HeaderContentType, "application/csp-report")
+	resp, err = app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusNoContent, resp.StatusCode)
+
+	batch := `[{"type": "csp-violation", "body": {"documentURL": "https://example.com/", "blockedURL": "inline", "effectiveDirective": "style-src", "disposition": "report"}}, {"type": "deprecation", "body": {}}]`
+	req = httptest.NewRequest(fiber.MethodPost, "/csp-report", strings.NewReader(batch))
+	req.Header.Set(fiber.HeaderContentType, "application/reports+json")
+	resp, err = app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusNoContent, resp.StatusCode)
+
+	utils.AssertEqual(t, 2, len(reports))
+	utils.AssertEqual(t, "https://evil.com/x.js", reports[0].BlockedURI)
+	utils.AssertEqual(t, "script-src-elem", reports[0].ViolatedDirective)
+	utils.AssertEqual(t, 3, reports[0].LineNumber)
+	utils.AssertEqual(t, "inline", reports[1].BlockedURI)
+	utils.AssertEqual(t, "style-src", reports[1].EffectiveDirective)
+	utils.AssertEqual(t, "report", reports[1].Disposition)
+
+	req = httptest.NewRequest(fiber.MethodPost, "/csp-report", strings.NewReader("not json"))
+	resp, err = app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusBadRequest, resp.StatusCode)
+}
+
+// go test -v -run=^$ -bench=Benchmark_Helmet -benchmem -count=4
+func Benchmark_Helmet(b *testing.B) {
+	app := fiber.New()
+	app.Use(New(Config{CSP: DefaultCSP()}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return nil
+	})
+	h := app.Handler()
+
+	fctx := &fasthttp.RequestCtx{}
+	fctx.Request.Header.SetMethod(fiber.MethodGet)
+	fctx.Request.SetRequestURI("/")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		h(fctx)
+	}
+}
@@ -0,0 +1,82 @@
+package helmet
+
+import (
+	"encoding/json"
+)
+
+// Report is a Content-Security-Policy violation report
+type Report struct {
+	DocumentURI        string `json:"document-uri"`
+	Referrer           string `json:"referrer"`
+	BlockedURI         string `json:"blocked-uri"`
+	ViolatedDirective  string `json:"violated-directive"`
+	EffectiveDirective string `json:"effective-directive"`
+	OriginalPolicy     string `json:"original-policy"`
+	Disposition        string `json:"disposition"`
+	SourceFile         string `json:"source-file"`
+	Sample             string `json:"script-sample"`
+	LineNumber         int    `json:"line-number"`
+	ColumnNumber       int    `json:"column-number"`
+	StatusCode         int    `json:"status-code"`
+}
+
+// reportingAPIBody is the body of a csp-violation report of the Reporting API
+type reportingAPIBody struct {
+	DocumentURL        string `json:"documentURL"`
+	Referrer           string `json:"referrer"`
+	BlockedURL         string `json:"blockedURL"`
+	EffectiveDirective string `json:"effectiveDirective"`
+	OriginalPolicy     string `json:"originalPolicy"`
+	Disposition        string `json:"disposition"`
+	SourceFile         string `json:"sourceFile"`
+	Sample             string `json:"sample"`
+	LineNumber         int    `json:"lineNumber"`
+	ColumnNumber       int    `json:"columnNumber"`
+	StatusCode         int    `json:"statusCode"`
+}
+
+// parseReports parses the legacy application/csp-report format sent for report-uri
+// and the application/reports+json format of the Reporting API sent for report-to
+func parseReports(body []byte) ([]Report, error) {
+	var legacy struct {
+		Report *Report `json:"csp-report"`
+	}
+	if len(body) > 0 && body[0] == '{' {
+		if err := json.Unmarshal(body, &legacy); err != nil {
+			return nil, err
+		}
+		if legacy.Report == nil {
+			return nil, nil
+		}
+		return []Report{*legacy.Report}, nil
+	}
+
+	var batch []struct {
+		Type string           `json:"type"`
+		Body reportingAPIBody `json:"body"`
+	}
+	if err := json.Unmarshal(body, &batch); err != nil {
+		return nil, err
+	}
+	reports := make([]Report, 0, len(batch))
+	for _, r := range batch {
+		if r.Type != "csp-violation" {
+			continue
+		}
+		reports = append(reports, Report{
+			DocumentURI:        r.Body.DocumentURL,
+			Referrer:           r.Body.Referrer,
+			BlockedURI:         r.Body.BlockedURL,
+			ViolatedDirective:  r.Body.EffectiveDirective,
+			EffectiveDirective: r.Body.EffectiveDirective,
+			OriginalPolicy:     r.Body.OriginalPolicy,
+			Disposition:        r.Body.Disposition,
+			SourceFile:         r.Body.SourceFile,
+			Sample:             r.Body.Sample,
+			LineNumber:         r.Body.LineNumber,
+			ColumnNumber:       r.Body.ColumnNumber,
+			StatusCode:         r.Body.StatusCode,
+		})
+	}
+	return reports, nil
+}
@@ -0,0 +1,100 @@
+package ipfilter
+
+import (
+	"net"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Config defines the config for middleware.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	//
+	// Optional. Default: nil
+	Next func(c *fiber.Ctx) bool
+
+	// Allow is a list of IP addresses and CIDR ranges that are allowed.
+	// When allow rules are set, all other IP addresses are denied.
+	//
+	// Optional. Default: nil
+	Allow []string
+
+	// Deny is a list of IP addresses and CIDR ranges that are denied.
+	// Deny rules take precedence over allow rules.
+	//
+	// Optional. Default: nil
+	Deny []string
+
+	// AllowFile is the path to a file with allowed IP addresses and CIDR
+	// ranges, one per line. Empty lines and lines starting with # are ignored.
+	//
+	// Optional. Default: ""
+	AllowFile string
+
+	// DenyFile is the path to a file with denied IP addresses and CIDR
+	// ranges, in the same format as AllowFile.
+	//
+	// Optional. Default: ""
+	DenyFile string
+
+	// ReloadInterval is the interval in which AllowFile and DenyFile are
+	// checked for changes. Files are only reloaded when they have been
+	// modified. If a file can't be loaded, the previous rules are kept.
+	//
+	// Optional. Default: 0 (disabled)
+	ReloadInterval time.Duration
+
+	// Country returns the ISO 3166-1 alpha-2 country code of the IP
+	// address, e.g. by looking it up in a GeoIP database. It is required
+	// when AllowCountries or DenyCountries is set.
+	//
+	// Optional. Default: nil
+	Country func(c *fiber.Ctx, ip net.IP) string
+
+	// AllowCountries is a list of country codes that are allowed.
+	// When set, IP addresses that don't match an allow rule are denied.
+	//
+	// Optional. Default: nil
+	AllowCountries []string
+
+	// DenyCountries is a list of country codes that are denied.
+	//
+	// Optional. Default: nil
+	DenyCountries []string
+
+	// Forbidden defines the response when the client IP address is denied.
+	//
+	// Optional. Default: func(c *fiber.Ctx) error { return fiber.ErrForbidden }
+	Forbidden fiber.Handler
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	Next:           nil,
+	ReloadInterval: 0,
+	Forbidden: func(c *fiber.Ctx) error {
+		return fiber.ErrForbidden
+	},
+}
+
+// Helper function to set default values
+func configDefault(config ...Config) Config {
+	// Return default config if nothing provided
+	if len(config) < 1 {
+		return ConfigDefault
+	}
+
+	// Override default config
+	cfg := config[0]
+
+	// Set default values
+	if cfg.Forbidden == nil {
+		cfg.Forbidden = ConfigDefault.Forbidden
+	}
+	if cfg.Country == nil && (len(cfg.AllowCountries) > 0 || len(cfg.DenyCountries) > 0) {
+		panic("[IPFILTER] Country is required for AllowCountries and DenyCountries")
+	}
+
+	return cfg
+}
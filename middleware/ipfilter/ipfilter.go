@@ -0,0 +1,214 @@
+package ipfilter
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// filter holds the allow and deny rules of the middleware
+type filter struct {
+	allow *rules
+	deny  *rules
+}
+
+// loader loads the rules of the config and reloads them when the files change
+type loader struct {
+	cfg    Config
+	filter atomic.Value
+	// reloading guards next and modTime, only one request checks the files
+	reloading int32
+	next      time.Time
+	modTime   map[string]time.Time
+}
+
+func newLoader(cfg Config) *loader {
+	l := &loader{cfg: cfg, modTime: make(map[string]time.Time)}
+	f, err := l.load()
+	if err != nil {
+		panic("[IPFILTER] " + err.Error())
+	}
+	l.filter.Store(f)
+	l.next = time.Now().Add(cfg.ReloadInterval)
+	return l
+}
+
+// load parses the rules of the config and files
+func (l *loader) load() (*filter, error) {
+	allowFile, err := l.read(l.cfg.AllowFile)
+	if err != nil {
+		return nil, err
+	}
+	denyFile, err := l.read(l.cfg.DenyFile)
+	if err != nil {
+		return nil, err
+	}
+	allow, err := parseRules(l.cfg.Allow, allowFile)
+	if err != nil {
+		return nil, err
+	}
+	deny, err := parseRules(l.cfg.Deny, denyFile)
+	if err != nil {
+		return nil, err
+	}
+	return &filter{allow: allow, deny: deny}, nil
+}
+
+func (l *loader) read(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if info, err := os.Stat(path); err == nil {
+		l.modTime[path] = info.ModTime()
+	}
+	entries, err := readRules(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// modified returns true if a file has been modified since it was loaded
+func (l *loader) modified() bool {
+	for _, path := range []string{l.cfg.AllowFile, l.cfg.DenyFile} {
+		if path == "" {
+			continue
+		}
+		if info, err := os.Stat(path); err == nil && !info.ModTime().Equal(l.modTime[path]) {
+			return true
+		}
+	}
+	return false
+}
+
+// get returns the current rules, reloading the files if needed
+func (l *loader) get() *filter {
+	if l.cfg.ReloadInterval > 0 && (l.cfg.AllowFile != "" || l.cfg.DenyFile != "") && atomic.CompareAndSwapInt32(&l.reloading, 0, 1) {
+		if now := time.Now(); now.After(l.next) {
+			l.next = now.Add(l.cfg.ReloadInterval)
+			if l.modified() {
+				if f, err := l.load(); err == nil {
+					l.filter.Store(f)
+				} else {
+					fmt.Printf("[Warning] ipfilter: %v, keeping previous rules\n", err)
+				}
+			}
+		}
+		atomic.StoreInt32(&l.reloading, 0)
+	}
+	return l.filter.Load().(*filter)
+}
+
+// New creates a new middleware handler
+func New(config ...Config) fiber.Handler {
+	// Set default config
+	cfg := configDefault(config...)
+
+	// Load rules
+	rules := newLoader(cfg)
+
+	// Trusted proxies of the apps the middleware is used by
+	var proxies sync.Map
+
+	allowCountries := make(map[string]struct{}, len(cfg.AllowCountries))
+	for _, country := range cfg.AllowCountries {
+		allowCountries[strings.ToUpper(country)] = struct{}{}
+	}
+	denyCountries := make(map[string]struct{}, len(cfg.DenyCountries))
+	for _, country := range cfg.DenyCountries {
+		denyCountries[strings.ToUpper(country)] = struct{}{}
+	}
+
+	// Return new handler
+	return func(c *fiber.Ctx) error {
+		// Don't execute middleware if Next returns true
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		ip := clientIP(c, &proxies)
+		if ip == nil {
+			return cfg.Forbidden(c)
+		}
+
+		f := rules.get()
+		if f.deny.contains(ip) {
+			return cfg.Forbidden(c)
+		}
+
+		var country string
+		if cfg.Country != nil {
+			country = strings.ToUpper(cfg.Country(c, ip))
+		}
+		if _, denied := denyCountries[country]; denied && country != "" {
+			return cfg.Forbidden(c)
+		}
+
+		// Without allow rules, all IP addresses that are not denied are allowed
+		if f.allow.empty() && len(allowCountries) == 0 {
+			return c.Next()
+		}
+		if f.allow.contains(ip) {
+			return c.Next()
+		}
+		if _, allowed := allowCountries[country]; allowed && country != "" {
+			return c.Next()
+		}
+
+		return cfg.Forbidden(c)
+	}
+}
+
+// clientIP returns the IP address of the client. The ProxyHeader of the
+// app is only used when EnableTrustedProxyCheck is enabled and the request
+// comes from a trusted proxy, in which case the rightmost address of the
+// header that isn't a trusted proxy is used. Returns nil if the address is
+// invalid.
+func clientIP(c *fiber.Ctx, proxies *sync.Map) net.IP {
+	remoteIP := c.Context().RemoteIP()
+
+	appConfig := c.App().Config()
+	if !appConfig.EnableTrustedProxyCheck || appConfig.ProxyHeader == "" || !c.IsProxyTrusted() {
+		return remoteIP
+	}
+	header := c.Get(appConfig.ProxyHeader)
+	if header == "" {
+		// The request was made by the proxy itself
+		return remoteIP
+	}
+
+	trusted, ok := proxies.Load(c.App())
+	if !ok {
+		trusted, _ = proxies.LoadOrStore(c.App(), parseTrustedProxies(appConfig.TrustedProxies))
+	}
+
+	// Proxies append the address of their client, so addresses left of the
+	// first untrusted address can be spoofed by the client
+	hops := strings.Split(header, ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(hops[i]))
+		if ip == nil {
+			return nil
+		}
+		if i == 0 || !trusted.(*rules).contains(ip) {
+			return ip
+		}
+	}
+	return nil
+}
+
+// parseTrustedProxies parses the trusted proxies of the app, invalid
+// entries are ignored like the app does
+func parseTrustedProxies(entries []string) *rules {
+	trusted := &rules{ips: make(map[string]struct{})}
+	for _, entry := range entries {
+		_ = trusted.add(entry)
+	}
+	return trusted
+}
@@ -0,0 +1,221 @@
+package ipfilter
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/valyala/fasthttp"
+)
+
+func testApp(config Config, appConfig ...fiber.Config) fasthttp.RequestHandler {
+	app := fiber.New(appConfig...)
+	app.Use(New(config))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	return app.Handler()
+}
+
+func request(h fasthttp.RequestHandler, remoteIP string, headers ...string) int {
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(fiber.MethodGet)
+	ctx.Request.SetRequestURI("/")
+	for i := 0; i+1 < len(headers); i += 2 {
+		ctx.Request.Header.Set(headers[i], headers[i+1])
+	}
+	ctx.SetRemoteAddr(&net.TCPAddr{IP: net.ParseIP(remoteIP), Port: 1234})
+	h(ctx)
+	return ctx.Response.StatusCode()
+}
+
+// go test -run Test_IPFilter_Allow
+func Test_IPFilter_Allow(t *testing.T) {
+	t.Parallel()
+
+	h := testApp(Config{
+		Allow: []string{"10.0.0.0/8", "192.168.1.1", "2001:db8::/32"},
+		Deny:  []string{"10.0.0.1"},
+	})
+
+	utils.AssertEqual(t, fiber.StatusOK, request(h, "10.1.2.3"))
+	utils.AssertEqual(t, fiber.StatusOK, request(h, "192.168.1.1"))
+	utils.AssertEqual(t, fiber.StatusOK, request(h, "2001:db8::1"))
+	utils.AssertEqual(t, fiber.StatusForbidden, request(h, "192.168.1.2"))
+	utils.AssertEqual(t, fiber.StatusForbidden, request(h, "10.0.0.1"))
+}
+
+// go test -run Test_IPFilter_Deny
+func Test_IPFilter_Deny(t *testing.T) {
+	t.Parallel()
+
+	h := testApp(Config{
+		Deny: []string{"203.0.113.0/24"},
+	})
+
+	utils.AssertEqual(t, fiber.StatusOK, request(h, "198.51.100.1"))
+	utils.AssertEqual(t, fiber.StatusForbidden, request(h, "203.0.113.7"))
+}
+
+// go test -run Test_IPFilter_Invalid
+func Test_IPFilter_Invalid(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		utils.AssertEqual(t, "[IPFILTER] invalid CIDR range \"10.0.0.0/33\"", recover())
+	}()
+
+	New(Config{Allow: []string{"10.0.0.0/33"}})
+}
+
+// go test -run Test_IPFilter_ProxyHeader
+func Test_IPFilter_ProxyHeader(t *testing.T) {
+	t.Parallel()
+
+	h := testApp(Config{
+		Allow: []string{"198.51.100.1"},
+	}, fiber.Config{
+		ProxyHeader:             fiber.HeaderXForwardedFor,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          []string{"10.0.0.0/8"},
+	})
+
+	// The header is used for trusted proxies
+	utils.AssertEqual(t, fiber.StatusOK, request(h, "10.0.0.1", fiber.HeaderXForwardedFor, "198.51.100.1, 10.0.0.2"))
+	utils.AssertEqual(t, fiber.StatusForbidden, request(h, "10.0.0.1", fiber.HeaderXForwardedFor, "198.51.100.2"))
+	utils.AssertEqual(t, fiber.StatusForbidden, request(h, "10.0.0.1", fiber.HeaderXForwardedFor, "invalid"))
+
+	// The header is ignored for other clients
+	utils.AssertEqual(t, fiber.StatusForbidden, request(h, "203.0.113.1", fiber.HeaderXForwardedFor, "198.51.100.1"))
+	utils.AssertEqual(t, fiber.StatusOK, request(h, "198.51.100.1", fiber.HeaderXForwardedFor, "203.0.113.1"))
+}
+
+// go test -run Test_IPFilter_ProxyHeader_Spoofing
+func Test_IPFilter_ProxyHeader_Spoofing(t *testing.T) {
+	t.Parallel()
+
+	// Without the trusted proxy check, the header is ignored
+	h := testApp(Config{
+		Allow: []string{"198.51.100.1"},
+	}, fiber.Config{
+		ProxyHeader: fiber.HeaderXForwardedFor,
+	})
+
+	utils.AssertEqual(t, fiber.StatusForbidden, request(h, "203.0.113.1", fiber.HeaderXForwardedFor, "198.51.100.1"))
+	utils.AssertEqual(t, fiber.StatusOK, request(h, "198.51.100.1", fiber.HeaderXForwardedFor, "203.0.113.1"))
+
+	// Addresses prepended by the client are ignored
+	h = testApp(Config{
+		Allow: []string{"198.51.100.1"},
+	}, fiber.Config{
+		ProxyHeader:             fiber.HeaderXForwardedFor,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          []string{"10.0.0.0/8", "192.168.0.1"},
+	})
+
+	utils.AssertEqual(t, fiber.StatusForbidden, request(h, "10.0.0.1", fiber.HeaderXForwardedFor, "198.51.100.1, 203.0.113.1"))
+	utils.AssertEqual(t, fiber.StatusOK, request(h, "10.0.0.1", fiber.HeaderXForwardedFor, "203.0.113.1, 198.51.100.1, 192.168.0.1, 10.0.0.2"))
+	utils.AssertEqual(t, fiber.StatusForbidden, request(h, "10.0.0.1", fiber.HeaderXForwardedFor, "198.51.100.1, invalid"))
+}
+
+// go test -run Test_IPFilter_Country
+func Test_IPFilter_Country(t *testing.T) {
+	t.Parallel()
+
+	country := func(c *fiber.Ctx, ip net.IP) string {
+		switch ip.String() {
+		case "198.51.100.1":
+			return "nl"
+		case "198.51.100.2":
+			return "US"
+		}
+		return ""
+	}
+
+	h := testApp(Config{
+		Country:        country,
+		AllowCountries: []string{"NL"},
+		Allow:          []string{"10.0.0.0/8"},
+	})
+	utils.AssertEqual(t, fiber.StatusOK, request(h, "198.51.100.1"))
+	utils.AssertEqual(t, fiber.StatusForbidden, request(h, "198.51.100.2"))
+	utils.AssertEqual(t, fiber.StatusForbidden, request(h, "198.51.100.3"))
+	utils.AssertEqual(t, fiber.StatusOK, request(h, "10.0.0.1"))
+
+	h = testApp(Config{
+		Country:       country,
+		DenyCountries: []string{"us"},
+	})
+	utils.AssertEqual(t, fiber.StatusOK, request(h, "198.51.100.1"))
+	utils.AssertEqual(t, fiber.StatusForbidden, request(h, "198.51.100.2"))
+	utils.AssertEqual(t, fiber.StatusOK, request(h, "198.51.100.3"))
+}
+
+// go test -run Test_IPFilter_File_Reload
+func Test_IPFilter_File_Reload(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "deny.txt")
+	utils.AssertEqual(t, nil, os.WriteFile(path, []byte("# blocked\n203.0.113.1\n\n"), 0o600))
+
+	h := testApp(Config{
+		DenyFile:       path,
+		ReloadInterval: time.Millisecond,
+	})
+	utils.AssertEqual(t, fiber.StatusForbidden, request(h, "203.0.113.1"))
+	utils.AssertEqual(t, fiber.StatusOK, request(h, "203.0.113.2"))
+
+	utils.AssertEqual(t, nil, os.WriteFile(path, []byte("203.0.113.2\n"), 0o600))
+	utils.AssertEqual(t, nil, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+	time.Sleep(5 * time.Millisecond)
+	utils.AssertEqual(t, fiber.StatusOK, request(h, "203.0.113.1"))
+	utils.AssertEqual(t, fiber.StatusForbidden, request(h, "203.0.113.2"))
+
+	// Invalid files keep the previous rules
+	utils.AssertEqual(t, nil, os.WriteFile(path, []byte("invalid\n"), 0o600))
+	utils.AssertEqual(t, nil, os.Chtimes(path, time.Now(), time.Now().Add(2*time.Minute)))
+	time.Sleep(5 * time.Millisecond)
+	utils.AssertEqual(t, fiber.StatusForbidden, request(h, "203.0.113.2"))
+}
+
+// go test -run Test_IPFilter_Next
+func Test_IPFilter_Next(t *testing.T) {
+	t.Parallel()
+
+	h := testApp(Config{
+		Deny: []string{"0.0.0.0/0"},
+		Next: func(_ *fiber.Ctx) bool {
+			return true
+		},
+	})
+	utils.AssertEqual(t, fiber.StatusOK, request(h, "203.0.113.1"))
+}
+
+// go test -v -run=^$ -bench=Benchmark_IPFilter -benchmem -count=4
+func Benchmark_IPFilter(b *testing.B) {
+	app := fiber.New()
+	app.Use(New(Config{
+		Allow: []string{"10.0.0.0/8", "192.168.0.0/16"},
+		Deny:  []string{"10.0.0.1"},
+	}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return nil
+	})
+	h := app.Handler()
+
+	fctx := &fasthttp.RequestCtx{}
+	fctx.Request.Header.SetMethod(fiber.MethodGet)
+	fctx.Request.SetRequestURI("/")
+	fctx.SetRemoteAddr(&net.TCPAddr{IP: net.ParseIP("192.168.1.1"), Port: 1234})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		h(fctx)
+	}
+}
@@ -0,0 +1,85 @@
+package ipfilter
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// rules is a set of IP addresses and CIDR ranges
+type rules struct {
+	ips  map[string]struct{}
+	nets []*net.IPNet
+}
+
+// parseRules parses a list of IP addresses and CIDR ranges
+func parseRules(entries ...[]string) (*rules, error) {
+	r := &rules{ips: make(map[string]struct{})}
+	for _, list := range entries {
+		for _, entry := range list {
+			if err := r.add(entry); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return r, nil
+}
+
+func (r *rules) add(entry string) error {
+	entry = strings.TrimSpace(entry)
+	if strings.Contains(entry, "/") {
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return fmt.Errorf("invalid CIDR range %q", entry)
+		}
+		r.nets = append(r.nets, ipNet)
+		return nil
+	}
+	ip := net.ParseIP(entry)
+	if ip == nil {
+		return fmt.Errorf("invalid IP address %q", entry)
+	}
+	r.ips[string(ip.To16())] = struct{}{}
+	return nil
+}
+
+// empty returns true if the set has no rules
+func (r *rules) empty() bool {
+	return len(r.ips) == 0 && len(r.nets) == 0
+}
+
+// contains returns true if the IP address matches a rule of the set
+func (r *rules) contains(ip net.IP) bool {
+	if _, ok := r.ips[string(ip.To16())]; ok {
+		return true
+	}
+	for _, ipNet := range r.nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// readRules reads the IP addresses and CIDR ranges of a file, one per line.
+// Empty lines and lines starting with # are ignored.
+func readRules(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		entries = append(entries, line)
+	}
+	return entries, nil
+}
@@ -0,0 +1,176 @@
+package jwt
+
+import (
+	"errors"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Config defines the config for middleware.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	//
+	// Optional. Default: nil
+	Next func(c *fiber.Ctx) bool
+
+	// Algorithms is the allowlist of accepted signing algorithms.
+	// Supported: HS256, HS384, HS512, RS256, RS384, RS512, PS256, PS384, PS512,
+	// ES256, ES384, ES512 and EdDSA. Tokens with other algorithms are rejected.
+	//
+	// Required.
+	Algorithms []string
+
+	// SigningKey is the key used to verify tokens without JWKS: a []byte secret
+	// for HMAC, or a *rsa.PublicKey, *ecdsa.PublicKey or ed25519.PublicKey.
+	//
+	// Optional. Default: nil
+	SigningKey interface{}
+
+	// JWKSFile is the path of a local JSON Web Key Set
+	//
+	// Optional. Default: ""
+	JWKSFile string
+
+	// JWKSURL is the URL of a JSON Web Key Set endpoint
+	//
+	// Optional. Default: ""
+	JWKSURL string
+
+	// JWKSRefreshInterval is how long the key set is cached before it is reloaded
+	//
+	// Optional. Default: 1 * time.Hour
+	JWKSRefreshInterval time.Duration
+
+	// JWKSMinRefreshInterval is the minimum time between two reloads of the key set,
+	// which are also triggered by tokens signed with an unknown key ID.
+	//
+	// Optional. Default: 1 * time.Minute
+	JWKSMinRefreshInterval time.Duration
+
+	// Issuer is the expected "iss" claim. It is not validated if empty.
+	//
+	// Optional. Default: ""
+	Issuer string
+
+	// Audience defines the accepted "aud" claims, the token must contain one of them.
+	// It is not validated if empty.
+	//
+	// Optional. Default: nil
+	Audience []string
+
+	// Leeway is the tolerated clock skew when validating "exp" and "nbf"
+	//
+	// Optional. Default: 0
+	Leeway time.Duration
+
+	// KeyLookup is a string in the form of "<source>:<key>" that is used
+	// to extract the token from the request.
+	// Possible values:
+	// - "header:<name>"
+	// - "query:<name>"
+	// - "cookie:<name>"
+	//
+	// Optional. Default: "header:Authorization"
+	KeyLookup string
+
+	// AuthScheme is the scheme expected in front of the token in the Authorization header
+	//
+	// Optional. Default: "Bearer"
+	AuthScheme string
+
+	// ContextKey is the key to store the verified claims in Locals
+	//
+	// Optional. Default: "user"
+	ContextKey string
+
+	// SuccessHandler is called after the token was verified
+	//
+	// Optional. Default: func(c *fiber.Ctx) error {
+	//   return c.Next()
+	// }
+	SuccessHandler fiber.Handler
+
+	// ErrorHandler is called when the token is missing or invalid
+	//
+	// Optional. Default: 401 with a RFC 6750 WWW-Authenticate challenge
+	ErrorHandler fiber.ErrorHandler
+
+	// now returns the current time, it is replaced in tests
+	now func() time.Time
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	Next:                   nil,
+	JWKSRefreshInterval:    1 * time.Hour,
+	JWKSMinRefreshInterval: 1 * time.Minute,
+	KeyLookup:              "header:" + fiber.HeaderAuthorization,
+	AuthScheme:             "Bearer",
+	ContextKey:             "user",
+	SuccessHandler: func(c *fiber.Ctx) error {
+		return c.Next()
+	},
+	ErrorHandler: func(c *fiber.Ctx, err error) error {
+		challenge := `Bearer error="invalid_token", error_description="` + err.Error() + `"`
+		if errors.Is(err, ErrMissingToken) {
+			challenge = "Bearer"
+		}
+		c.Set(fiber.HeaderWWWAuthenticate, challenge)
+		return c.Status(fiber.StatusUnauthorized).SendString(err.Error())
+	},
+}
+
+// Helper function to set default values
+func configDefault(config Config) Config {
+	cfg := config
+
+	// Set default values
+	if len(cfg.Algorithms) == 0 {
+		panic("[JWT] Algorithms is required")
+	}
+	for _, alg := range cfg.Algorithms {
+		if _, ok := algorithms[alg]; !ok {
+			panic("[JWT] unsupported algorithm " + alg)
+		}
+	}
+	if cfg.SigningKey == nil && cfg.JWKSFile == "" && cfg.JWKSURL == "" {
+		panic("[JWT] SigningKey, JWKSFile or JWKSURL is required")
+	}
+	if cfg.JWKSRefreshInterval <= 0 {
+		cfg.JWKSRefreshInterval = ConfigDefault.JWKSRefreshInterval
+	}
+	if cfg.JWKSMinRefreshInterval <= 0 {
+		cfg.JWKSMinRefreshInterval = ConfigDefault.JWKSMinRefreshInterval
+	}
+	if cfg.KeyLookup == "" {
+		cfg.KeyLookup = ConfigDefault.KeyLookup
+	}
+	if cfg.AuthScheme == "" {
+		cfg.AuthScheme = ConfigDefault.AuthScheme
+	}
+	if cfg.ContextKey == "" {
+		cfg.ContextKey = ConfigDefault.ContextKey
+	}
+	if cfg.SuccessHandler == nil {
+		cfg.SuccessHandler = ConfigDefault.SuccessHandler
+	}
+	if cfg.ErrorHandler == nil {
+		cfg.ErrorHandler = ConfigDefault.ErrorHandler
+	}
+	if cfg.now == nil {
+		cfg.now = time.Now
+	}
+
+	selectors := strings.Split(cfg.KeyLookup, ":")
+	if len(selectors) != 2 {
+		panic("[JWT] KeyLookup must in the form of <source>:<key>")
+	}
+	if selectors[0] == "header" {
+		selectors[1] = textproto.CanonicalMIMEHeaderKey(selectors[1])
+		cfg.KeyLookup = selectors[0] + ":" + selectors[1]
+	}
+	return cfg
+}
@@ -0,0 +1,213 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// jwk is a single JSON Web Key as defined in RFC 7517
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// key is a parsed verification key
+type key struct {
+	alg string
+	key interface{}
+}
+
+// parseJWKS parses a JSON Web Key Set. Keys that are not meant for signatures
+// or have an unsupported type are skipped.
+func parseJWKS(data []byte) (map[string]key, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]key, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.public()
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", k.Kid, err)
+		}
+		if pub != nil {
+			keys[k.Kid] = key{alg: k.Alg, key: pub}
+		}
+	}
+	return keys, nil
+}
+
+// public returns the verification key of the JWK
+func (k jwk) public() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, errors.New("unsupported curve " + k.Crv)
+		}
+		x, err := decodeInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, errors.New("unsupported curve " + k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	case "oct":
+		return base64.RawURLEncoding.DecodeString(k.K)
+	}
+	return nil, nil
+}
+
+func decodeInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// keySet caches the keys of a JWKS source and reloads them to pick up rotated keys
+type keySet struct {
+	mu       sync.RWMutex
+	keys     map[string]key
+	loadedAt time.Time
+	loading  bool
+
+	load        func() ([]byte, error)
+	refresh     time.Duration
+	minRefresh  time.Duration
+	now         func() time.Time
+	lastAttempt time.Time
+}
+
+func newKeySet(cfg *Config) *keySet {
+	ks := &keySet{
+		refresh:    cfg.JWKSRefreshInterval,
+		minRefresh: cfg.JWKSMinRefreshInterval,
+		now:        cfg.now,
+	}
+	if cfg.JWKSFile != "" {
+		path := cfg.JWKSFile
+		ks.load = func() ([]byte, error) {
+			return os.ReadFile(path)
+		}
+	} else {
+		url := cfg.JWKSURL
+		ks.load = func() ([]byte, error) {
+			code, body, errs := fiber.Get(url).Timeout(10 * time.Second).Bytes()
+			if len(errs) > 0 {
+				return nil, errs[0]
+			}
+			if code != fiber.StatusOK {
+				return nil, fmt.Errorf("unexpected status code %d", code)
+			}
+			return body, nil
+		}
+	}
+	return ks
+}
+
+// reload fetches the key set. On failure the previous keys are kept.
+func (ks *keySet) reload() error {
+	ks.mu.Lock()
+	if ks.loading {
+		ks.mu.Unlock()
+		return nil
+	}
+	ks.loading = true
+	ks.lastAttempt = ks.now()
+	ks.mu.Unlock()
+
+	data, err := ks.load()
+	var keys map[string]key
+	if err == nil {
+		keys, err = parseJWKS(data)
+	}
+
+	ks.mu.Lock()
+	ks.loading = false
+	if err == nil {
+		ks.keys = keys
+		ks.loadedAt = ks.now()
+	}
+	ks.mu.Unlock()
+	return err
+}
+
+// get returns the key with the given ID. The set is reloaded if it is stale
+// or the key is unknown, but not more often than the minimum refresh interval.
+func (ks *keySet) get(kid string) (key, bool) {
+	ks.mu.RLock()
+	k, ok := ks.keys[kid]
+	now := ks.now()
+	stale := now.Sub(ks.loadedAt) >= ks.refresh
+	throttled := now.Sub(ks.lastAttempt) < ks.minRefresh
+	ks.mu.RUnlock()
+
+	if (stale || !ok) && !throttled {
+		_ = ks.reload()
+		ks.mu.RLock()
+		k, ok = ks.keys[kid]
+		ks.mu.RUnlock()
+	}
+	return k, ok
+}
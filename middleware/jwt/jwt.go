@@ -0,0 +1,106 @@
+package jwt
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// New creates a new middleware handler
+func New(config Config) fiber.Handler {
+	// Set default config
+	cfg := configDefault(config)
+
+	allowed := make(map[string]bool, len(cfg.Algorithms))
+	for _, alg := range cfg.Algorithms {
+		allowed[alg] = true
+	}
+
+	var keys *keySet
+	if cfg.JWKSFile != "" || cfg.JWKSURL != "" {
+		keys = newKeySet(&cfg)
+		// A broken local file is a configuration error, an unreachable
+		// endpoint is retried on the next request
+		if err := keys.reload(); err != nil && cfg.JWKSFile != "" {
+			panic("[JWT] failed to load JWKS file: " + err.Error())
+		}
+	}
+
+	extract := extractor(cfg.KeyLookup, cfg.AuthScheme)
+
+	// Return new handler
+	return func(c *fiber.Ctx) error {
+		// Don't execute middleware if Next returns true
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		raw := extract(c)
+		if raw == "" {
+			return cfg.ErrorHandler(c, ErrMissingToken)
+		}
+		t, err := parse(raw)
+		if err != nil {
+			return cfg.ErrorHandler(c, err)
+		}
+
+		alg, ok := algorithms[t.header.Alg]
+		if !ok || !allowed[t.header.Alg] {
+			return cfg.ErrorHandler(c, ErrAlgorithm)
+		}
+
+		// Keys from the key set take precedence over the static key
+		var key interface{}
+		if keys != nil {
+			if k, found := keys.get(t.header.Kid); found {
+				if k.alg != "" && k.alg != t.header.Alg {
+					return cfg.ErrorHandler(c, ErrAlgorithm)
+				}
+				key = k.key
+			}
+		}
+		if key == nil {
+			key = cfg.SigningKey
+		}
+		if key == nil {
+			return cfg.ErrorHandler(c, ErrKeyNotFound)
+		}
+
+		if err = t.verify(alg, key); err != nil {
+			return cfg.ErrorHandler(c, err)
+		}
+		if err = cfg.validate(t.claims); err != nil {
+			return cfg.ErrorHandler(c, err)
+		}
+
+		c.Locals(cfg.ContextKey, t.claims)
+		return cfg.SuccessHandler(c)
+	}
+}
+
+// extractor returns a function that reads the raw token from the request
+func extractor(lookup, scheme string) func(c *fiber.Ctx) string {
+	selectors := strings.Split(lookup, ":")
+	source, name := selectors[0], selectors[1]
+	switch source {
+	case "query":
+		return func(c *fiber.Ctx) string {
+			return c.Query(name)
+		}
+	case "cookie":
+		return func(c *fiber.Ctx) string {
+			return c.Cookies(name)
+		}
+	}
+	return func(c *fiber.Ctx) string {
+		auth := c.Get(name)
+		if name != fiber.HeaderAuthorization {
+			return auth
+		}
+		l := len(scheme)
+		if len(auth) > l+1 && strings.EqualFold(auth[:l], scheme) && auth[l] == ' ' {
+			return strings.TrimSpace(auth[l+1:])
+		}
+		return ""
+	}
+}
@@ -0,0 +1,374 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/valyala/fasthttp"
+)
+
+var testNow = time.Unix(1700000000, 0)
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// sign creates a compact token, sig signs the header and payload
+func sign(t *testing.T, alg, kid string, claims Claims, sig func(signed []byte) []byte) string {
+	t.Helper()
+	h := map[string]string{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		h["kid"] = kid
+	}
+	hb, err := json.Marshal(h)
+	utils.AssertEqual(t, nil, err)
+	cb, err := json.Marshal(claims)
+	utils.AssertEqual(t, nil, err)
+	signed := b64(hb) + "." + b64(cb)
+	return signed + "." + b64(sig([]byte(signed)))
+}
+
+func hs256(secret []byte) func([]byte) []byte {
+	return func(signed []byte) []byte {
+		mac := hmac.New(sha256.New, secret)
+		_, _ = mac.Write(signed)
+		return mac.Sum(nil)
+	}
+}
+
+func rs256(key *rsa.PrivateKey) func([]byte) []byte {
+	return func(signed []byte) []byte {
+		digest := sha256.Sum256(signed)
+		sig, _ := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		return sig
+	}
+}
+
+func es256(key *ecdsa.PrivateKey) func([]byte) []byte {
+	return func(signed []byte) []byte {
+		digest := sha256.Sum256(signed)
+		r, s, _ := ecdsa.Sign(rand.Reader, key, digest[:])
+		sig := make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+		return sig
+	}
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA", "kid": kid, "use": "sig", "alg": "RS256",
+		"n": b64(key.N.Bytes()), "e": b64(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func jwks(t *testing.T, keys ...map[string]string) []byte {
+	t.Helper()
+	b, err := json.Marshal(map[string]interface{}{"keys": keys})
+	utils.AssertEqual(t, nil, err)
+	return b
+}
+
+func newTestApp(cfg Config) *fiber.App {
+	if cfg.now == nil {
+		cfg.now = func() time.Time { return testNow }
+	}
+	app := fiber.New()
+	app.Use(New(cfg))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString(c.Locals("user").(Claims).Subject())
+	})
+	return app
+}
+
+func request(t *testing.T, app *fiber.App, token string) (int, string, string) {
+	t.Helper()
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+	if token != "" {
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+	}
+	resp, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	return resp.StatusCode, string(body), resp.Header.Get(fiber.HeaderWWWAuthenticate)
+}
+
+// go test -run Test_JWT_HMAC
+func Test_JWT_HMAC(t *testing.T) {
+	t.Parallel()
+
+	secret := []byte("secret")
+	app := newTestApp(Config{Algorithms: []string{"HS256"}, SigningKey: secret})
+
+	code, body, _ := request(t, app, sign(t, "HS256", "", Claims{"sub": "john"}, hs256(secret)))
+	utils.AssertEqual(t, fiber.StatusOK, code)
+	utils.AssertEqual(t, "john", body)
+
+	code, _, challenge := request(t, app, "")
+	utils.AssertEqual(t, fiber.StatusUnauthorized, code)
+	utils.AssertEqual(t, "Bearer", challenge)
+
+	code, body, challenge = request(t, app, sign(t, "HS256", "", Claims{"sub": "john"}, hs256([]byte("wrong"))))
+	utils.AssertEqual(t, fiber.StatusUnauthorized, code)
+	utils.AssertEqual(t, ErrInvalidSignature.Error(), body)
+	utils.AssertEqual(t, `Bearer error="invalid_token", error_description="invalid signature"`, challenge)
+
+	_, body, _ = request(t, app, "not.a.token")
+	utils.AssertEqual(t, ErrMalformedToken.Error(), body)
+}
+
+// go test -run Test_JWT_Algorithm_Allowlist
+func Test_JWT_Algorithm_Allowlist(t *testing.T) {
+	t.Parallel()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	utils.AssertEqual(t, nil, err)
+	app := newTestApp(Config{Algorithms: []string{"RS256"}, SigningKey: &key.PublicKey})
+
+	code, body, _ := request(t, app, sign(t, "RS256", "", Claims{"sub": "john"}, rs256(key)))
+	utils.AssertEqual(t, fiber.StatusOK, code)
+	utils.AssertEqual(t, "john", body)
+
+	// "none" and algorithms outside of the allowlist are rejected
+	_, body, _ = request(t, app, sign(t, "none", "", Claims{"sub": "john"}, func([]byte) []byte { return nil }))
+	utils.AssertEqual(t, ErrAlgorithm.Error(), body)
+	_, body, _ = request(t, app, sign(t, "HS256", "", Claims{"sub": "john"}, hs256([]byte("secret"))))
+	utils.AssertEqual(t, ErrAlgorithm.Error(), body)
+
+	// A public key can't be used as an HMAC secret
+	app = newTestApp(Config{Algorithms: []string{"RS256", "HS256"}, SigningKey: &key.PublicKey})
+	_, body, _ = request(t, app, sign(t, "HS256", "", Claims{"sub": "john"}, hs256(key.PublicKey.N.Bytes())))
+	utils.AssertEqual(t, ErrKeyNotFound.Error(), body)
+}
+
+// go test -run Test_JWT_ECDSA_EdDSA
+func Test_JWT_ECDSA_EdDSA(t *testing.T) {
+	t.Parallel()
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	utils.AssertEqual(t, nil, err)
+	app := newTestApp(Config{Algorithms: []string{"ES256"}, SigningKey: &ecKey.PublicKey})
+	code, _, _ := request(t, app, sign(t, "ES256", "", Claims{"sub": "john"}, es256(ecKey)))
+	utils.AssertEqual(t, fiber.StatusOK, code)
+
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	utils.AssertEqual(t, nil, err)
+	app = newTestApp(Config{Algorithms: []string{"EdDSA"}, SigningKey: edPub})
+	code, _, _ = request(t, app, sign(t, "EdDSA", "", Claims{"sub": "john"}, func(signed []byte) []byte {
+		return ed25519.Sign(edKey, signed)
+	}))
+	utils.AssertEqual(t, fiber.StatusOK, code)
+}
+
+// go test -run Test_JWT_Claims
+func Test_JWT_Claims(t *testing.T) {
+	t.Parallel()
+
+	secret := []byte("secret")
+	app := newTestApp(Config{
+		Algorithms: []string{"HS256"},
+		SigningKey: secret,
+		Issuer:     "https://issuer.example.com",
+		Audience:   []string{"api", "admin"},
+		Leeway:     30 * time.Second,
+	})
+
+	now := testNow.Unix()
+	valid := Claims{"sub": "john", "iss": "https://issuer.example.com", "aud": []string{"web", "api"}}
+	with := func(k string, v interface{}) Claims {
+		c := Claims{}
+		for key, value := range valid {
+			c[key] = value
+		}
+		c[k] = v
+		return c
+	}
+
+	tests := []struct {
+		claims Claims
+		err    error
+	}{
+		{valid, nil},
+		{with("exp", now-10), nil},
+		{with("exp", now-60), ErrExpired},
+		{with("nbf", now+10), nil},
+		{with("nbf", now+60), ErrNotValidYet},
+		{with("exp", "2030-01-01"), ErrMalformedToken},
+		{with("nbf", nil), ErrMalformedToken},
+		{with("iat", true), ErrMalformedToken},
+		{with("iss", "https://evil.example.com"), ErrIssuer},
+		{with("aud", "admin"), nil},
+		{with("aud", "web"), ErrAudience},
+	}
+
+	for _, tt := range tests {
+		code, body, _ := request(t, app, sign(t, "HS256", "", tt.claims, hs256(secret)))
+		if tt.err == nil {
+			utils.AssertEqual(t, fiber.StatusOK, code)
+			continue
+		}
+		utils.AssertEqual(t, fiber.StatusUnauthorized, code)
+		utils.AssertEqual(t, tt.err.Error(), body)
+	}
+}
+
+// go test -run Test_JWT_JWKS_File
+func Test_JWT_JWKS_File(t *testing.T) {
+	t.Parallel()
+
+	key1, err := rsa.GenerateKey(rand.Reader, 2048)
+	utils.AssertEqual(t, nil, err)
+	key2, err := rsa.GenerateKey(rand.Reader, 2048)
+	utils.AssertEqual(t, nil, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	utils.AssertEqual(t, nil, os.WriteFile(path, jwks(t, rsaJWK("k1", &key1.PublicKey)), 0o600))
+
+	var mu sync.Mutex
+	now := testNow
+	app := newTestApp(Config{
+		Algorithms:             []string{"RS256"},
+		JWKSFile:               path,
+		JWKSMinRefreshInterval: time.Minute,
+		now: func() time.Time {
+			mu.Lock()
+			defer mu.Unlock()
+			return now
+		},
+	})
+
+	code, _, _ := request(t, app, sign(t, "RS256", "k1", Claims{"sub": "john"}, rs256(key1)))
+	utils.AssertEqual(t, fiber.StatusOK, code)
+
+	// Rotate the key, the unknown key ID triggers a reload once the
+	// minimum refresh interval passed
+	utils.AssertEqual(t, nil, os.WriteFile(path, jwks(t, rsaJWK("k2", &key2.PublicKey)), 0o600))
+	token := sign(t, "RS256", "k2", Claims{"sub": "john"}, rs256(key2))
+	code, body, _ := request(t, app, token)
+	utils.AssertEqual(t, fiber.StatusUnauthorized, code)
+	utils.AssertEqual(t, ErrKeyNotFound.Error(), body)
+
+	mu.Lock()
+	now = now.Add(time.Minute)
+	mu.Unlock()
+	code, _, _ = request(t, app, token)
+	utils.AssertEqual(t, fiber.StatusOK, code)
+
+	// The old key is gone
+	code, _, _ = request(t, app, sign(t, "RS256", "k1", Claims{"sub": "john"}, rs256(key1)))
+	utils.AssertEqual(t, fiber.StatusUnauthorized, code)
+}
+
+// go test -run Test_JWT_JWKS_URL
+func Test_JWT_JWKS_URL(t *testing.T) {
+	t.Parallel()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	utils.AssertEqual(t, nil, err)
+	set := jwks(t, map[string]string{
+		"kty": "EC", "kid": "ec1", "crv": "P-256",
+		"x": b64(key.X.FillBytes(make([]byte, 32))), "y": b64(key.Y.FillBytes(make([]byte, 32))),
+	})
+
+	// Local stand-in for the identity provider
+	var fetches int32
+	provider := fiber.New(fiber.Config{DisableStartupMessage: true})
+	provider.Get("/.well-known/jwks.json", func(c *fiber.Ctx) error {
+		atomic.AddInt32(&fetches, 1)
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return c.Send(set)
+	})
+	ln, err := net.Listen(fiber.NetworkTCP4, "127.0.0.1:0")
+	utils.AssertEqual(t, nil, err)
+	go func() { utils.AssertEqual(t, nil, provider.Listener(ln)) }()
+	defer func() { _ = provider.Shutdown() }()
+
+	var mu sync.Mutex
+	now := testNow
+	app := newTestApp(Config{
+		Algorithms:          []string{"ES256"},
+		JWKSURL:             "http://" + ln.Addr().String() + "/.well-known/jwks.json",
+		JWKSRefreshInterval: time.Hour,
+		now: func() time.Time {
+			mu.Lock()
+			defer mu.Unlock()
+			return now
+		},
+	})
+	utils.AssertEqual(t, int32(1), atomic.LoadInt32(&fetches))
+
+	token := sign(t, "ES256", "ec1", Claims{"sub": "john"}, es256(key))
+	for i := 0; i < 3; i++ {
+		code, body, _ := request(t, app, token)
+		utils.AssertEqual(t, fiber.StatusOK, code)
+		utils.AssertEqual(t, "john", body)
+	}
+	// The key set is cached
+	utils.AssertEqual(t, int32(1), atomic.LoadInt32(&fetches))
+
+	mu.Lock()
+	now = now.Add(time.Hour)
+	mu.Unlock()
+	code, _, _ := request(t, app, token)
+	utils.AssertEqual(t, fiber.StatusOK, code)
+	utils.AssertEqual(t, int32(2), atomic.LoadInt32(&fetches))
+}
+
+// go test -run Test_JWT_Config_Panics
+func Test_JWT_Config_Panics(t *testing.T) {
+	t.Parallel()
+
+	assertPanic := func(cfg Config) {
+		defer func() { utils.AssertEqual(t, true, recover() != nil) }()
+		_ = New(cfg)
+	}
+	assertPanic(Config{SigningKey: []byte("secret")})
+	assertPanic(Config{Algorithms: []string{"none"}, SigningKey: []byte("secret")})
+	assertPanic(Config{Algorithms: []string{"HS256"}})
+	assertPanic(Config{Algorithms: []string{"RS256"}, JWKSFile: "does-not-exist.json"})
+}
+
+// go test -v -run=^$ -bench=Benchmark_JWT_HMAC -benchmem -count=4
+func Benchmark_JWT_HMAC(b *testing.B) {
+	secret := []byte("secret")
+	handler := New(Config{Algorithms: []string{"HS256"}, SigningKey: secret})
+	app := fiber.New()
+	app.Use(handler)
+	app.Get("/", func(c *fiber.Ctx) error { return nil })
+	h := app.Handler()
+
+	token := "eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiJqb2huIn0." + b64(hs256(secret)([]byte("eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiJqb2huIn0")))
+
+	fctx := &fasthttp.RequestCtx{}
+	fctx.Request.Header.SetMethod(fiber.MethodGet)
+	fctx.Request.SetRequestURI("/")
+	fctx.Request.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		h(fctx)
+	}
+	utils.AssertEqual(b, fiber.StatusOK, fctx.Response.StatusCode())
+}
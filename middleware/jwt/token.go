@@ -0,0 +1,260 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	// Register the hash functions used by the algorithms
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+// Errors returned when a token is rejected
+var (
+	ErrMissingToken     = errors.New("missing or malformed token")
+	ErrMalformedToken   = errors.New("malformed token")
+	ErrAlgorithm        = errors.New("unexpected signing algorithm")
+	ErrKeyNotFound      = errors.New("signing key not found")
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrExpired          = errors.New("token is expired")
+	ErrNotValidYet      = errors.New("token is not valid yet")
+	ErrIssuer           = errors.New("invalid issuer")
+	ErrAudience         = errors.New("invalid audience")
+)
+
+// Claims are the verified claims of a token
+type Claims map[string]interface{}
+
+// Subject returns the "sub" claim
+func (c Claims) Subject() string {
+	s, _ := c["sub"].(string)
+	return s
+}
+
+// Issuer returns the "iss" claim
+func (c Claims) Issuer() string {
+	s, _ := c["iss"].(string)
+	return s
+}
+
+// Audience returns the "aud" claim, which can be a string or an array
+func (c Claims) Audience() []string {
+	switch aud := c["aud"].(type) {
+	case string:
+		return []string{aud}
+	case []interface{}:
+		out := make([]string, 0, len(aud))
+		for _, v := range aud {
+			if s, ok := v.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// ExpiresAt returns the "exp" claim and whether it is present
+func (c Claims) ExpiresAt() (time.Time, bool) {
+	return c.time("exp")
+}
+
+// NotBefore returns the "nbf" claim and whether it is present
+func (c Claims) NotBefore() (time.Time, bool) {
+	return c.time("nbf")
+}
+
+// IssuedAt returns the "iat" claim and whether it is present
+func (c Claims) IssuedAt() (time.Time, bool) {
+	return c.time("iat")
+}
+
+func (c Claims) time(name string) (time.Time, bool) {
+	switch v := c[name].(type) {
+	case float64:
+		return time.Unix(0, int64(v*float64(time.Second))), true
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return time.Time{}, false
+		}
+		return time.Unix(0, int64(f*float64(time.Second))), true
+	}
+	return time.Time{}, false
+}
+
+// algorithm describes how a signing algorithm verifies signatures
+type algorithm struct {
+	hash   crypto.Hash
+	family string
+	// size is the byte size of r and s of ECDSA signatures
+	size int
+}
+
+// Algorithm families
+const (
+	familyHMAC    = "HMAC"
+	familyRSA     = "RSA"
+	familyRSAPSS  = "RSAPSS"
+	familyECDSA   = "ECDSA"
+	familyEd25519 = "Ed25519"
+)
+
+var algorithms = map[string]algorithm{
+	"HS256": {hash: crypto.SHA256, family: familyHMAC},
+	"HS384": {hash: crypto.SHA384, family: familyHMAC},
+	"HS512": {hash: crypto.SHA512, family: familyHMAC},
+	"RS256": {hash: crypto.SHA256, family: familyRSA},
+	"RS384": {hash: crypto.SHA384, family: familyRSA},
+	"RS512": {hash: crypto.SHA512, family: familyRSA},
+	"PS256": {hash: crypto.SHA256, family: familyRSAPSS},
+	"PS384": {hash: crypto.SHA384, family: familyRSAPSS},
+	"PS512": {hash: crypto.SHA512, family: familyRSAPSS},
+	"ES256": {hash: crypto.SHA256, family: familyECDSA, size: 32},
+	"ES384": {hash: crypto.SHA384, family: familyECDSA, size: 48},
+	"ES512": {hash: crypto.SHA512, family: familyECDSA, size: 66},
+	"EdDSA": {family: familyEd25519},
+}
+
+// header is the JOSE header of a token
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// token is a parsed but not yet verified token
+type token struct {
+	header    header
+	claims    Claims
+	signed    string
+	signature []byte
+}
+
+// parse splits and decodes a compact serialized token
+func parse(raw string) (*token, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformedToken
+	}
+	t := &token{signed: raw[:len(parts[0])+1+len(parts[1])]}
+
+	b, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || json.Unmarshal(b, &t.header) != nil {
+		return nil, ErrMalformedToken
+	}
+	if b, err = base64.RawURLEncoding.DecodeString(parts[1]); err != nil {
+		return nil, ErrMalformedToken
+	}
+	if json.Unmarshal(b, &t.claims) != nil || t.claims == nil {
+		return nil, ErrMalformedToken
+	}
+	if t.signature, err = base64.RawURLEncoding.DecodeString(parts[2]); err != nil {
+		return nil, ErrMalformedToken
+	}
+	return t, nil
+}
+
+// verify checks the signature of the token with key.
+// The key type must match the algorithm family, which prevents a public key
+// from being used as an HMAC secret.
+func (t *token) verify(alg algorithm, key interface{}) error {
+	var digest []byte
+	if alg.hash != 0 {
+		h := alg.hash.New()
+		_, _ = h.Write([]byte(t.signed))
+		digest = h.Sum(nil)
+	}
+
+	switch alg.family {
+	case familyHMAC:
+		secret, ok := key.([]byte)
+		if !ok {
+			return ErrKeyNotFound
+		}
+		mac := hmac.New(alg.hash.New, secret)
+		_, _ = mac.Write([]byte(t.signed))
+		if !hmac.Equal(mac.Sum(nil), t.signature) {
+			return ErrInvalidSignature
+		}
+	case familyRSA, familyRSAPSS:
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return ErrKeyNotFound
+		}
+		var err error
+		if alg.family == familyRSA {
+			err = rsa.VerifyPKCS1v15(pub, alg.hash, digest, t.signature)
+		} else {
+			err = rsa.VerifyPSS(pub, alg.hash, digest, t.signature, nil)
+		}
+		if err != nil {
+			return ErrInvalidSignature
+		}
+	case familyECDSA:
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || (pub.Curve.Params().BitSize+7)/8 != alg.size {
+			return ErrKeyNotFound
+		}
+		if len(t.signature) != 2*alg.size {
+			return ErrInvalidSignature
+		}
+		r := new(big.Int).SetBytes(t.signature[:alg.size])
+		s := new(big.Int).SetBytes(t.signature[alg.size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return ErrInvalidSignature
+		}
+	case familyEd25519:
+		pub, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return ErrKeyNotFound
+		}
+		if !ed25519.Verify(pub, []byte(t.signed), t.signature) {
+			return ErrInvalidSignature
+		}
+	}
+	return nil
+}
+
+// validate checks the registered time, issuer and audience claims
+func (cfg *Config) validate(claims Claims) error {
+	// A time claim that isn't a NumericDate must not be treated as absent,
+	// a malformed "exp" would never expire
+	for _, name := range []string{"exp", "nbf", "iat"} {
+		if _, present := claims[name]; present {
+			if _, ok := claims.time(name); !ok {
+				return ErrMalformedToken
+			}
+		}
+	}
+
+	now := cfg.now()
+	if exp, ok := claims.ExpiresAt(); ok && !now.Before(exp.Add(cfg.Leeway)) {
+		return ErrExpired
+	}
+	if nbf, ok := claims.NotBefore(); ok && now.Add(cfg.Leeway).Before(nbf) {
+		return ErrNotValidYet
+	}
+	if cfg.Issuer != "" && claims.Issuer() != cfg.Issuer {
+		return ErrIssuer
+	}
+	if len(cfg.Audience) > 0 {
+		for _, aud := range claims.Audience() {
+			for _, want := range cfg.Audience {
+				if aud == want {
+					return nil
+				}
+			}
+		}
+		return ErrAudience
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package keyauth
+
+import (
+	"errors"
+	"net/textproto"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// Config defines the config for middleware.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	//
+	// Optional. Default: nil
+	Next func(c *fiber.Ctx) bool
+
+	// KeyLookup is a string in the form of "<source>:<key>" that is used
+	// to create an Extractor that extracts the key from the request.
+	// Possible values:
+	// - "header:<name>"
+	// - "query:<name>"
+	// - "param:<name>"
+	// - "form:<name>"
+	// - "cookie:<name>"
+	//
+	// Ignored if an Extractor is explicitly set.
+	//
+	// Optional. Default: "header:Authorization"
+	KeyLookup string
+
+	// AuthScheme is the scheme expected in front of the key
+	// if it is looked up in a header.
+	//
+	// Optional. Default: "Bearer" if KeyLookup is "header:Authorization"
+	AuthScheme string
+
+	// Extractor returns the key
+	//
+	// If set this will be used in place of an Extractor based on KeyLookup.
+	//
+	// Optional. Default will create an Extractor based on KeyLookup.
+	Extractor func(c *fiber.Ctx) (string, error)
+
+	// Validator reports whether the key is valid. The request context,
+	// i.e. c.UserContext(), can be used to look up the key.
+	// Returned errors are passed to the ErrorHandler.
+	//
+	// Required unless HashedKeys is set.
+	Validator func(c *fiber.Ctx, key string) (bool, error)
+
+	// HashedKeys defines the valid keys as hex encoded SHA-256 hashes,
+	// see HashKey. It is used if no Validator is set, so plaintext keys
+	// do not need to be stored.
+	//
+	// Optional. Default: nil
+	HashedKeys []string
+
+	// SuccessHandler is called after the key was validated
+	//
+	// Optional. Default: func(c *fiber.Ctx) error {
+	//   return c.Next()
+	// }
+	SuccessHandler fiber.Handler
+
+	// ErrorHandler is called when the key is missing, malformed or invalid.
+	// The default handler responds with a RFC 6750 WWW-Authenticate challenge.
+	//
+	// Optional. Default: see defaultErrorHandler
+	ErrorHandler fiber.ErrorHandler
+
+	// Realm is the realm attribute of the WWW-Authenticate challenge
+	//
+	// Optional. Default: "Restricted"
+	Realm string
+
+	// ContextKey is the key to store the validated key in Locals
+	//
+	// Optional. Default: "token"
+	ContextKey string
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	Next:       nil,
+	KeyLookup:  "header:" + fiber.HeaderAuthorization,
+	AuthScheme: "Bearer",
+	SuccessHandler: func(c *fiber.Ctx) error {
+		return c.Next()
+	},
+	Realm:      "Restricted",
+	ContextKey: "token",
+}
+
+// Helper function to set default values
+func configDefault(config ...Config) Config {
+	// Return default config if nothing provided
+	if len(config) < 1 {
+		panic("[KEYAUTH] Validator or HashedKeys is required")
+	}
+
+	// Override default config
+	cfg := config[0]
+
+	// Set default values
+	if cfg.KeyLookup == "" {
+		cfg.KeyLookup = ConfigDefault.KeyLookup
+	}
+	if cfg.AuthScheme == "" && utils.EqualFold(cfg.KeyLookup, ConfigDefault.KeyLookup) {
+		cfg.AuthScheme = ConfigDefault.AuthScheme
+	}
+	if cfg.SuccessHandler == nil {
+		cfg.SuccessHandler = ConfigDefault.SuccessHandler
+	}
+	if cfg.Realm == "" {
+		cfg.Realm = ConfigDefault.Realm
+	}
+	if cfg.ContextKey == "" {
+		cfg.ContextKey = ConfigDefault.ContextKey
+	}
+	if cfg.ErrorHandler == nil {
+		cfg.ErrorHandler = defaultErrorHandler(cfg.AuthScheme, cfg.Realm)
+	}
+	if cfg.Validator == nil {
+		if len(cfg.HashedKeys) == 0 {
+			panic("[KEYAUTH] Validator or HashedKeys is required")
+		}
+		cfg.Validator = hashedKeysValidator(cfg.HashedKeys)
+	}
+
+	// Generate the correct extractor to get the key from the correct location
+	selectors := strings.Split(cfg.KeyLookup, ":")
+
+	if len(selectors) != 2 {
+		panic("[KEYAUTH] KeyLookup must in the form of <source>:<key>")
+	}
+
+	if cfg.Extractor == nil {
+		// By default we extract from a header
+		cfg.Extractor = KeyFromHeader(textproto.CanonicalMIMEHeaderKey(selectors[1]), cfg.AuthScheme)
+
+		switch selectors[0] {
+		case "form":
+			cfg.Extractor = KeyFromForm(selectors[1])
+		case "query":
+			cfg.Extractor = KeyFromQuery(selectors[1])
+		case "param":
+			cfg.Extractor = KeyFromParam(selectors[1])
+		case "cookie":
+			cfg.Extractor = KeyFromCookie(selectors[1])
+		}
+	}
+
+	return cfg
+}
+
+// defaultErrorHandler responds with a RFC 6750 challenge.
+// Errors other than the ones of this package are returned unchanged.
+func defaultErrorHandler(scheme, realm string) fiber.ErrorHandler {
+	if scheme == "" {
+		scheme = ConfigDefault.AuthScheme
+	}
+	challenge := scheme + ` realm="` + realm + `"`
+
+	return func(c *fiber.Ctx, err error) error {
+		switch {
+		case errors.Is(err, ErrMissingKey):
+			c.Set(fiber.HeaderWWWAuthenticate, challenge)
+			return c.Status(fiber.StatusUnauthorized).SendString(err.Error())
+		case errors.Is(err, ErrMalformedKey):
+			c.Set(fiber.HeaderWWWAuthenticate, challenge+`, error="invalid_request", error_description="`+err.Error()+`"`)
+			return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+		case errors.Is(err, ErrInvalidKey):
+			c.Set(fiber.HeaderWWWAuthenticate, challenge+`, error="invalid_token", error_description="`+err.Error()+`"`)
+			return c.Status(fiber.StatusUnauthorized).SendString(err.Error())
+		}
+		return err
+	}
+}
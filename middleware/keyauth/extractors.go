@@ -0,0 +1,79 @@
+package keyauth
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// KeyFromHeader returns a function that extracts the key from the request header.
+// If authScheme is not empty, the header must be in the form of "<authScheme> <key>".
+func KeyFromHeader(header, authScheme string) func(c *fiber.Ctx) (string, error) {
+	return func(c *fiber.Ctx) (string, error) {
+		auth := c.Get(header)
+		if auth == "" {
+			return "", ErrMissingKey
+		}
+		if authScheme == "" {
+			return auth, nil
+		}
+		l := len(authScheme)
+		if len(auth) < l || !utils.EqualFold(auth[:l], authScheme) {
+			// Credentials of another scheme
+			return "", ErrMissingKey
+		}
+		if len(auth) == l || auth[l] != ' ' {
+			return "", ErrMalformedKey
+		}
+		key := strings.TrimLeft(auth[l+1:], " ")
+		if key == "" {
+			return "", ErrMalformedKey
+		}
+		return key, nil
+	}
+}
+
+// KeyFromQuery returns a function that extracts the key from the query string.
+func KeyFromQuery(param string) func(c *fiber.Ctx) (string, error) {
+	return func(c *fiber.Ctx) (string, error) {
+		key := c.Query(param)
+		if key == "" {
+			return "", ErrMissingKey
+		}
+		return key, nil
+	}
+}
+
+// KeyFromForm returns a function that extracts the key from a form.
+func KeyFromForm(param string) func(c *fiber.Ctx) (string, error) {
+	return func(c *fiber.Ctx) (string, error) {
+		key := c.FormValue(param)
+		if key == "" {
+			return "", ErrMissingKey
+		}
+		return key, nil
+	}
+}
+
+// KeyFromParam returns a function that extracts the key from the url param string.
+func KeyFromParam(param string) func(c *fiber.Ctx) (string, error) {
+	return func(c *fiber.Ctx) (string, error) {
+		key := c.Params(param)
+		if key == "" {
+			return "", ErrMissingKey
+		}
+		return key, nil
+	}
+}
+
+// KeyFromCookie returns a function that extracts the key from the cookie header.
+func KeyFromCookie(name string) func(c *fiber.Ctx) (string, error) {
+	return func(c *fiber.Ctx) (string, error) {
+		key := c.Cookies(name)
+		if key == "" {
+			return "", ErrMissingKey
+		}
+		return key, nil
+	}
+}
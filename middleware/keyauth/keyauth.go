@@ -0,0 +1,78 @@
+package keyauth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+var (
+	// ErrMissingKey is returned if the request carries no key
+	ErrMissingKey = errors.New("missing API key")
+	// ErrMalformedKey is returned if the key could not be extracted
+	ErrMalformedKey = errors.New("malformed API key")
+	// ErrInvalidKey is returned if the key was rejected by the Validator
+	ErrInvalidKey = errors.New("invalid API key")
+)
+
+// New creates a new middleware handler
+func New(config ...Config) fiber.Handler {
+	// Init config
+	cfg := configDefault(config...)
+
+	// Return new handler
+	return func(c *fiber.Ctx) error {
+		// Don't execute middleware if Next returns true
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		// Extract and verify key
+		key, err := cfg.Extractor(c)
+		if err != nil {
+			return cfg.ErrorHandler(c, err)
+		}
+
+		valid, err := cfg.Validator(c, key)
+		if err != nil {
+			return cfg.ErrorHandler(c, err)
+		}
+		if !valid {
+			return cfg.ErrorHandler(c, ErrInvalidKey)
+		}
+
+		c.Locals(cfg.ContextKey, key)
+		return cfg.SuccessHandler(c)
+	}
+}
+
+// HashKey returns the hex encoded SHA-256 hash of the key as expected by Config.HashedKeys
+func HashKey(key string) string {
+	sum := sha256.Sum256(utils.UnsafeBytes(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// hashedKeysValidator compares the hash of the key with all hashes in constant time
+func hashedKeysValidator(hashes []string) func(c *fiber.Ctx, key string) (bool, error) {
+	sums := make([][]byte, 0, len(hashes))
+	for _, h := range hashes {
+		sum, err := hex.DecodeString(h)
+		if err != nil || len(sum) != sha256.Size {
+			panic("[KEYAUTH] HashedKeys must be hex encoded SHA-256 hashes")
+		}
+		sums = append(sums, sum)
+	}
+
+	return func(_ *fiber.Ctx, key string) (bool, error) {
+		sum := sha256.Sum256(utils.UnsafeBytes(key))
+		match := 0
+		for _, s := range sums {
+			match |= subtle.ConstantTimeCompare(sum[:], s)
+		}
+		return match == 1, nil
+	}
+}
@@ -0,0 +1,215 @@
+package keyauth
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+const testKey = "my-secret-key"
+
+func validateTestKey(_ *fiber.Ctx, key string) (bool, error) {
+	return key == testKey, nil
+}
+
+func newTestApp(config Config) *fiber.App {
+	app := fiber.New()
+	app.Use(New(config))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString(c.Locals("token").(string))
+	})
+	app.Post("/", func(c *fiber.Ctx) error {
+		return c.SendString(c.Locals("token").(string))
+	})
+	return app
+}
+
+// go test -run Test_KeyAuth_Header
+func Test_KeyAuth_Header(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(Config{Validator: validateTestKey})
+
+	tests := []struct {
+		auth      string
+		status    int
+		challenge string
+	}{
+		{"Bearer " + testKey, fiber.StatusOK, ""},
+		{"bearer " + testKey, fiber.StatusOK, ""},
+		{"", fiber.StatusUnauthorized, `Bearer realm="Restricted"`},
+		{"Basic dXNlcjpwYXNz", fiber.StatusUnauthorized, `Bearer realm="Restricted"`},
+		{"Bearer ", fiber.StatusBadRequest, `Bearer realm="Restricted", error="invalid_request", error_description="malformed API key"`},
+		{"Bearer wrong", fiber.StatusUnauthorized, `Bearer realm="Restricted", error="invalid_token", error_description="invalid API key"`},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+		if tt.auth != "" {
+			req.Header.Set(fiber.HeaderAuthorization, tt.auth)
+		}
+		resp, err := app.Test(req)
+		utils.AssertEqual(t, nil, err)
+		utils.AssertEqual(t, tt.status, resp.StatusCode, tt.auth)
+		utils.AssertEqual(t, tt.challenge, resp.Header.Get(fiber.HeaderWWWAuthenticate), tt.auth)
+
+		if tt.status == fiber.StatusOK {
+			body, err := io.ReadAll(resp.Body)
+			utils.AssertEqual(t, nil, err)
+			utils.AssertEqual(t, testKey, string(body))
+		}
+	}
+}
+
+// go test -run Test_KeyAuth_Lookup
+func Test_KeyAuth_Lookup(t *testing.T) {
+	t.Parallel()
+
+	t.Run("query", func(t *testing.T) {
+		app := newTestApp(Config{KeyLookup: "query:api_key", Validator: validateTestKey})
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/?api_key="+testKey, nil))
+		utils.AssertEqual(t, nil, err)
+		utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("header", func(t *testing.T) {
+		app := newTestApp(Config{KeyLookup: "header:x-api-key", Validator: validateTestKey})
+		req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+		req.Header.Set("X-Api-Key", testKey)
+		resp, err := app.Test(req)
+		utils.AssertEqual(t, nil, err)
+		utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("cookie", func(t *testing.T) {
+		app := newTestApp(Config{KeyLookup: "cookie:api_key", Validator: validateTestKey})
+		req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+		req.Header.Set(fiber.HeaderCookie, "api_key="+testKey)
+		resp, err := app.Test(req)
+		utils.AssertEqual(t, nil, err)
+		utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("form", func(t *testing.T) {
+		app := newTestApp(Config{KeyLookup: "form:api_key", Validator: validateTestKey})
+		form := url.Values{"api_key": {testKey}}
+		req := httptest.NewRequest(fiber.MethodPost, "/", strings.NewReader(form.Encode()))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationForm)
+		resp, err := app.Test(req)
+		utils.AssertEqual(t, nil, err)
+		utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("param", func(t *testing.T) {
+		app := fiber.New()
+		app.Get("/:key", New(Config{KeyLookup: "param:key", Validator: validateTestKey}), func(c *fiber.Ctx) error {
+			return c.SendStatus(fiber.StatusNoContent)
+		})
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/"+testKey, nil))
+		utils.AssertEqual(t, nil, err)
+		utils.AssertEqual(t, fiber.StatusNoContent, resp.StatusCode)
+	})
+}
+
+// go test -run Test_KeyAuth_HashedKeys
+func Test_KeyAuth_HashedKeys(t *testing.T) {
+	t.Parallel()
+
+	utils.AssertEqual(t, "1311f8fc80a7ea28d78dd7723f09c44c1754cd35160ca8e7133ae3d7f636a19a", HashKey("my-secret-key"))
+
+	app := newTestApp(Config{HashedKeys: []string{HashKey("other"), HashKey(testKey)}})
+
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer "+testKey)
+	resp, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
+
+	req = httptest.NewRequest(fiber.MethodGet, "/", nil)
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer "+HashKey(testKey))
+	resp, err = app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusUnauthorized, resp.StatusCode)
+}
+
+// go test -run Test_KeyAuth_Validator_Error
+func Test_KeyAuth_Validator_Error(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(Config{
+		Validator: func(c *fiber.Ctx, key string) (bool, error) {
+			return false, errors.New("key store unavailable")
+		},
+	})
+
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer "+testKey)
+	resp, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusInternalServerError, resp.StatusCode)
+}
+
+// go test -run Test_KeyAuth_Custom_Handlers
+func Test_KeyAuth_Custom_Handlers(t *testing.T) {
+	t.Parallel()
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Validator: validateTestKey,
+		SuccessHandler: func(c *fiber.Ctx) error {
+			return c.SendString("welcome")
+		},
+		ErrorHandler: func(c *fiber.Ctx, err error) error {
+			return c.Status(fiber.StatusForbidden).SendString(err.Error())
+		},
+	}))
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusForbidden, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "missing API key", string(body))
+
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer "+testKey)
+	resp, err = app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
+	body, err = io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "welcome", string(body))
+}
+
+// go test -run Test_KeyAuth_Next
+func Test_KeyAuth_Next(t *testing.T) {
+	t.Parallel()
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Validator: validateTestKey,
+		Next: func(_ *fiber.Ctx) bool {
+			return true
+		},
+	}))
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusNotFound, resp.StatusCode)
+}
+
+// go test -run Test_KeyAuth_Missing_Validator
+func Test_KeyAuth_Missing_Validator(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		utils.AssertEqual(t, "[KEYAUTH] Validator or HashedKeys is required", recover())
+	}()
+	New()
+}
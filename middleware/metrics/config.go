@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// Config defines the config for middleware.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	//
+	// Optional. Default: nil
+	Next func(c *fiber.Ctx) bool
+
+	// Path is the path the metrics are exposed on
+	//
+	// Optional. Default: "/metrics"
+	Path string
+
+	// Namespace is prepended to the name of every request metric,
+	// i.e. "myapp" exposes "myapp_http_requests_total".
+	//
+	// Optional. Default: ""
+	Namespace string
+
+	// Buckets defines the upper bounds of the latency histogram in seconds
+	//
+	// Optional. Default: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	Buckets []float64
+
+	// DisableProcessMetrics disables the process and OS statistics
+	// also reported by the monitor middleware.
+	//
+	// Optional. Default: false
+	DisableProcessMetrics bool
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	Next:                  nil,
+	Path:                  "/metrics",
+	Namespace:             "",
+	Buckets:               []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	DisableProcessMetrics: false,
+}
+
+// Helper function to set default values
+func configDefault(config ...Config) Config {
+	// Return default config if nothing provided
+	if len(config) < 1 {
+		return ConfigDefault
+	}
+
+	// Override default config
+	cfg := config[0]
+
+	// Set default values
+	if cfg.Path == "" {
+		cfg.Path = ConfigDefault.Path
+	}
+	if len(cfg.Buckets) == 0 {
+		cfg.Buckets = ConfigDefault.Buckets
+	}
+	return cfg
+}
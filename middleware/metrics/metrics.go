@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/internal/bytebufferpool"
+	"github.com/gofiber/fiber/v2/internal/gopsutil/process"
+)
+
+// contentType is the content type of the text exposition format
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// New creates a new middleware handler
+func New(config ...Config) fiber.Handler {
+	// Set default config
+	cfg := configDefault(config...)
+
+	buckets := append([]float64(nil), cfg.Buckets...)
+	sort.Float64s(buckets)
+
+	prefix := ""
+	if cfg.Namespace != "" {
+		prefix = cfg.Namespace + "_"
+	}
+
+	var (
+		requests = newFamily(prefix+"http_requests_total",
+			"Total number of HTTP requests.", kindCounter, nil, "method", "route", "status")
+		latency = newFamily(prefix+"http_request_duration_seconds",
+			"Duration of HTTP requests in seconds.", kindHistogram, buckets, "method", "route", "status")
+		inFlight = newFamily(prefix+"http_requests_in_flight",
+			"Number of HTTP requests currently being served.", kindGauge, nil, "method")
+
+		proc *process.Process
+	)
+
+	if !cfg.DisableProcessMetrics {
+		proc = currentProcess()
+	}
+
+	// Return new handler
+	return func(c *fiber.Ctx) error {
+		// Don't execute middleware if Next returns true
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		// Expose the metrics
+		if c.Path() == cfg.Path {
+			if c.Method() != fiber.MethodGet {
+				return fiber.ErrMethodNotAllowed
+			}
+			buf := bytebufferpool.Get()
+			defer bytebufferpool.Put(buf)
+
+			requests.write(buf)
+			latency.write(buf)
+			inFlight.write(buf)
+			if !cfg.DisableProcessMetrics {
+				writeProcessMetrics(buf, proc)
+			}
+
+			c.Set(fiber.HeaderContentType, contentType)
+			return c.Status(fiber.StatusOK).Send(buf.Bytes())
+		}
+
+		// The method may be overridden while handling the request
+		method := c.Method()
+
+		inFlight.add(1, method)
+		defer inFlight.add(-1, method)
+		start := time.Now()
+
+		// Handle request, the status is only known after the error handler ran
+		chainErr := c.Next()
+		if chainErr != nil {
+			if err := c.App().ErrorHandler(c, chainErr); err != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		elapsed := time.Since(start).Seconds()
+
+		route := c.Route().Path
+		status := strconv.Itoa(c.Response().StatusCode())
+		requests.add(1, method, route, status)
+		latency.observe(elapsed, method, route, status)
+
+		return nil
+	}
+}
@@ -0,0 +1,171 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/valyala/fasthttp"
+)
+
+func scrape(t *testing.T, app *fiber.App, path string) string {
+	t.Helper()
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, path, nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
+	utils.AssertEqual(t, contentType, resp.Header.Get(fiber.HeaderContentType))
+	b, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	return string(b)
+}
+
+// go test -run Test_Metrics
+func Test_Metrics(t *testing.T) {
+	t.Parallel()
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Buckets:               []float64{1, 0.5},
+		DisableProcessMetrics: true,
+	}))
+	app.Get("/users/:id", func(c *fiber.Ctx) error {
+		return c.SendString(c.Params("id"))
+	})
+	app.Post("/users", func(c *fiber.Ctx) error {
+		return fiber.ErrConflict
+	})
+	app.Get("/fail", func(c *fiber.Ctx) error {
+		return errors.New("boom")
+	})
+
+	for _, id := range []string{"1", "2"} {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/users/"+id, nil))
+		utils.AssertEqual(t, nil, err)
+		utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
+	}
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodPost, "/users", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusConflict, resp.StatusCode)
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodGet, "/fail", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusInternalServerError, resp.StatusCode)
+
+	body := scrape(t, app, "/metrics")
+
+	utils.AssertEqual(t, true, strings.Contains(body, "# TYPE http_requests_total counter\n"))
+	utils.AssertEqual(t, true, strings.Contains(body, `http_requests_total{method="GET",route="/users/:id",status="200"} 2`+"\n"))
+	utils.AssertEqual(t, true, strings.Contains(body, `http_requests_total{method="POST",route="/users",status="409"} 1`+"\n"))
+	utils.AssertEqual(t, true, strings.Contains(body, `http_requests_total{method="GET",route="/fail",status="500"} 1`+"\n"))
+
+	utils.AssertEqual(t, true, strings.Contains(body, "# TYPE http_request_duration_seconds histogram\n"))
+	utils.AssertEqual(t, true, strings.Contains(body, `http_request_duration_seconds_bucket{method="GET",route="/users/:id",status="200",le="0.5"} 2`+"\n"))
+	utils.AssertEqual(t, true, strings.Contains(body, `http_request_duration_seconds_bucket{method="GET",route="/users/:id",status="200",le="1"} 2`+"\n"))
+	utils.AssertEqual(t, true, strings.Contains(body, `http_request_duration_seconds_bucket{method="GET",route="/users/:id",status="200",le="+Inf"} 2`+"\n"))
+	utils.AssertEqual(t, true, strings.Contains(body, `http_request_duration_seconds_count{method="GET",route="/users/:id",status="200"} 2`+"\n"))
+
+	utils.AssertEqual(t, true, strings.Contains(body, `http_requests_in_flight{method="GET"} 0`+"\n"))
+	utils.AssertEqual(t, false, strings.Contains(body, "/users/1"))
+	utils.AssertEqual(t, false, strings.Contains(body, "process_"))
+}
+
+// go test -run Test_Metrics_Panic
+func Test_Metrics_Panic(t *testing.T) {
+	t.Parallel()
+
+	app := fiber.New()
+	app.Use(recover.New())
+	app.Use(New(Config{DisableProcessMetrics: true}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		panic("boom")
+	})
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusInternalServerError, resp.StatusCode)
+
+	body := scrape(t, app, "/metrics")
+	utils.AssertEqual(t, true, strings.Contains(body, `http_requests_in_flight{method="GET"} 0`+"\n"))
+}
+
+// go test -run Test_Metrics_Process
+func Test_Metrics_Process(t *testing.T) {
+	t.Parallel()
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Path:      "/prometheus",
+		Namespace: "myapp",
+	}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusNoContent, resp.StatusCode)
+
+	body := scrape(t, app, "/prometheus")
+	utils.AssertEqual(t, true, strings.Contains(body, `myapp_http_requests_total{method="GET",route="/",status="204"} 1`+"\n"))
+	utils.AssertEqual(t, true, strings.Contains(body, "# TYPE go_goroutines gauge\n"))
+}
+
+// go test -run Test_Metrics_Method_Not_Allowed
+func Test_Metrics_Method_Not_Allowed(t *testing.T) {
+	t.Parallel()
+
+	app := fiber.New()
+	app.Use(New())
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodPost, "/metrics", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+// go test -run Test_Metrics_Next
+func Test_Metrics_Next(t *testing.T) {
+	t.Parallel()
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Next: func(_ *fiber.Ctx) bool {
+			return true
+		},
+	}))
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/metrics", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusNotFound, resp.StatusCode)
+}
+
+// go test -run Test_Metrics_Escape_Label
+func Test_Metrics_Escape_Label(t *testing.T) {
+	t.Parallel()
+
+	utils.AssertEqual(t, `a\"b\\c\nd`, escapeLabel("a\"b\\c\nd"))
+}
+
+// go test -v -run=^$ -bench=Benchmark_Metrics -benchmem -count=4
+func Benchmark_Metrics(b *testing.B) {
+	app := fiber.New()
+	app.Use(New(Config{DisableProcessMetrics: true}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return nil
+	})
+	h := app.Handler()
+
+	fctx := &fasthttp.RequestCtx{}
+	fctx.Request.Header.SetMethod(fiber.MethodGet)
+	fctx.Request.SetRequestURI("/")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		h(fctx)
+	}
+}
@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"os"
+	"runtime"
+
+	"github.com/gofiber/fiber/v2/internal/bytebufferpool"
+	"github.com/gofiber/fiber/v2/internal/gopsutil/cpu"
+	"github.com/gofiber/fiber/v2/internal/gopsutil/load"
+	"github.com/gofiber/fiber/v2/internal/gopsutil/mem"
+	"github.com/gofiber/fiber/v2/internal/gopsutil/net"
+	"github.com/gofiber/fiber/v2/internal/gopsutil/process"
+)
+
+// writeProcessMetrics appends the statistics of the monitor middleware
+// to buf. Statistics that are not available on the platform are skipped.
+func writeProcessMetrics(buf *bytebufferpool.ByteBuffer, p *process.Process) {
+	metric := func(name, help, kind string, v float64) {
+		writeHeader(buf, name, help, kind)
+		writeSample(buf, name, nil, nil, "", "", v)
+	}
+
+	if p != nil {
+		if times, _ := p.Times(); times != nil {
+			metric("process_cpu_seconds_total", "Total user and system CPU time spent in seconds.", kindCounter, times.User+times.System)
+		}
+		if pidMem, _ := p.MemoryInfo(); pidMem != nil {
+			metric("process_resident_memory_bytes", "Resident memory size in bytes.", kindGauge, float64(pidMem.RSS))
+		}
+		if pidConns, err := net.ConnectionsPid("tcp", p.Pid); err == nil {
+			metric("process_open_connections", "Number of open TCP connections of the process.", kindGauge, float64(len(pidConns)))
+		}
+	}
+
+	metric("go_goroutines", "Number of goroutines that currently exist.", kindGauge, float64(runtime.NumGoroutine()))
+
+	if osCPU, _ := cpu.Percent(0, false); len(osCPU) > 0 {
+		metric("os_cpu_usage_percent", "CPU usage of the system in percent since the last scrape.", kindGauge, osCPU[0])
+	}
+	if osMem, _ := mem.VirtualMemory(); osMem != nil {
+		metric("os_memory_used_bytes", "Used memory of the system in bytes.", kindGauge, float64(osMem.Used))
+		metric("os_memory_total_bytes", "Total memory of the system in bytes.", kindGauge, float64(osMem.Total))
+	}
+	if loadAvg, _ := load.Avg(); loadAvg != nil {
+		metric("os_load1", "1m load average of the system.", kindGauge, loadAvg.Load1)
+	}
+	if osConns, err := net.Connections("tcp"); err == nil {
+		metric("os_open_connections", "Number of open TCP connections of the system.", kindGauge, float64(len(osConns)))
+	}
+}
+
+// currentProcess returns the process of the application
+func currentProcess() *process.Process {
+	p, err := process.NewProcess(int32(os.Getpid()))
+	if err != nil {
+		return nil
+	}
+	return p
+}
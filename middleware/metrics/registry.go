@@ -0,0 +1,161 @@
+package metrics
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2/internal/bytebufferpool"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// series holds the values of a metric for one set of label values
+type series struct {
+	labels  []string
+	value   float64
+	buckets []uint64
+	count   uint64
+	sum     float64
+}
+
+// family is a metric with a fixed set of label names
+type family struct {
+	name    string
+	help    string
+	kind    string
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+func newFamily(name, help, kind string, buckets []float64, labels ...string) *family {
+	return &family{
+		name:    name,
+		help:    help,
+		kind:    kind,
+		labels:  labels,
+		buckets: buckets,
+		series:  make(map[string]*series),
+	}
+}
+
+// get returns the series of the label values. The caller must hold the lock.
+func (f *family) get(values []string) *series {
+	key := strings.Join(values, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		// The values may point into the request buffer
+		labels := make([]string, len(values))
+		for i := range values {
+			labels[i] = utils.CopyString(values[i])
+		}
+		s = &series{labels: labels}
+		if f.kind == kindHistogram {
+			s.buckets = make([]uint64, len(f.buckets))
+		}
+		f.series[utils.CopyString(key)] = s
+	}
+	return s
+}
+
+// add adds v to a counter or gauge
+func (f *family) add(v float64, values ...string) {
+	f.mu.Lock()
+	f.get(values).value += v
+	f.mu.Unlock()
+}
+
+// observe records v in a histogram
+func (f *family) observe(v float64, values ...string) {
+	f.mu.Lock()
+	s := f.get(values)
+	for i, le := range f.buckets {
+		if v <= le {
+			s.buckets[i]++
+		}
+	}
+	s.count++
+	s.sum += v
+	f.mu.Unlock()
+}
+
+// Metric types of the text exposition format
+const (
+	kindCounter   = "counter"
+	kindGauge     = "gauge"
+	kindHistogram = "histogram"
+)
+
+// write appends the family in the text exposition format to buf
+func (f *family) write(buf *bytebufferpool.ByteBuffer) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.series) == 0 {
+		return
+	}
+	writeHeader(buf, f.name, f.help, f.kind)
+
+	keys := make([]string, 0, len(f.series))
+	for k := range f.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		s := f.series[k]
+		if f.kind != kindHistogram {
+			writeSample(buf, f.name, f.labels, s.labels, "", "", s.value)
+			continue
+		}
+		for i, le := range f.buckets {
+			writeSample(buf, f.name+"_bucket", f.labels, s.labels, "le", formatFloat(le), float64(s.buckets[i]))
+		}
+		writeSample(buf, f.name+"_bucket", f.labels, s.labels, "le", "+Inf", float64(s.count))
+		writeSample(buf, f.name+"_sum", f.labels, s.labels, "", "", s.sum)
+		writeSample(buf, f.name+"_count", f.labels, s.labels, "", "", float64(s.count))
+	}
+}
+
+// writeHeader appends the HELP and TYPE lines of a metric
+func writeHeader(buf *bytebufferpool.ByteBuffer, name, help, kind string) {
+	_, _ = buf.WriteString("# HELP " + name + " " + help + "\n")
+	_, _ = buf.WriteString("# TYPE " + name + " " + kind + "\n")
+}
+
+// writeSample appends a single sample line, extra is an additional label
+func writeSample(buf *bytebufferpool.ByteBuffer, name string, names, values []string, extra, extraValue string, v float64) {
+	_, _ = buf.WriteString(name)
+	if len(names) > 0 || extra != "" {
+		_ = buf.WriteByte('{')
+		for i := range names {
+			if i > 0 {
+				_ = buf.WriteByte(',')
+			}
+			_, _ = buf.WriteString(names[i] + `="` + escapeLabel(values[i]) + `"`)
+		}
+		if extra != "" {
+			if len(names) > 0 {
+				_ = buf.WriteByte(',')
+			}
+			_, _ = buf.WriteString(extra + `="` + extraValue + `"`)
+		}
+		_ = buf.WriteByte('}')
+	}
+	_, _ = buf.WriteString(" " + formatFloat(v) + "\n")
+}
+
+var labelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabel escapes a label value as required by the text exposition format
+func escapeLabel(v string) string {
+	return labelReplacer.Replace(v)
+}
+
+// formatFloat formats a sample value
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,212 @@
+package proxy
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// CircuitState is the state of the circuit breaker of an upstream server
+type CircuitState int
+
+// Circuit breaker states
+const (
+	// StateClosed lets all requests through
+	StateClosed CircuitState = iota
+	// StateOpen rejects all requests with ErrCircuitOpen
+	StateOpen
+	// StateHalfOpen lets a limited number of probe requests through
+	StateHalfOpen
+)
+
+// String returns the name of the state
+func (s CircuitState) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// ErrCircuitOpen is returned when a request is rejected by an open circuit
+var ErrCircuitOpen = errors.New("proxy: circuit open")
+
+// openPenalty is added to the pending requests of an upstream whose circuit
+// rejects requests, so the load balancer prefers upstreams that accept them.
+const openPenalty = 1 << 16
+
+// breaker is a consecutive failure circuit breaker
+type breaker struct {
+	mu sync.Mutex
+
+	threshold int
+	timeout   time.Duration
+	probes    int
+
+	state     CircuitState
+	failures  int
+	successes int
+	inflight  int
+	openedAt  time.Time
+}
+
+// current returns the state, moving an expired open circuit to half-open.
+// The caller must hold the lock.
+func (b *breaker) current() CircuitState {
+	if b.state == StateOpen && time.Since(b.openedAt) >= b.timeout {
+		b.state = StateHalfOpen
+		b.successes = 0
+		b.inflight = 0
+	}
+	return b.state
+}
+
+// State returns the current state of the circuit
+func (b *breaker) State() CircuitState {
+	if b.threshold <= 0 {
+		return StateClosed
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.current()
+}
+
+// allow reports whether a request may be sent to the upstream
+func (b *breaker) allow() bool {
+	if b.threshold <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.current() {
+	case StateOpen:
+		return false
+	case StateHalfOpen:
+		if b.inflight >= b.probes {
+			return false
+		}
+		b.inflight++
+	}
+	return true
+}
+
+// rejects reports whether allow would reject a request, i.e. the circuit is
+// open or half-open with all probes in flight
+func (b *breaker) rejects() bool {
+	if b.threshold <= 0 {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.current() {
+	case StateOpen:
+		return true
+	case StateHalfOpen:
+		return b.inflight >= b.probes
+	}
+	return false
+}
+
+// done records the outcome of a request that was allowed through
+func (b *breaker) done(success bool) {
+	if b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case StateClosed:
+		if success {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.threshold {
+			b.open()
+		}
+	case StateHalfOpen:
+		if !success {
+			b.open()
+			return
+		}
+		b.successes++
+		if b.successes >= b.probes {
+			b.state = StateClosed
+			b.failures = 0
+		}
+	}
+}
+
+// open trips the circuit. The caller must hold the lock.
+func (b *breaker) open() {
+	b.state = StateOpen
+	b.openedAt = time.Now()
+	b.failures = 0
+}
+
+// upstream is a balancing client guarded by a circuit breaker
+type upstream struct {
+	addr    string
+	client  *fasthttp.HostClient
+	breaker *breaker
+}
+
+// DoDeadline implements fasthttp.BalancingClient
+func (u *upstream) DoDeadline(req *fasthttp.Request, resp *fasthttp.Response, deadline time.Time) error {
+	if !u.breaker.allow() {
+		return ErrCircuitOpen
+	}
+	err := u.client.DoDeadline(req, resp, deadline)
+	u.breaker.done(err == nil && resp.StatusCode() < fasthttp.StatusInternalServerError)
+	return err
+}
+
+// PendingRequests implements fasthttp.BalancingClient
+func (u *upstream) PendingRequests() int {
+	n := u.client.PendingRequests()
+	if u.breaker.rejects() {
+		n += openPenalty
+	}
+	return n
+}
+
+// retryBudget limits the number of retries to a ratio of the requests
+type retryBudget struct {
+	mu     sync.Mutex
+	ratio  float64
+	tokens float64
+}
+
+// retryBudgetReserve is the amount of retries that can be spent in a burst
+const retryBudgetReserve = 10
+
+func newRetryBudget(ratio float64) *retryBudget {
+	return &retryBudget{ratio: ratio, tokens: retryBudgetReserve}
+}
+
+// deposit adds the share of a request to the budget
+func (b *retryBudget) deposit() {
+	b.mu.Lock()
+	b.tokens += b.ratio
+	if b.tokens > retryBudgetReserve {
+		b.tokens = retryBudgetReserve
+	}
+	b.mu.Unlock()
+}
+
+// withdraw takes a retry from the budget and reports whether it was available
+func (b *retryBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -54,6 +54,46 @@ type Config struct {
 	// Note that Servers, Timeout, WriteBufferSize, ReadBufferSize and TlsConfig
 	// will not be used if the client are set.
 	Client *fasthttp.LBClient
+
+	// Retries is the maximum number of additional attempts made for a request
+	// that failed with an error or one of RetryStatusCodes. Only idempotent
+	// methods and requests carrying an Idempotency-Key header are retried.
+	// Errors other than connection errors, e.g. timeouts, are only retried
+	// for idempotent methods.
+	//
+	// Optional. Default: 0
+	Retries int
+
+	// RetryStatusCodes defines the upstream response codes that trigger a retry
+	//
+	// Optional. Default: nil
+	RetryStatusCodes []int
+
+	// RetryBudget is the ratio of retries to requests the balancer may spend.
+	// Every request adds RetryBudget to the budget and every retry takes one,
+	// so retries cannot amplify the load on an unhealthy upstream.
+	//
+	// Optional. Default: 0.2
+	RetryBudget float64
+
+	// BreakerThreshold is the number of consecutive failures (connection errors
+	// or 5xx responses) after which the circuit of an upstream opens.
+	// The circuit breaker is disabled when the value is 0.
+	//
+	// Optional. Default: 0
+	BreakerThreshold int
+
+	// BreakerTimeout is how long an open circuit rejects requests
+	// before it lets probe requests through in half-open state.
+	//
+	// Optional. Default: 10 seconds
+	BreakerTimeout time.Duration
+
+	// BreakerProbes is the number of successful probe requests needed
+	// to close a half-open circuit.
+	//
+	// Optional. Default: 1
+	BreakerProbes int
 }
 
 // ConfigDefault is the default config
@@ -62,6 +102,9 @@ var ConfigDefault = Config{
 	ModifyRequest:  nil,
 	ModifyResponse: nil,
 	Timeout:        fasthttp.DefaultLBClientTimeout,
+	RetryBudget:    0.2,
+	BreakerTimeout: 10 * time.Second,
+	BreakerProbes:  1,
 }
 
 // configDefault function to set default values
@@ -78,6 +121,15 @@ func configDefault(config ...Config) Config {
 	if cfg.Timeout <= 0 {
 		cfg.Timeout = ConfigDefault.Timeout
 	}
+	if cfg.RetryBudget <= 0 {
+		cfg.RetryBudget = ConfigDefault.RetryBudget
+	}
+	if cfg.BreakerTimeout <= 0 {
+		cfg.BreakerTimeout = ConfigDefault.BreakerTimeout
+	}
+	if cfg.BreakerProbes <= 0 {
+		cfg.BreakerProbes = ConfigDefault.BreakerProbes
+	}
 
 	// Set default values
 	if len(cfg.Servers) == 0 && cfg.Client == nil {
@@ -3,7 +3,9 @@ package proxy
 import (
 	"bytes"
 	"crypto/tls"
+	"errors"
 	"fmt"
+	"net"
 	"net/url"
 	"strings"
 	"sync"
@@ -21,16 +23,39 @@ func New(config Config) fiber.Handler {
 
 // Balancer creates a load balancer among multiple upstream servers
 func Balancer(config Config) fiber.Handler {
+	return NewBalancer(config).Handler
+}
+
+// LoadBalancer proxies requests among multiple upstream servers,
+// retrying failed requests and guarding every upstream with a circuit breaker.
+type LoadBalancer struct {
+	cfg       Config
+	lbc       *fasthttp.LBClient
+	upstreams []*upstream
+	budget    *retryBudget
+	retryOn   map[int]struct{}
+}
+
+// NewBalancer creates a load balancer among multiple upstream servers
+func NewBalancer(config Config) *LoadBalancer {
 	// Set default config
 	cfg := configDefault(config)
 
-	// Load balanced client
-	var lbc = &fasthttp.LBClient{}
-	// Note that Servers, Timeout, WriteBufferSize, ReadBufferSize and TlsConfig
-	// will not be used if the client are set.
+	lb := &LoadBalancer{
+		cfg:     cfg,
+		lbc:     &fasthttp.LBClient{},
+		budget:  newRetryBudget(cfg.RetryBudget),
+		retryOn: make(map[int]struct{}, len(cfg.RetryStatusCodes)),
+	}
+	for _, code := range cfg.RetryStatusCodes {
+		lb.retryOn[code] = struct{}{}
+	}
+
+	// Note that Servers, Timeout, WriteBufferSize, ReadBufferSize, TlsConfig
+	// and the circuit breaker will not be used if the client are set.
 	if config.Client == nil {
 		// Set timeout
-		lbc.Timeout = cfg.Timeout
+		lb.lbc.Timeout = cfg.Timeout
 		// Scheme must be provided, falls back to http
 		for _, server := range cfg.Servers {
 			if !strings.HasPrefix(server, "http") {
@@ -53,54 +78,140 @@ func Balancer(config Config) fiber.Handler {
 				TLSConfig: config.TlsConfig,
 			}
 
-			lbc.Clients = append(lbc.Clients, client)
+			up := &upstream{
+				addr:   u.Host,
+				client: client,
+				breaker: &breaker{
+					threshold: cfg.BreakerThreshold,
+					timeout:   cfg.BreakerTimeout,
+					probes:    cfg.BreakerProbes,
+				},
+			}
+			lb.upstreams = append(lb.upstreams, up)
+			lb.lbc.Clients = append(lb.lbc.Clients, up)
 		}
 	} else {
 		// Set custom client
-		lbc = config.Client
+		lb.lbc = config.Client
+	}
+
+	return lb
+}
+
+// Handler proxies the request to one of the upstream servers
+func (lb *LoadBalancer) Handler(c *fiber.Ctx) (err error) {
+	// Don't execute middleware if Next returns true
+	if lb.cfg.Next != nil && lb.cfg.Next(c) {
+		return c.Next()
 	}
 
-	// Return new handler
-	return func(c *fiber.Ctx) (err error) {
-		// Don't execute middleware if Next returns true
-		if cfg.Next != nil && cfg.Next(c) {
-			return c.Next()
+	// Set request and response
+	req := c.Request()
+	res := c.Response()
+
+	// Don't proxy "Connection" header
+	req.Header.Del(fiber.HeaderConnection)
+
+	// Modify request
+	if lb.cfg.ModifyRequest != nil {
+		if err = lb.cfg.ModifyRequest(c); err != nil {
+			return err
 		}
+	}
 
-		// Set request and response
-		req := c.Request()
-		res := c.Response()
+	req.SetRequestURI(utils.UnsafeString(req.RequestURI()))
 
-		// Don't proxy "Connection" header
-		req.Header.Del(fiber.HeaderConnection)
+	// Propagate values of the user context, i.e. the trace context
+	fiber.PropagateContext(c.UserContext(), req)
 
-		// Modify request
-		if cfg.ModifyRequest != nil {
-			if err = cfg.ModifyRequest(c); err != nil {
-				return err
-			}
+	// Forward request, retrying if allowed
+	retry := lb.cfg.Retries > 0 && isRetryable(req)
+	if retry {
+		lb.budget.deposit()
+	}
+	for attempt, skipped := 0, 0; ; attempt++ {
+		err = lb.lbc.Do(req, res)
+		// A request rejected by an open circuit wasn't sent, so it goes to
+		// another upstream without counting as a retry
+		if errors.Is(err, ErrCircuitOpen) && skipped < len(lb.upstreams)-1 {
+			skipped++
+			attempt--
+			continue
+		}
+		if !retry || attempt >= lb.cfg.Retries || !lb.shouldRetry(req, res, err) || !lb.budget.withdraw() {
+			break
 		}
+	}
+	if err != nil {
+		if errors.Is(err, ErrCircuitOpen) {
+			return fiber.ErrServiceUnavailable
+		}
+		return err
+	}
 
-		req.SetRequestURI(utils.UnsafeString(req.RequestURI()))
+	// Don't proxy "Connection" header
+	res.Header.Del(fiber.HeaderConnection)
 
-		// Forward request
-		if err = lbc.Do(req, res); err != nil {
+	// Modify response
+	if lb.cfg.ModifyResponse != nil {
+		if err = lb.cfg.ModifyResponse(c); err != nil {
 			return err
 		}
+	}
 
-		// Don't proxy "Connection" header
-		res.Header.Del(fiber.HeaderConnection)
+	// Return nil to end proxying if no error
+	return nil
+}
 
-		// Modify response
-		if cfg.ModifyResponse != nil {
-			if err = cfg.ModifyResponse(c); err != nil {
-				return err
-			}
-		}
+// CircuitStates returns the circuit breaker state of every upstream server,
+// keyed by its host. The map is empty if a custom client is used.
+func (lb *LoadBalancer) CircuitStates() map[string]CircuitState {
+	states := make(map[string]CircuitState, len(lb.upstreams))
+	for _, up := range lb.upstreams {
+		states[up.addr] = up.breaker.State()
+	}
+	return states
+}
 
-		// Return nil to end proxying if no error
-		return nil
+// shouldRetry reports whether the outcome of an attempt warrants a retry.
+// Errors are retried if the request didn't reach the upstream, or for any
+// error if the method is idempotent.
+func (lb *LoadBalancer) shouldRetry(req *fasthttp.Request, res *fasthttp.Response, err error) bool {
+	if err != nil {
+		return isConnectionError(err) || isIdempotent(req)
+	}
+	_, ok := lb.retryOn[res.StatusCode()]
+	return ok
+}
+
+// isConnectionError reports whether err means that no connection to the
+// upstream was made or it was closed before the request was answered
+func isConnectionError(err error) bool {
+	if errors.Is(err, fasthttp.ErrConnectionClosed) || errors.Is(err, fasthttp.ErrDialTimeout) ||
+		errors.Is(err, fasthttp.ErrNoFreeConns) {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return opErr.Op == "dial"
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
+
+// isRetryable reports whether the request can safely be sent more than once
+func isRetryable(req *fasthttp.Request) bool {
+	return len(req.Header.Peek(fiber.HeaderIdempotencyKey)) > 0 || isIdempotent(req)
+}
+
+// isIdempotent reports whether the request method is idempotent
+func isIdempotent(req *fasthttp.Request) bool {
+	switch utils.UnsafeString(req.Header.Method()) {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions,
+		fiber.MethodTrace, fiber.MethodPut, fiber.MethodDelete:
+		return true
 	}
+	return false
 }
 
 var client = &fasthttp.Client{
@@ -161,6 +272,7 @@ func Do(c *fiber.Ctx, addr string, clients ...*fasthttp.Client) error {
 	}
 
 	req.Header.Del(fiber.HeaderConnection)
+	fiber.PropagateContext(c.UserContext(), req)
 	if err := cli.Do(req, res); err != nil {
 		return err
 	}
@@ -1,12 +1,14 @@
 package proxy
 
 import (
+	"context"
 	"crypto/tls"
 	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -474,3 +476,277 @@ func Test_ProxyBalancer_Custom_Client(t *testing.T) {
 	utils.AssertEqual(t, nil, err)
 	utils.AssertEqual(t, fiber.StatusTeapot, resp.StatusCode)
 }
+
+// go test -run Test_Proxy_Balancer_Retry_Status
+func Test_Proxy_Balancer_Retry_Status(t *testing.T) {
+	t.Parallel()
+
+	var calls int32
+	_, addr := createProxyTestServer(func(c *fiber.Ctx) error {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return c.SendStatus(fiber.StatusServiceUnavailable)
+		}
+		return c.SendString("retried")
+	}, t)
+
+	app := fiber.New()
+	app.Use(Balancer(Config{
+		Servers:          []string{addr},
+		Retries:          2,
+		RetryStatusCodes: []int{fiber.StatusServiceUnavailable},
+	}))
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
+	utils.AssertEqual(t, int32(2), atomic.LoadInt32(&calls))
+
+	b, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "retried", string(b))
+}
+
+// go test -run Test_Proxy_Balancer_Retry_Idempotency
+func Test_Proxy_Balancer_Retry_Idempotency(t *testing.T) {
+	t.Parallel()
+
+	var calls int32
+	target := fiber.New(fiber.Config{DisableStartupMessage: true})
+	target.Post("/", func(c *fiber.Ctx) error {
+		atomic.AddInt32(&calls, 1)
+		return c.SendStatus(fiber.StatusServiceUnavailable)
+	})
+
+	ln, err := net.Listen(fiber.NetworkTCP4, "127.0.0.1:0")
+	utils.AssertEqual(t, nil, err)
+	go func() { utils.AssertEqual(t, nil, target.Listener(ln)) }()
+	addr := ln.Addr().String()
+
+	app := fiber.New()
+	app.Use(Balancer(Config{
+		Servers:          []string{addr},
+		Retries:          1,
+		RetryStatusCodes: []int{fiber.StatusServiceUnavailable},
+	}))
+
+	// POST is not idempotent and must not be retried
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodPost, "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusServiceUnavailable, resp.StatusCode)
+	utils.AssertEqual(t, int32(1), atomic.LoadInt32(&calls))
+
+	// unless the client provides an idempotency key
+	req := httptest.NewRequest(fiber.MethodPost, "/", nil)
+	req.Header.Set(fiber.HeaderIdempotencyKey, "8e03978e-40d5-43e8-bc93-6894a57f9324")
+	resp, err = app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusServiceUnavailable, resp.StatusCode)
+	utils.AssertEqual(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+// go test -run Test_Proxy_Balancer_Retry_Errors
+func Test_Proxy_Balancer_Retry_Errors(t *testing.T) {
+	t.Parallel()
+
+	var calls int32
+	target := fiber.New(fiber.Config{DisableStartupMessage: true})
+	target.All("/", func(c *fiber.Ctx) error {
+		if atomic.AddInt32(&calls, 1) <= 2 {
+			time.Sleep(200 * time.Millisecond)
+		}
+		return c.SendString("done")
+	})
+
+	ln, err := net.Listen(fiber.NetworkTCP4, "127.0.0.1:0")
+	utils.AssertEqual(t, nil, err)
+	go func() { utils.AssertEqual(t, nil, target.Listener(ln)) }()
+	addr := ln.Addr().String()
+
+	// reserve an address nobody listens on
+	closed, err := net.Listen(fiber.NetworkTCP4, "127.0.0.1:0")
+	utils.AssertEqual(t, nil, err)
+	closedAddr := closed.Addr().String()
+	utils.AssertEqual(t, nil, closed.Close())
+
+	keyed := func(method string) *http.Request {
+		req := httptest.NewRequest(method, "/", nil)
+		req.Header.Set(fiber.HeaderIdempotencyKey, "8e03978e-40d5-43e8-bc93-6894a57f9324")
+		return req
+	}
+
+	// A POST that timed out may have been processed, so it isn't retried
+	app := fiber.New()
+	app.Use(Balancer(Config{Servers: []string{addr}, Retries: 1, Timeout: 100 * time.Millisecond}))
+	resp, err := app.Test(keyed(fiber.MethodPost))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusInternalServerError, resp.StatusCode)
+	utils.AssertEqual(t, int32(1), atomic.LoadInt32(&calls))
+
+	// while an idempotent request is
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
+	utils.AssertEqual(t, int32(3), atomic.LoadInt32(&calls))
+
+	// A POST that couldn't connect is retried on the next upstream
+	app = fiber.New()
+	app.Use(Balancer(Config{Servers: []string{closedAddr, addr}, Retries: 1, Timeout: time.Second}))
+	resp, err = app.Test(keyed(fiber.MethodPost))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
+	utils.AssertEqual(t, int32(4), atomic.LoadInt32(&calls))
+}
+
+// go test -run Test_Proxy_Balancer_Retry_Budget
+func Test_Proxy_Balancer_Retry_Budget(t *testing.T) {
+	t.Parallel()
+
+	budget := newRetryBudget(0.5)
+	for i := 0; i < retryBudgetReserve; i++ {
+		utils.AssertEqual(t, true, budget.withdraw())
+	}
+	utils.AssertEqual(t, false, budget.withdraw())
+
+	budget.deposit()
+	utils.AssertEqual(t, false, budget.withdraw())
+	budget.deposit()
+	utils.AssertEqual(t, true, budget.withdraw())
+}
+
+// go test -run Test_Proxy_Balancer_CircuitBreaker
+func Test_Proxy_Balancer_CircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	// reserve an address nobody listens on
+	ln, err := net.Listen(fiber.NetworkTCP4, "127.0.0.1:0")
+	utils.AssertEqual(t, nil, err)
+	addr := ln.Addr().String()
+	utils.AssertEqual(t, nil, ln.Close())
+
+	lb := NewBalancer(Config{
+		Servers:          []string{addr},
+		BreakerThreshold: 2,
+		BreakerTimeout:   100 * time.Millisecond,
+	})
+	app := fiber.New()
+	app.Use(lb.Handler)
+
+	utils.AssertEqual(t, StateClosed, lb.CircuitStates()[addr])
+
+	for i := 0; i < 2; i++ {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+		utils.AssertEqual(t, nil, err)
+		utils.AssertEqual(t, fiber.StatusInternalServerError, resp.StatusCode)
+	}
+	utils.AssertEqual(t, StateOpen, lb.CircuitStates()[addr])
+
+	// fail fast while the circuit is open
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusServiceUnavailable, resp.StatusCode)
+
+	time.Sleep(150 * time.Millisecond)
+	utils.AssertEqual(t, StateHalfOpen, lb.CircuitStates()[addr])
+
+	// a failed probe opens the circuit again
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusInternalServerError, resp.StatusCode)
+	utils.AssertEqual(t, StateOpen, lb.CircuitStates()[addr])
+}
+
+// go test -run Test_Proxy_Balancer_CircuitBreaker_Skip
+func Test_Proxy_Balancer_CircuitBreaker_Skip(t *testing.T) {
+	t.Parallel()
+
+	var calls int32
+	_, addr := createProxyTestServer(func(c *fiber.Ctx) error {
+		atomic.AddInt32(&calls, 1)
+		return c.SendString("accepted")
+	}, t)
+
+	lb := NewBalancer(Config{
+		Servers:          []string{"127.0.0.1:1", addr},
+		BreakerThreshold: 1,
+		BreakerTimeout:   time.Hour,
+	})
+	app := fiber.New()
+	app.Use(lb.Handler)
+
+	// The circuit of the first upstream is half-open with all probes in
+	// flight, so it is penalized like an open one
+	first := lb.upstreams[0].breaker
+	first.state = StateHalfOpen
+	first.inflight = first.probes
+	utils.AssertEqual(t, true, lb.upstreams[0].PendingRequests() >= openPenalty)
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
+	utils.AssertEqual(t, int32(1), atomic.LoadInt32(&calls))
+
+	// No upstream accepts the request. Retries are disabled, but rejected
+	// requests weren't sent and go to the next upstream.
+	lb.upstreams[1].breaker.open()
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusServiceUnavailable, resp.StatusCode)
+	utils.AssertEqual(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+// go test -run Test_Proxy_Breaker_HalfOpen_Close
+func Test_Proxy_Breaker_HalfOpen_Close(t *testing.T) {
+	t.Parallel()
+
+	b := &breaker{threshold: 1, timeout: time.Millisecond, probes: 2}
+	utils.AssertEqual(t, true, b.allow())
+	b.done(false)
+	utils.AssertEqual(t, StateOpen, b.State())
+	utils.AssertEqual(t, false, b.allow())
+
+	time.Sleep(5 * time.Millisecond)
+	utils.AssertEqual(t, "half-open", b.State().String())
+
+	// only the configured number of probes is let through
+	utils.AssertEqual(t, true, b.allow())
+	utils.AssertEqual(t, true, b.allow())
+	utils.AssertEqual(t, false, b.allow())
+
+	b.done(true)
+	utils.AssertEqual(t, StateHalfOpen, b.State())
+	b.done(true)
+	utils.AssertEqual(t, StateClosed, b.State())
+}
+
+type propagationKey struct{}
+
+// go test -run Test_Proxy_Balancer_Propagate_Context
+func Test_Proxy_Balancer_Propagate_Context(t *testing.T) {
+	t.Parallel()
+
+	unregister := fiber.RegisterContextPropagator(func(ctx context.Context, req *fiber.Request) {
+		if v, ok := ctx.Value(propagationKey{}).(string); ok {
+			req.Header.Set("X-Propagated", v)
+		}
+	})
+	defer unregister()
+
+	_, addr := createProxyTestServer(func(c *fiber.Ctx) error {
+		return c.SendString(c.Get("X-Propagated"))
+	}, t)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.SetUserContext(context.WithValue(c.UserContext(), propagationKey{}, "from-context"))
+		return c.Next()
+	})
+	app.Use(Balancer(Config{Servers: []string{addr}}))
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
+
+	b, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "from-context", string(b))
+}
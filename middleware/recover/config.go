@@ -20,13 +20,27 @@ type Config struct {
 	//
 	// Optional. Default: defaultStackTraceHandler
 	StackTraceHandler func(c *fiber.Ctx, e interface{})
+
+	// Reporter is called with every recovered panic before it is passed to
+	// the ErrorHandler, e.g. to send it to an error tracking service.
+	//
+	// Optional. Default: nil
+	Reporter func(c *fiber.Ctx, err *PanicError)
+
+	// RequestIDContextKey is the key of the request ID in the locals, as set
+	// by the requestid middleware.
+	//
+	// Optional. Default: "requestid"
+	RequestIDContextKey string
 }
 
 // ConfigDefault is the default config
 var ConfigDefault = Config{
-	Next:              nil,
-	EnableStackTrace:  false,
-	StackTraceHandler: defaultStackTraceHandler,
+	Next:                nil,
+	EnableStackTrace:    false,
+	StackTraceHandler:   defaultStackTraceHandler,
+	Reporter:            nil,
+	RequestIDContextKey: "requestid",
 }
 
 // Helper function to set default values
@@ -43,5 +57,9 @@ func configDefault(config ...Config) Config {
 		cfg.StackTraceHandler = defaultStackTraceHandler
 	}
 
+	if cfg.RequestIDContextKey == "" {
+		cfg.RequestIDContextKey = ConfigDefault.RequestIDContextKey
+	}
+
 	return cfg
 }
@@ -0,0 +1,115 @@
+package recover
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// Frame is a single frame of the stack of a panic
+type Frame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// String returns the frame in the format of a Go stack trace
+func (f Frame) String() string {
+	return f.Function + "\n\t" + f.File + ":" + strconv.Itoa(f.Line)
+}
+
+// PanicError is the error passed to the ErrorHandler when a panic is recovered.
+// It wraps the recovered value together with the stack of the panic and the
+// request it occurred in.
+type PanicError struct {
+	// Value is the value passed to panic
+	Value interface{} `json:"-"`
+
+	// Stack holds the frames of the panicking goroutine, starting at the
+	// function that called panic
+	Stack []Frame `json:"stack"`
+
+	// Method, Path and Route of the request
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Route  string `json:"route"`
+
+	// RequestID is the ID of the request, if the requestid middleware is used
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// Error returns the formatted panic value
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%v", e.Value)
+}
+
+// Unwrap returns the panic value if it is an error
+func (e *PanicError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// StackTrace returns the stack in the format of a Go stack trace
+func (e *PanicError) StackTrace() string {
+	var b strings.Builder
+	for _, frame := range e.Stack {
+		b.WriteString(frame.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// newPanicError creates a PanicError for a value recovered in the calling
+// deferred function
+func newPanicError(c *fiber.Ctx, value interface{}, requestIDKey string) *PanicError {
+	e := &PanicError{
+		Value:  value,
+		Stack:  callers(),
+		Method: utils.CopyString(c.Method()),
+		Path:   utils.CopyString(c.Path()),
+	}
+	if route := c.Route(); route != nil {
+		e.Route = route.Path
+	}
+	if rid, ok := c.Locals(requestIDKey).(string); ok {
+		e.RequestID = rid
+	} else {
+		e.RequestID = string(c.Response().Header.Peek(fiber.HeaderXRequestID))
+	}
+	return e
+}
+
+// callers returns the stack of the panicking goroutine, skipping the frames
+// of the runtime and of the recover middleware
+func callers() []Frame {
+	pc := make([]uintptr, 64)
+	pc = pc[:runtime.Callers(3, pc)]
+
+	var (
+		stack    []Frame
+		frames   = runtime.CallersFrames(pc)
+		panicked = false
+	)
+	for {
+		frame, more := frames.Next()
+		if panicked {
+			stack = append(stack, Frame{
+				Function: frame.Function,
+				File:     frame.File,
+				Line:     frame.Line,
+			})
+		} else if frame.Function == "runtime.gopanic" {
+			panicked = true
+		}
+		if !more {
+			break
+		}
+	}
+	return stack
+}
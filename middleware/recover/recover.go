@@ -31,11 +31,12 @@ func New(config ...Config) fiber.Handler {
 					cfg.StackTraceHandler(c, r)
 				}
 
-				var ok bool
-				if err, ok = r.(error); !ok {
-					// Set error that will call the global error handler
-					err = fmt.Errorf("%v", r)
+				// Set error that will call the global error handler
+				e := newPanicError(c, r, cfg.RequestIDContextKey)
+				if cfg.Reporter != nil {
+					cfg.Reporter(c, e)
 				}
+				err = e
 			}
 		}()
 
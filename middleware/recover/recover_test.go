@@ -1,7 +1,10 @@
 package recover
 
 import (
+	"errors"
+	"io"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/gofiber/fiber/v2"
@@ -56,3 +59,68 @@ func Test_Recover_EnableStackTrace(t *testing.T) {
 	utils.AssertEqual(t, nil, err)
 	utils.AssertEqual(t, fiber.StatusInternalServerError, resp.StatusCode)
 }
+
+// go test -run Test_Recover_PanicError
+func Test_Recover_PanicError(t *testing.T) {
+	t.Parallel()
+
+	var reported *PanicError
+	app := fiber.New(fiber.Config{
+		ErrorHandler: func(c *fiber.Ctx, err error) error {
+			var e *PanicError
+			utils.AssertEqual(t, true, errors.As(err, &e))
+			utils.AssertEqual(t, reported, e)
+			return c.Status(fiber.StatusInternalServerError).SendString("request " + e.RequestID)
+		},
+	})
+
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("requestid", "abc")
+		return c.Next()
+	})
+	app.Use(New(Config{
+		Reporter: func(c *fiber.Ctx, err *PanicError) {
+			reported = err
+		},
+	}))
+
+	app.Get("/users/:id", func(c *fiber.Ctx) error {
+		panic("Hi, I'm an error!")
+	})
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/users/1", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusInternalServerError, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "request abc", string(body))
+
+	utils.AssertEqual(t, "Hi, I'm an error!", reported.Error())
+	utils.AssertEqual(t, fiber.MethodGet, reported.Method)
+	utils.AssertEqual(t, "/users/1", reported.Path)
+	utils.AssertEqual(t, "/users/:id", reported.Route)
+	utils.AssertEqual(t, "abc", reported.RequestID)
+	utils.AssertEqual(t, nil, reported.Unwrap())
+
+	// The stack starts at the panicking handler
+	utils.AssertEqual(t, true, len(reported.Stack) > 0)
+	utils.AssertEqual(t, true, strings.HasSuffix(reported.Stack[0].File, "recover_test.go"))
+	utils.AssertEqual(t, true, strings.Contains(reported.Stack[0].Function, "Test_Recover_PanicError"))
+	utils.AssertEqual(t, true, strings.HasPrefix(reported.StackTrace(), reported.Stack[0].Function+"\n\t"))
+}
+
+// go test -run Test_Recover_Error
+func Test_Recover_Error(t *testing.T) {
+	t.Parallel()
+
+	app := fiber.New()
+	app.Use(New())
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		panic(fiber.ErrBadRequest)
+	})
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusBadRequest, resp.StatusCode)
+}
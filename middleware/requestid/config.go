@@ -18,15 +18,29 @@ type Config struct {
 	Header string
 
 	// Generator defines a function to generate the unique identifier.
+	// The package provides ULID, KSUID and Snowflake generators.
 	//
 	// Optional. Default: utils.UUID
 	Generator func() string
 
+	// Validator reports whether a request ID received in the request header
+	// is accepted. Invalid IDs are replaced with a generated ID.
+	//
+	// Optional. Default: IsValid
+	Validator func(id string) bool
+
 	// ContextKey defines the key used when storing the request ID in
 	// the locals for a specific request.
 	//
 	// Optional. Default: requestid
 	ContextKey string
+
+	// DisablePropagation disables forwarding the request ID in the Header
+	// of requests made by agents with the user context of the request and
+	// by the proxy middleware.
+	//
+	// Optional. Default: false
+	DisablePropagation bool
 }
 
 // ConfigDefault is the default config
@@ -34,6 +48,7 @@ var ConfigDefault = Config{
 	Next:       nil,
 	Header:     fiber.HeaderXRequestID,
 	Generator:  utils.UUID,
+	Validator:  IsValid,
 	ContextKey: "requestid",
 }
 
@@ -51,11 +66,18 @@ func configDefault(config ...Config) Config {
 	if cfg.Header == "" {
 		cfg.Header = ConfigDefault.Header
 	}
+
 	if cfg.Generator == nil {
 		cfg.Generator = ConfigDefault.Generator
 	}
+
+	if cfg.Validator == nil {
+		cfg.Validator = ConfigDefault.Validator
+	}
+
 	if cfg.ContextKey == "" {
 		cfg.ContextKey = ConfigDefault.ContextKey
 	}
+
 	return cfg
 }
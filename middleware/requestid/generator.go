@@ -0,0 +1,101 @@
+package requestid
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	base62    = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+	// ksuidEpoch is the epoch of KSUID timestamps, 2014-05-13T16:53:20Z
+	ksuidEpoch = 1400000000
+	// snowflakeEpoch is the epoch of snowflake timestamps, 2010-11-04T01:42:54.657Z
+	snowflakeEpoch = 1288834974657
+)
+
+func random(b []byte) {
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+}
+
+// ULID generates a Universally Unique Lexicographically Sortable Identifier,
+// a 26 character string of a 48 bit millisecond timestamp and 80 random bits.
+func ULID() string {
+	var b [16]byte
+	ms := uint64(time.Now().UnixNano() / int64(time.Millisecond))
+	b[0], b[1], b[2], b[3], b[4], b[5] = byte(ms>>40), byte(ms>>32), byte(ms>>24), byte(ms>>16), byte(ms>>8), byte(ms)
+	random(b[6:])
+
+	// Encode the 128 bits as 26 base32 characters of 5 bits, the first
+	// character only holds the 3 most significant bits
+	hi, lo := binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:])
+	var id [26]byte
+	for i := 25; i >= 0; i-- {
+		id[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(id[:])
+}
+
+// KSUID generates a K-Sortable Unique Identifier, a 27 character string of
+// a 32 bit second timestamp and 128 random bits.
+func KSUID() string {
+	var b [20]byte
+	binary.BigEndian.PutUint32(b[:4], uint32(time.Now().Unix()-ksuidEpoch))
+	random(b[4:])
+
+	// Encode the 160 bits in base62 by repeated division
+	var id [27]byte
+	for i := len(id) - 1; i >= 0; i-- {
+		var rem uint32
+		for j := range b {
+			v := rem<<8 | uint32(b[j])
+			b[j] = byte(v / 62)
+			rem = v % 62
+		}
+		id[i] = base62[rem]
+	}
+	return string(id[:])
+}
+
+// Snowflake returns a generator of snowflake IDs for the given node, which
+// must be between 0 and 1023. A snowflake ID is a 64 bit integer of a 41 bit
+// millisecond timestamp, a 10 bit node and a 12 bit sequence number.
+// Every node should have a unique number to prevent collisions.
+func Snowflake(node int64) func() string {
+	if node < 0 || node > 1023 {
+		panic("requestid: snowflake node must be between 0 and 1023")
+	}
+
+	var (
+		mu       sync.Mutex
+		last     int64
+		sequence int64
+	)
+	return func() string {
+		mu.Lock()
+		now := time.Now().UnixNano()/int64(time.Millisecond) - snowflakeEpoch
+		if now <= last {
+			now = last
+			sequence = (sequence + 1) & 0xfff
+			if sequence == 0 {
+				// Sequence exhausted, continue with the next millisecond
+				now++
+			}
+		} else {
+			sequence = 0
+		}
+		last = now
+		id := now<<22 | node<<12 | sequence
+		mu.Unlock()
+
+		return strconv.FormatInt(id, 10)
+	}
+}
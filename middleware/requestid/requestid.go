@@ -1,22 +1,54 @@
 package requestid
 
 import (
+	"context"
+	"sync"
+
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
 )
 
+// MaxLength is the maximum length of request IDs accepted by IsValid
+const MaxLength = 128
+
+type contextKey struct{}
+
+// requestID is the request ID stored in the user context
+type requestID struct {
+	id        string
+	header    string
+	propagate bool
+}
+
+var registerOnce sync.Once
+
 // New creates a new middleware handler
 func New(config ...Config) fiber.Handler {
 	// Set default config
 	cfg := configDefault(config...)
 
+	// Forward the request ID in outgoing requests
+	registerOnce.Do(func() {
+		fiber.RegisterContextPropagator(propagate)
+	})
+
 	// Return new handler
 	return func(c *fiber.Ctx) error {
 		// Don't execute middleware if Next returns true
 		if cfg.Next != nil && cfg.Next(c) {
 			return c.Next()
 		}
+
 		// Get id from request, else we generate one
-		rid := c.Get(cfg.Header, cfg.Generator())
+		rid := c.Get(cfg.Header)
+		if rid == "" || !cfg.Validator(rid) {
+			rid = cfg.Generator()
+			// Replace invalid ids, so they are not forwarded by the proxy middleware
+			c.Request().Header.Set(cfg.Header, rid)
+		} else {
+			// The header value is only valid for the lifetime of the request
+			rid = utils.CopyString(rid)
+		}
 
 		// Set new id to response header
 		c.Set(cfg.Header, rid)
@@ -24,7 +56,47 @@ func New(config ...Config) fiber.Handler {
 		// Add the request ID to locals
 		c.Locals(cfg.ContextKey, rid)
 
+		// Add the request ID to the user context
+		c.SetUserContext(context.WithValue(c.UserContext(), contextKey{}, requestID{
+			id:        rid,
+			header:    cfg.Header,
+			propagate: !cfg.DisablePropagation,
+		}))
+
 		// Continue stack
 		return c.Next()
 	}
 }
+
+// FromContext returns the request ID stored in ctx, i.e. c.UserContext(),
+// or an empty string
+func FromContext(ctx context.Context) string {
+	rid, _ := ctx.Value(contextKey{}).(requestID)
+	return rid.id
+}
+
+// IsValid reports whether id is a valid request ID: between 1 and MaxLength
+// characters of letters, digits and -_.:+=/
+func IsValid(id string) bool {
+	if len(id) == 0 || len(id) > MaxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		switch ch := id[i]; {
+		case ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z', ch >= '0' && ch <= '9':
+		case ch == '-', ch == '_', ch == '.', ch == ':', ch == '+', ch == '=', ch == '/':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// propagate sets the request ID header of outgoing requests
+func propagate(ctx context.Context, req *fiber.Request) {
+	rid, ok := ctx.Value(contextKey{}).(requestID)
+	if !ok || !rid.propagate || len(req.Header.Peek(rid.header)) > 0 {
+		return
+	}
+	req.Header.Set(rid.header, rid.id)
+}
@@ -1,11 +1,16 @@
 package requestid
 
 import (
+	"context"
+	"io"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/utils"
+	"github.com/valyala/fasthttp"
 )
 
 // go test -run Test_RequestID
@@ -73,3 +78,140 @@ func Test_RequestID_Locals(t *testing.T) {
 	utils.AssertEqual(t, nil, err)
 	utils.AssertEqual(t, reqId, ctxVal)
 }
+
+// go test -run Test_RequestID_Validation
+func Test_RequestID_Validation(t *testing.T) {
+	t.Parallel()
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Generator: func() string {
+			return "generated"
+		},
+	}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString(c.Get(fiber.HeaderXRequestID))
+	})
+
+	for id, expected := range map[string]string{
+		"01GF4M7Y3KJ0Q2WZ4C6G9V5N8T":           "01GF4M7Y3KJ0Q2WZ4C6G9V5N8T",
+		"abc-123_4.5:6+7=8/9":                  "abc-123_4.5:6+7=8/9",
+		"<script>":                             "generated",
+		"id with spaces":                       "generated",
+		strings.Repeat("a", MaxLength+1):       "generated",
+		strings.Repeat("a", MaxLength):         strings.Repeat("a", MaxLength),
+		"2a0e5b2c-6f4b-4b8e-9a1d-3f6c2b8e9d10": "2a0e5b2c-6f4b-4b8e-9a1d-3f6c2b8e9d10",
+	} {
+		req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+		req.Header.Set(fiber.HeaderXRequestID, id)
+		resp, err := app.Test(req)
+		utils.AssertEqual(t, nil, err)
+		utils.AssertEqual(t, expected, resp.Header.Get(fiber.HeaderXRequestID))
+
+		body, err := io.ReadAll(resp.Body)
+		utils.AssertEqual(t, nil, err)
+		utils.AssertEqual(t, expected, string(body))
+	}
+}
+
+// go test -run Test_RequestID_UserContext
+func Test_RequestID_UserContext(t *testing.T) {
+	t.Parallel()
+
+	app := fiber.New()
+	app.Use(New())
+	app.Get("/", func(c *fiber.Ctx) error {
+		req := fasthttp.AcquireRequest()
+		defer fasthttp.ReleaseRequest(req)
+		fiber.PropagateContext(c.UserContext(), req)
+
+		return c.SendString(FromContext(c.UserContext()) + "," + string(req.Header.Peek(fiber.HeaderXRequestID)))
+	})
+
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+	req.Header.Set(fiber.HeaderXRequestID, "abc")
+	resp, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "abc,abc", string(body))
+
+	utils.AssertEqual(t, "", FromContext(context.Background()))
+}
+
+// go test -run Test_RequestID_DisablePropagation
+func Test_RequestID_DisablePropagation(t *testing.T) {
+	t.Parallel()
+
+	app := fiber.New()
+	app.Use(New(Config{DisablePropagation: true}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		req := fasthttp.AcquireRequest()
+		defer fasthttp.ReleaseRequest(req)
+		fiber.PropagateContext(c.UserContext(), req)
+
+		return c.SendString(FromContext(c.UserContext()) + "," + string(req.Header.Peek(fiber.HeaderXRequestID)))
+	})
+
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+	req.Header.Set(fiber.HeaderXRequestID, "abc")
+	resp, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "abc,", string(body))
+}
+
+// go test -run Test_RequestID_Generators
+func Test_RequestID_Generators(t *testing.T) {
+	t.Parallel()
+
+	ulid := ULID()
+	utils.AssertEqual(t, 26, len(ulid))
+	utils.AssertEqual(t, true, IsValid(ulid))
+	utils.AssertEqual(t, true, strings.Trim(ulid, crockford) == "")
+	utils.AssertEqual(t, true, ulid[0] <= '7')
+
+	ksuid := KSUID()
+	utils.AssertEqual(t, 27, len(ksuid))
+	utils.AssertEqual(t, true, IsValid(ksuid))
+	utils.AssertEqual(t, true, strings.Trim(ksuid, base62) == "")
+
+	snowflake := Snowflake(42)
+	seen := make(map[string]struct{})
+	var last int64
+	for i := 0; i < 10000; i++ {
+		id := snowflake()
+		_, dup := seen[id]
+		utils.AssertEqual(t, false, dup)
+		seen[id] = struct{}{}
+
+		n, err := strconv.ParseInt(id, 10, 64)
+		utils.AssertEqual(t, nil, err)
+		utils.AssertEqual(t, true, n > last)
+		utils.AssertEqual(t, int64(42), n>>12&0x3ff)
+		last = n
+	}
+
+	defer func() {
+		utils.AssertEqual(t, "requestid: snowflake node must be between 0 and 1023", recover())
+	}()
+	Snowflake(1024)
+}
+
+// go test -v -run=^$ -bench=Benchmark_Generators -benchmem -count=4
+func Benchmark_Generators(b *testing.B) {
+	for name, generator := range map[string]func() string{
+		"UUID":      utils.UUID,
+		"ULID":      ULID,
+		"KSUID":     KSUID,
+		"Snowflake": Snowflake(1),
+	} {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				_ = generator()
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package timeout
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Config defines the config for middleware.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	//
+	// Optional. Default: nil
+	Next func(c *fiber.Ctx) bool
+
+	// Timeout is the maximum duration of the wrapped handler
+	//
+	// Optional. Default: 5 * time.Second
+	Timeout time.Duration
+
+	// Routes overrides Timeout for specific routes, keyed by route path
+	// as returned by c.Route().Path, i.e. "/users/:id"
+	//
+	// Optional. Default: nil
+	Routes map[string]time.Duration
+
+	// TimeoutErrors defines errors of the handler that are treated as a timeout,
+	// besides context.DeadlineExceeded.
+	//
+	// Optional. Default: nil
+	TimeoutErrors []error
+
+	// TimeoutHandler is called when the handler exceeded its deadline
+	//
+	// Optional. Default: func(c *fiber.Ctx) error {
+	//   return fiber.ErrRequestTimeout
+	// }
+	TimeoutHandler fiber.Handler
+
+	// Detach runs the handler on a detached copy of the context, so the response
+	// is sent as soon as the deadline passes even if the handler ignores
+	// c.UserContext(). The handler keeps running in the background and
+	// anything it writes after the deadline is discarded.
+	//
+	// Optional. Default: false
+	Detach bool
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	Next:    nil,
+	Timeout: 5 * time.Second,
+	TimeoutHandler: func(c *fiber.Ctx) error {
+		return fiber.ErrRequestTimeout
+	},
+	Detach: false,
+}
+
+// Helper function to set default values
+func configDefault(config ...Config) Config {
+	// Return default config if nothing provided
+	if len(config) < 1 {
+		return ConfigDefault
+	}
+
+	// Override default config
+	cfg := config[0]
+
+	// Set default values
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = ConfigDefault.Timeout
+	}
+	if cfg.TimeoutHandler == nil {
+		cfg.TimeoutHandler = ConfigDefault.TimeoutHandler
+	}
+	return cfg
+}
@@ -3,6 +3,8 @@ package timeout
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -15,16 +17,113 @@ func New(h fiber.Handler, t time.Duration, tErrs ...error) fiber.Handler {
 		defer cancel()
 		ctx.SetUserContext(timeoutContext)
 		if err := h(ctx); err != nil {
-			if errors.Is(err, context.DeadlineExceeded) {
+			if isTimeout(err, tErrs) {
 				return fiber.ErrRequestTimeout
 			}
-			for i := range tErrs {
-				if errors.Is(err, tErrs[i]) {
-					return fiber.ErrRequestTimeout
-				}
+			return err
+		}
+		return nil
+	}
+}
+
+// NewWithConfig wraps the handler h with a deadline defined by the config
+func NewWithConfig(h fiber.Handler, config ...Config) fiber.Handler {
+	// Set default config
+	cfg := configDefault(config...)
+
+	// Return new handler
+	return func(c *fiber.Ctx) error {
+		// Don't execute middleware if Next returns true
+		if cfg.Next != nil && cfg.Next(c) {
+			return h(c)
+		}
+
+		t := cfg.Timeout
+		if d, ok := cfg.Routes[c.Route().Path]; ok {
+			t = d
+		}
+
+		ctx, cancel := context.WithTimeout(c.UserContext(), t)
+		if cfg.Detach {
+			return detached(c, h, ctx, cancel, &cfg)
+		}
+		defer cancel()
+
+		c.SetUserContext(ctx)
+		if err := h(c); err != nil {
+			if isTimeout(err, cfg.TimeoutErrors) {
+				return cfg.TimeoutHandler(c)
 			}
 			return err
 		}
 		return nil
 	}
 }
+
+// detached runs the handler on a copy of c and returns as soon as
+// the handler finishes or the deadline of ctx passes.
+func detached(c *fiber.Ctx, h fiber.Handler, ctx context.Context, cancel context.CancelFunc, cfg *Config) error {
+	// c may be reused once the request is answered
+	app := c.App()
+	d := c.Detach()
+	d.SetUserContext(ctx)
+
+	done := make(chan error, 1)
+	go func() {
+		defer cancel()
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("timeout: panic in handler: %v", r)
+			}
+		}()
+		done <- h(d)
+	}()
+
+	select {
+	case err := <-done:
+		copyResponse(app, c, d)
+		if err != nil && isTimeout(err, cfg.TimeoutErrors) {
+			return cfg.TimeoutHandler(c)
+		}
+		return err
+	case <-ctx.Done():
+		// Release the copy once the handler gave up
+		go func() {
+			<-done
+			app.ReleaseCtx(d)
+		}()
+		return cfg.TimeoutHandler(c)
+	}
+}
+
+// copyResponse copies the response of the detached copy d to c and releases d
+func copyResponse(app *fiber.App, c, d *fiber.Ctx) {
+	res := d.Response()
+	res.CopyTo(c.Response())
+	if !res.IsBodyStream() {
+		app.ReleaseCtx(d)
+		return
+	}
+
+	// CopyTo doesn't copy a body stream, i.e. of SendFile or SendStream,
+	// so it is piped to c while the response is written
+	pr, pw := io.Pipe()
+	c.Response().SetBodyStream(pr, res.Header.ContentLength())
+	go func() {
+		_ = pw.CloseWithError(res.BodyWriteTo(pw))
+		app.ReleaseCtx(d)
+	}()
+}
+
+// isTimeout reports whether err is a deadline or one of the given timeout errors
+func isTimeout(err error, tErrs []error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	for i := range tErrs {
+		if errors.Is(err, tErrs[i]) {
+			return true
+		}
+	}
+	return false
+}
@@ -4,7 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -70,6 +73,127 @@ func Test_TimeoutWithCustomError(t *testing.T) {
 	testSucces("30")
 }
 
+// go test -run Test_TimeoutWithConfig
+func Test_TimeoutWithConfig(t *testing.T) {
+	app := fiber.New()
+	h := NewWithConfig(func(c *fiber.Ctx) error {
+		sleepTime, _ := time.ParseDuration(c.Params("sleepTime") + "ms")
+		return sleepWithContext(c.UserContext(), sleepTime, ErrFooTimeOut)
+	}, Config{
+		Timeout:       100 * time.Millisecond,
+		TimeoutErrors: []error{ErrFooTimeOut},
+		Routes: map[string]time.Duration{
+			"/slow/:sleepTime": 400 * time.Millisecond,
+		},
+		TimeoutHandler: func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusServiceUnavailable).SendString("timeout")
+		},
+	})
+	app.Get("/test/:sleepTime", h)
+	app.Get("/slow/:sleepTime", h)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/test/300", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, fiber.StatusServiceUnavailable, resp.StatusCode, "Status code")
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/slow/300", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode, "Status code")
+}
+
+// go test -run Test_TimeoutWithConfig_Next
+func Test_TimeoutWithConfig_Next(t *testing.T) {
+	app := fiber.New()
+	app.Get("/", NewWithConfig(func(c *fiber.Ctx) error {
+		_, ok := c.UserContext().Deadline()
+		utils.AssertEqual(t, false, ok)
+		return nil
+	}, Config{
+		Next: func(_ *fiber.Ctx) bool {
+			return true
+		},
+	}))
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode, "Status code")
+}
+
+// go test -run Test_TimeoutWithConfig_Detach -race
+func Test_TimeoutWithConfig_Detach(t *testing.T) {
+	finished := make(chan string, 1)
+
+	app := fiber.New()
+	app.Get("/test/:sleepTime", NewWithConfig(func(c *fiber.Ctx) error {
+		// ignores the context on purpose
+		sleepTime, _ := time.ParseDuration(c.Params("sleepTime") + "ms")
+		time.Sleep(sleepTime)
+		if sleepTime > 100*time.Millisecond {
+			finished <- c.Params("sleepTime")
+		}
+		c.Set("X-Handler", "done")
+		return c.SendString("finished")
+	}, Config{
+		Timeout: 100 * time.Millisecond,
+		Detach:  true,
+	}))
+
+	start := time.Now()
+	resp, err := app.Test(httptest.NewRequest("GET", "/test/500", nil), 2000)
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, fiber.StatusRequestTimeout, resp.StatusCode, "Status code")
+	utils.AssertEqual(t, true, time.Since(start) < 400*time.Millisecond)
+
+	// The handler keeps running on the detached copy
+	utils.AssertEqual(t, "500", <-finished)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/test/10", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode, "Status code")
+	utils.AssertEqual(t, "done", resp.Header.Get("X-Handler"))
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "finished", string(body))
+}
+
+// go test -run Test_TimeoutWithConfig_Detach_Panic
+func Test_TimeoutWithConfig_Detach_Panic(t *testing.T) {
+	app := fiber.New()
+	app.Get("/", NewWithConfig(func(c *fiber.Ctx) error {
+		panic("boom")
+	}, Config{Detach: true}))
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err, "app.Test(req)")
+	utils.AssertEqual(t, fiber.StatusInternalServerError, resp.StatusCode, "Status code")
+}
+
+// go test -run Test_TimeoutWithConfig_Detach_Stream
+func Test_TimeoutWithConfig_Detach_Stream(t *testing.T) {
+	app := fiber.New()
+	app.Get("/stream", NewWithConfig(func(c *fiber.Ctx) error {
+		return c.SendStream(strings.NewReader("streamed body"))
+	}, Config{Detach: true}))
+	app.Get("/file", NewWithConfig(func(c *fiber.Ctx) error {
+		return c.SendFile("./README.md")
+	}, Config{Detach: true}))
+
+	file, err := os.ReadFile("./README.md")
+	utils.AssertEqual(t, nil, err)
+
+	for path, expected := range map[string]string{
+		"/stream": "streamed body",
+		"/file":   string(file),
+	} {
+		resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+		utils.AssertEqual(t, nil, err, "app.Test(req)")
+		utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode, "Status code")
+		body, err := io.ReadAll(resp.Body)
+		utils.AssertEqual(t, nil, err)
+		utils.AssertEqual(t, expected, string(body), path)
+	}
+}
+
 func sleepWithContext(ctx context.Context, d time.Duration, te error) error {
 	timer := time.NewTimer(d)
 	select {
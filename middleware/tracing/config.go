@@ -0,0 +1,66 @@
+package tracing
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// Config defines the config for middleware.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	//
+	// Optional. Default: nil
+	Next func(c *fiber.Ctx) bool
+
+	// Exporter receives the ended spans
+	//
+	// Optional. Default: nil, spans are not exported
+	Exporter Exporter
+
+	// SpanName returns the name of the request span.
+	// It is called after the request was handled, so c.Route() is the matched route.
+	//
+	// Optional. Default: func(c *fiber.Ctx) string {
+	//   return c.Method() + " " + c.Route().Path
+	// }
+	SpanName func(c *fiber.Ctx) string
+
+	// Sampler decides whether a new trace is sampled. Requests with
+	// a traceparent header follow the sampling decision of the caller.
+	//
+	// Optional. Default: func(c *fiber.Ctx) bool {
+	//   return true
+	// }
+	Sampler func(c *fiber.Ctx) bool
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	Next:     nil,
+	Exporter: nil,
+	SpanName: func(c *fiber.Ctx) string {
+		return c.Method() + " " + c.Route().Path
+	},
+	Sampler: func(c *fiber.Ctx) bool {
+		return true
+	},
+}
+
+// Helper function to set default values
+func configDefault(config ...Config) Config {
+	// Return default config if nothing provided
+	if len(config) < 1 {
+		return ConfigDefault
+	}
+
+	// Override default config
+	cfg := config[0]
+
+	// Set default values
+	if cfg.SpanName == nil {
+		cfg.SpanName = ConfigDefault.SpanName
+	}
+	if cfg.Sampler == nil {
+		cfg.Sampler = ConfigDefault.Sampler
+	}
+	return cfg
+}
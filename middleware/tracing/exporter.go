@@ -0,0 +1,43 @@
+package tracing
+
+import (
+	"sync"
+)
+
+// Exporter receives ended spans, i.e. to send them to a tracing backend.
+// ExportSpan is called synchronously when a span ends, so it should not block.
+type Exporter interface {
+	ExportSpan(span *Span)
+}
+
+// InMemoryExporter stores ended spans in memory, it is meant for tests
+type InMemoryExporter struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+// NewInMemoryExporter creates a new in-memory exporter
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{}
+}
+
+// ExportSpan implements Exporter
+func (e *InMemoryExporter) ExportSpan(span *Span) {
+	e.mu.Lock()
+	e.spans = append(e.spans, span)
+	e.mu.Unlock()
+}
+
+// Spans returns the exported spans in the order they ended
+func (e *InMemoryExporter) Spans() []*Span {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]*Span(nil), e.spans...)
+}
+
+// Reset removes all exported spans
+func (e *InMemoryExporter) Reset() {
+	e.mu.Lock()
+	e.spans = nil
+	e.mu.Unlock()
+}
@@ -0,0 +1,120 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// W3C Trace Context headers
+// https://www.w3.org/TR/trace-context/
+const (
+	HeaderTraceparent = "traceparent"
+	HeaderTracestate  = "tracestate"
+)
+
+const (
+	traceparentLen    = 55
+	maxTracestateLen  = 512
+	supportedVersion  = "00"
+	invalidVersion    = "ff"
+	flagSampled       = 0x01
+	traceparentFormat = "00-00000000000000000000000000000000-0000000000000000-00"
+)
+
+// ParseTraceparent parses the value of a traceparent header.
+// It reports false if the value is malformed or carries invalid IDs.
+func ParseTraceparent(value string) (sc SpanContext, ok bool) {
+	value = utils.Trim(value, ' ')
+	if len(value) < traceparentLen {
+		return sc, false
+	}
+	version := value[:2]
+	if !isLowerHex(version) || version == invalidVersion {
+		return sc, false
+	}
+	// Version 00 has a fixed length, future versions may append fields
+	if version == supportedVersion && len(value) != traceparentLen {
+		return sc, false
+	}
+	if len(value) > traceparentLen && value[traceparentLen] != '-' {
+		return sc, false
+	}
+	if value[2] != '-' || value[35] != '-' || value[52] != '-' {
+		return sc, false
+	}
+
+	traceID, spanID, flags := value[3:35], value[36:52], value[53:55]
+	if !isLowerHex(traceID) || !isLowerHex(spanID) || !isLowerHex(flags) {
+		return sc, false
+	}
+	_, _ = hex.Decode(sc.TraceID[:], utils.UnsafeBytes(traceID))
+	_, _ = hex.Decode(sc.SpanID[:], utils.UnsafeBytes(spanID))
+
+	var f [1]byte
+	_, _ = hex.Decode(f[:], utils.UnsafeBytes(flags))
+	sc.Sampled = f[0]&flagSampled != 0
+	sc.Remote = true
+
+	return sc, sc.IsValid()
+}
+
+// FormatTraceparent formats the span context as traceparent header value
+func FormatTraceparent(sc SpanContext) string {
+	b := []byte(traceparentFormat)
+	hex.Encode(b[3:35], sc.TraceID[:])
+	hex.Encode(b[36:52], sc.SpanID[:])
+	if sc.Sampled {
+		b[54] = '1'
+	}
+	return string(b)
+}
+
+// Extract returns the span context of the traceparent and tracestate
+// headers of the request. It reports false if there is no valid traceparent.
+func Extract(c *fiber.Ctx) (SpanContext, bool) {
+	sc, ok := ParseTraceparent(c.Get(HeaderTraceparent))
+	if !ok {
+		return SpanContext{}, false
+	}
+	// Multiple tracestate headers are combined as a single list
+	var states []string
+	c.Request().Header.VisitAll(func(key, value []byte) {
+		if utils.EqualFold(utils.UnsafeString(key), HeaderTracestate) {
+			states = append(states, string(value))
+		}
+	})
+	if state := strings.Join(states, ","); len(state) <= maxTracestateLen {
+		sc.TraceState = state
+	}
+	return sc, true
+}
+
+// Inject sets the traceparent and tracestate headers of an outgoing
+// request to the span stored in ctx. It is registered as fiber.ContextPropagator,
+// so the fiber Agent and the proxy middleware call it automatically.
+func Inject(ctx context.Context, req *fiber.Request) {
+	span := SpanFromContext(ctx)
+	if span == nil {
+		return
+	}
+	sc := span.SpanContext()
+	req.Header.Set(HeaderTraceparent, FormatTraceparent(sc))
+	if sc.TraceState != "" {
+		req.Header.Set(HeaderTracestate, sc.TraceState)
+	} else {
+		req.Header.Del(HeaderTracestate)
+	}
+}
+
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if (s[i] < '0' || s[i] > '9') && (s[i] < 'a' || s[i] > 'f') {
+			return false
+		}
+	}
+	return true
+}
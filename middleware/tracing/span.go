@@ -0,0 +1,216 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// TraceID identifies a trace
+type TraceID [16]byte
+
+// String returns the hex encoded trace ID
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// SpanID identifies a span within a trace
+type SpanID [8]byte
+
+// String returns the hex encoded span ID
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// SpanContext is the part of a span that is propagated across process boundaries
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Sampled    bool
+	TraceState string
+	// Remote is true if the span context was extracted from a request
+	Remote bool
+}
+
+// IsValid reports whether trace and span ID are set
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+}
+
+// StatusCode is the status of a span
+type StatusCode int
+
+// Span status codes
+const (
+	StatusUnset StatusCode = iota
+	StatusOK
+	StatusError
+)
+
+// Span is a unit of work, i.e. the handling of a request
+type Span struct {
+	mu sync.Mutex
+
+	name          string
+	spanContext   SpanContext
+	parent        SpanContext
+	start         time.Time
+	end           time.Time
+	attributes    map[string]string
+	status        StatusCode
+	statusMessage string
+	ended         bool
+	exporter      Exporter
+}
+
+// newSpan starts a span as child of parent, a new trace is started if parent is invalid
+func newSpan(name string, parent SpanContext, sampled bool, exporter Exporter) *Span {
+	s := &Span{
+		name:       name,
+		parent:     parent,
+		start:      time.Now(),
+		attributes: make(map[string]string),
+		exporter:   exporter,
+	}
+	s.spanContext.Sampled = sampled
+	if parent.IsValid() {
+		s.spanContext.TraceID = parent.TraceID
+		s.spanContext.TraceState = parent.TraceState
+		s.spanContext.Sampled = parent.Sampled
+	} else {
+		_, _ = rand.Read(s.spanContext.TraceID[:])
+	}
+	_, _ = rand.Read(s.spanContext.SpanID[:])
+	return s
+}
+
+type spanKey struct{}
+
+// ContextWithSpan returns a copy of ctx carrying the span
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the span stored in ctx or nil
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// StartSpan starts a child span of the span stored in ctx, i.e. c.UserContext(),
+// and returns a copy of ctx carrying the new span. The span has to be ended by the caller.
+func StartSpan(ctx context.Context, name string) (context.Context, *Span) {
+	var (
+		parent   SpanContext
+		exporter Exporter
+	)
+	if p := SpanFromContext(ctx); p != nil {
+		parent = p.SpanContext()
+		exporter = p.exporter
+	}
+	span := newSpan(name, parent, true, exporter)
+	return ContextWithSpan(ctx, span), span
+}
+
+// Name returns the name of the span
+func (s *Span) Name() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.name
+}
+
+// SetName changes the name of the span
+func (s *Span) SetName(name string) {
+	s.mu.Lock()
+	if !s.ended {
+		s.name = name
+	}
+	s.mu.Unlock()
+}
+
+// SpanContext returns the span context
+func (s *Span) SpanContext() SpanContext {
+	return s.spanContext
+}
+
+// Parent returns the span context of the parent, it is invalid for root spans
+func (s *Span) Parent() SpanContext {
+	return s.parent
+}
+
+// StartTime returns the time the span was started
+func (s *Span) StartTime() time.Time {
+	return s.start
+}
+
+// EndTime returns the time the span was ended
+func (s *Span) EndTime() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.end
+}
+
+// SetAttribute sets an attribute of the span
+func (s *Span) SetAttribute(key, value string) {
+	s.mu.Lock()
+	if !s.ended {
+		s.attributes[key] = value
+	}
+	s.mu.Unlock()
+}
+
+// Attributes returns a copy of the attributes of the span
+func (s *Span) Attributes() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	attrs := make(map[string]string, len(s.attributes))
+	for k, v := range s.attributes {
+		attrs[k] = v
+	}
+	return attrs
+}
+
+// SetStatus sets the status of the span
+func (s *Span) SetStatus(code StatusCode, message string) {
+	s.mu.Lock()
+	if !s.ended {
+		s.status = code
+		s.statusMessage = message
+	}
+	s.mu.Unlock()
+}
+
+// Status returns the status code and message of the span
+func (s *Span) Status() (StatusCode, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status, s.statusMessage
+}
+
+// RecordError marks the span as failed with the error
+func (s *Span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.SetAttribute("error.message", err.Error())
+	s.SetStatus(StatusError, err.Error())
+}
+
+// End ends the span and exports it if it is sampled.
+// Calling End more than once has no effect.
+func (s *Span) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mu.Unlock()
+
+	if s.exporter != nil && s.spanContext.Sampled {
+		s.exporter.ExportSpan(s)
+	}
+}
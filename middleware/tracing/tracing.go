@@ -0,0 +1,66 @@
+package tracing
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+var registerOnce sync.Once
+
+// New creates a new middleware handler
+func New(config ...Config) fiber.Handler {
+	// Set default config
+	cfg := configDefault(config...)
+
+	// Propagate the trace context into outgoing requests
+	registerOnce.Do(func() {
+		fiber.RegisterContextPropagator(Inject)
+	})
+
+	// Return new handler
+	return func(c *fiber.Ctx) error {
+		// Don't execute middleware if Next returns true
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		parent, ok := Extract(c)
+		sampled := ok && parent.Sampled || !ok && cfg.Sampler(c)
+
+		span := newSpan(c.Method(), parent, sampled, cfg.Exporter)
+		c.SetUserContext(ContextWithSpan(c.UserContext(), span))
+
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = fiber.StatusInternalServerError
+			var e *fiber.Error
+			if errors.As(err, &e) {
+				status = e.Code
+			}
+		}
+		// Client errors are not failures of the server span
+		switch {
+		case status >= fiber.StatusInternalServerError && err != nil:
+			span.RecordError(err)
+		case status >= fiber.StatusInternalServerError:
+			span.SetStatus(StatusError, utils.StatusMessage(status))
+		case err != nil:
+			span.SetAttribute("error.message", err.Error())
+		}
+
+		span.SetName(utils.CopyString(cfg.SpanName(c)))
+		span.SetAttribute("http.method", utils.CopyString(c.Method()))
+		span.SetAttribute("http.route", c.Route().Path)
+		span.SetAttribute("http.target", utils.CopyString(c.OriginalURL()))
+		span.SetAttribute("http.status_code", strconv.Itoa(status))
+		span.End()
+
+		return err
+	}
+}
@@ -0,0 +1,228 @@
+package tracing
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+// go test -run Test_Tracing_Traceparent
+func Test_Tracing_Traceparent(t *testing.T) {
+	t.Parallel()
+
+	sc, ok := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	utils.AssertEqual(t, true, ok)
+	utils.AssertEqual(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+	utils.AssertEqual(t, "00f067aa0ba902b7", sc.SpanID.String())
+	utils.AssertEqual(t, true, sc.Sampled)
+	utils.AssertEqual(t, true, sc.Remote)
+	utils.AssertEqual(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", FormatTraceparent(sc))
+
+	// future versions may append fields
+	_, ok = ParseTraceparent("cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-what-the-future-will-be-like")
+	utils.AssertEqual(t, true, ok)
+
+	for _, invalid := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00_4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-0x",
+	} {
+		_, ok = ParseTraceparent(invalid)
+		utils.AssertEqual(t, false, ok, invalid)
+	}
+}
+
+// go test -run Test_Tracing_Root_Span
+func Test_Tracing_Root_Span(t *testing.T) {
+	t.Parallel()
+
+	exporter := NewInMemoryExporter()
+
+	app := fiber.New()
+	app.Use(New(Config{Exporter: exporter}))
+	app.Get("/users/:id", func(c *fiber.Ctx) error {
+		span := SpanFromContext(c.UserContext())
+		utils.AssertEqual(t, true, span != nil)
+		return c.SendString(span.SpanContext().TraceID.String())
+	})
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/users/42?page=1", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+
+	spans := exporter.Spans()
+	utils.AssertEqual(t, 1, len(spans))
+	span := spans[0]
+	utils.AssertEqual(t, "GET /users/:id", span.Name())
+	utils.AssertEqual(t, string(body), span.SpanContext().TraceID.String())
+	utils.AssertEqual(t, false, span.Parent().IsValid())
+	utils.AssertEqual(t, map[string]string{
+		"http.method":      "GET",
+		"http.route":       "/users/:id",
+		"http.target":      "/users/42?page=1",
+		"http.status_code": "200",
+	}, span.Attributes())
+	code, _ := span.Status()
+	utils.AssertEqual(t, StatusUnset, code)
+	utils.AssertEqual(t, false, span.EndTime().Before(span.StartTime()))
+}
+
+// go test -run Test_Tracing_Remote_Parent
+func Test_Tracing_Remote_Parent(t *testing.T) {
+	t.Parallel()
+
+	exporter := NewInMemoryExporter()
+
+	app := fiber.New()
+	app.Use(New(Config{Exporter: exporter}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return nil
+	})
+
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+	req.Header.Set(HeaderTraceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Add(HeaderTracestate, "congo=t61rcWkgMzE")
+	req.Header.Add(HeaderTracestate, "rojo=00f067aa0ba902b7")
+	_, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+
+	spans := exporter.Spans()
+	utils.AssertEqual(t, 1, len(spans))
+	sc := spans[0].SpanContext()
+	utils.AssertEqual(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+	utils.AssertEqual(t, "00f067aa0ba902b7", spans[0].Parent().SpanID.String())
+	utils.AssertEqual(t, true, sc.SpanID != spans[0].Parent().SpanID)
+	utils.AssertEqual(t, "congo=t61rcWkgMzE,rojo=00f067aa0ba902b7", sc.TraceState)
+
+	// The sampling decision of the caller is respected
+	exporter.Reset()
+	req = httptest.NewRequest(fiber.MethodGet, "/", nil)
+	req.Header.Set(HeaderTraceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	_, err = app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, 0, len(exporter.Spans()))
+}
+
+// go test -run Test_Tracing_Errors
+func Test_Tracing_Errors(t *testing.T) {
+	t.Parallel()
+
+	exporter := NewInMemoryExporter()
+
+	app := fiber.New()
+	app.Use(New(Config{Exporter: exporter}))
+	app.Get("/fail", func(c *fiber.Ctx) error {
+		return errors.New("database unavailable")
+	})
+	app.Get("/missing", func(c *fiber.Ctx) error {
+		return fiber.ErrNotFound
+	})
+
+	_, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/fail", nil))
+	utils.AssertEqual(t, nil, err)
+	_, err = app.Test(httptest.NewRequest(fiber.MethodGet, "/missing", nil))
+	utils.AssertEqual(t, nil, err)
+
+	spans := exporter.Spans()
+	utils.AssertEqual(t, 2, len(spans))
+
+	code, msg := spans[0].Status()
+	utils.AssertEqual(t, StatusError, code)
+	utils.AssertEqual(t, "database unavailable", msg)
+	utils.AssertEqual(t, "500", spans[0].Attributes()["http.status_code"])
+
+	code, _ = spans[1].Status()
+	utils.AssertEqual(t, StatusUnset, code)
+	utils.AssertEqual(t, "404", spans[1].Attributes()["http.status_code"])
+}
+
+// go test -run Test_Tracing_Child_Span
+func Test_Tracing_Child_Span(t *testing.T) {
+	t.Parallel()
+
+	exporter := NewInMemoryExporter()
+
+	app := fiber.New()
+	app.Use(New(Config{Exporter: exporter}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		_, span := StartSpan(c.UserContext(), "query")
+		span.SetAttribute("db.system", "postgresql")
+		span.End()
+		return nil
+	})
+
+	_, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	spans := exporter.Spans()
+	utils.AssertEqual(t, 2, len(spans))
+	utils.AssertEqual(t, "query", spans[0].Name())
+	utils.AssertEqual(t, spans[1].SpanContext().TraceID, spans[0].SpanContext().TraceID)
+	utils.AssertEqual(t, spans[1].SpanContext().SpanID, spans[0].Parent().SpanID)
+}
+
+// go test -run Test_Tracing_Agent_Propagation
+func Test_Tracing_Agent_Propagation(t *testing.T) {
+	t.Parallel()
+
+	ln := fasthttputil.NewInmemoryListener()
+	downstream := fiber.New(fiber.Config{DisableStartupMessage: true})
+	downstream.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString(c.Get(HeaderTraceparent))
+	})
+	go func() { utils.AssertEqual(t, nil, downstream.Listener(ln)) }()
+
+	exporter := NewInMemoryExporter()
+
+	app := fiber.New()
+	app.Use(New(Config{Exporter: exporter}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		a := fiber.Get("http://example.com").WithContext(c.UserContext())
+		a.HostClient.Dial = func(addr string) (net.Conn, error) { return ln.Dial() }
+		code, body, errs := a.String()
+		utils.AssertEqual(t, 0, len(errs))
+		return c.Status(code).SendString(body)
+	})
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+	utils.AssertEqual(t, nil, err)
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+
+	spans := exporter.Spans()
+	utils.AssertEqual(t, 1, len(spans))
+	utils.AssertEqual(t, FormatTraceparent(spans[0].SpanContext()), string(body))
+}
+
+// go test -run Test_Tracing_Next
+func Test_Tracing_Next(t *testing.T) {
+	t.Parallel()
+
+	exporter := NewInMemoryExporter()
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Exporter: exporter,
+		Next: func(_ *fiber.Ctx) bool {
+			return true
+		},
+	}))
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusNotFound, resp.StatusCode)
+	utils.AssertEqual(t, 0, len(exporter.Spans()))
+}
@@ -41,6 +41,7 @@ type Router interface {
 	Mount(prefix string, fiber *App) Router
 
 	Name(name string) Router
+	Meta(key string, value interface{}) Router
 }
 
 // Route is a struct that holds all metadata for each registered handler.
@@ -55,11 +56,12 @@ type Route struct {
 	group       *Group      // Group instance. used for routes in groups
 
 	// Public fields
-	Method   string    `json:"method"` // HTTP method
-	Name     string    `json:"name"`   // Route's name
-	Path     string    `json:"path"`   // Original registered route path
-	Params   []string  `json:"params"` // Case sensitive param keys
-	Handlers []Handler `json:"-"`      // Ctx handlers
+	Method   string                 `json:"method"` // HTTP method
+	Name     string                 `json:"name"`   // Route's name
+	Path     string                 `json:"path"`   // Original registered route path
+	Params   []string               `json:"params"` // Case sensitive param keys
+	Metadata map[string]interface{} `json:"-"`      // Route's metadata
+	Handlers []Handler              `json:"-"`      // Ctx handlers
 }
 
 func (r *Route) match(detectionPath, path string, params *[maxParams]string) (match bool) {
@@ -269,6 +271,7 @@ func (app *App) register(method, pathRaw string, group *Group, handlers ...Handl
 		// Public data
 		Path:     pathRaw,
 		Method:   method,
+		Metadata: make(map[string]interface{}),
 		Handlers: handlers,
 	}
 	// Increment global handler count
@@ -416,6 +419,7 @@ func (app *App) registerStatic(prefix, root string, config ...Static) Router {
 		// Public data
 		Method:   MethodGet,
 		Path:     prefix,
+		Metadata: make(map[string]interface{}),
 		Handlers: []Handler{handler},
 	}
 	// Increment global handler count
@@ -427,6 +431,14 @@ func (app *App) registerStatic(prefix, root string, config ...Static) Router {
 	return app
 }
 
+// shareMetadata lets the latest route use the metadata map of a route registered
+// before, so the HEAD and GET routes of app.Get are described by the same map
+func (app *App) shareMetadata(metadata map[string]interface{}) {
+	app.mutex.Lock()
+	app.latestRoute.Metadata = metadata
+	app.mutex.Unlock()
+}
+
 func (app *App) addRoute(method string, route *Route, isMounted ...bool) {
 	// Check mounted routes
 	var mounted bool